@@ -0,0 +1,209 @@
+// Package archive creates the tar archives sent to the docker daemon,
+// like build contexts and the content copied to containers, and safely
+// extracts the archives returned by the daemon.
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Compression is the compression algorithm of an archive.
+type Compression int
+
+const (
+	// Uncompressed doesn't compress the archive.
+	Uncompressed Compression = iota
+	// Gzip compresses the archive with gzip.
+	Gzip
+)
+
+// normalizedModTime is the modification time of the entries in normalized archives.
+var normalizedModTime = time.Unix(0, 0)
+
+// TarOptions holds the options to create an archive.
+type TarOptions struct {
+	// IncludeFiles holds the paths to archive, relative to the source directory.
+	// The whole directory is archived if it's empty.
+	IncludeFiles []string
+	// ExcludePatterns holds the patterns of the paths to exclude from the archive,
+	// with the syntax of the .dockerignore file. See PatternMatcher.
+	ExcludePatterns []string
+	// Compression is the compression algorithm of the archive.
+	Compression Compression
+	// IncludeSourceDir includes the entry of the source directory itself,
+	// as ./, when one of the included paths is the whole directory.
+	IncludeSourceDir bool
+	// RebaseNames renames the included paths, and everything inside them,
+	// in the archive. The keys are the included paths, the values their
+	// new names.
+	RebaseNames map[string]string
+	// Normalize resets the ownership, the modification times and the permissions
+	// of the entries, so that the archive only depends on the names and the
+	// content of the files. Executable files keep the executable bit.
+	Normalize bool
+}
+
+// Tar archives the directory srcPath with the given options.
+// The paths in the archive are relative to srcPath.
+// It's up to the caller to close the io.ReadCloser returned.
+func Tar(srcPath string, options *TarOptions) (io.ReadCloser, error) {
+	if options == nil {
+		options = &TarOptions{}
+	}
+	if _, err := os.Stat(srcPath); err != nil {
+		return nil, err
+	}
+	pm, err := NewPatternMatcher(options.ExcludePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	includes := options.IncludeFiles
+	if len(includes) == 0 {
+		includes = []string{"."}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(pw, srcPath, includes, pm, options))
+	}()
+	return pr, nil
+}
+
+func writeTar(w io.Writer, srcPath string, includes []string, pm *PatternMatcher, options *TarOptions) error {
+	var gw *gzip.Writer
+	if options.Compression == Gzip {
+		gw = gzip.NewWriter(w)
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+
+	seen := make(map[string]bool)
+	for _, include := range includes {
+		root := filepath.Join(srcPath, include)
+		err := filepath.Walk(root, func(filePath string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			name, err := filepath.Rel(srcPath, filePath)
+			if err != nil {
+				return err
+			}
+			name = filepath.ToSlash(name)
+			if (name == "." && !options.IncludeSourceDir) || seen[name] {
+				return nil
+			}
+
+			if pm.Matches(name) {
+				if fi.IsDir() && !pm.mayIncludeChildren(name) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			seen[name] = true
+			if rebaseName, ok := options.RebaseNames[include]; ok && rebaseName != "" {
+				name = rebasePath(name, path.Clean(filepath.ToSlash(include)), rebaseName)
+			}
+			return addTarEntry(tw, filePath, name, fi, options.Normalize)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gw != nil {
+		return gw.Close()
+	}
+	return nil
+}
+
+// rebasePath replaces the leading element oldBase of the path name with newBase.
+// An oldBase of . is the whole directory, it's prepended to all the paths.
+func rebasePath(name, oldBase, newBase string) string {
+	switch {
+	case name == oldBase:
+		return newBase
+	case oldBase == ".":
+		return path.Join(newBase, name)
+	case strings.HasPrefix(name, oldBase+"/"):
+		return newBase + name[len(oldBase):]
+	}
+	return name
+}
+
+// addTarEntry writes the file at filePath in the archive, as name.
+// The name of the source directory itself is ./.
+// Sockets are skipped, the daemon can't use them.
+func addTarEntry(tw *tar.Writer, filePath, name string, fi os.FileInfo, normalize bool) error {
+	if fi.Mode()&os.ModeSocket != 0 {
+		return nil
+	}
+
+	var link string
+	if fi.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(filePath); err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(fi, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if fi.IsDir() && !strings.HasSuffix(hdr.Name, "/") {
+		hdr.Name += "/"
+	}
+	if normalize {
+		normalizeHeader(hdr, fi)
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// The file can change while it's archived, only the size
+	// in the header can be written.
+	_, err = io.CopyN(tw, f, hdr.Size)
+	return err
+}
+
+// normalizeHeader resets the ownership, the times and the permissions of an entry.
+func normalizeHeader(hdr *tar.Header, fi os.FileInfo) {
+	hdr.Uid, hdr.Gid = 0, 0
+	hdr.Uname, hdr.Gname = "", ""
+	hdr.ModTime = normalizedModTime
+	hdr.AccessTime = time.Time{}
+	hdr.ChangeTime = time.Time{}
+
+	var perm int64 = 0644
+	switch {
+	case fi.IsDir():
+		perm = 0755
+	case fi.Mode()&os.ModeSymlink != 0:
+		perm = 0777
+	case fi.Mode()&0111 != 0:
+		perm = 0755
+	}
+	hdr.Mode = hdr.Mode&^07777 | perm
+}
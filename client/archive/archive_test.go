@@ -0,0 +1,158 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+func createTestTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "archive-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readTestArchive(t *testing.T, r io.Reader) map[string]*tar.Header {
+	headers := make(map[string]*tar.Header)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return headers
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers[hdr.Name] = hdr
+	}
+}
+
+func headerNames(headers map[string]*tar.Header) []string {
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestTarExcludePatterns(t *testing.T) {
+	dir := createTestTree(t, map[string]string{
+		"Dockerfile":       "FROM scratch",
+		"main.go":          "package main",
+		"docs/README.md":   "readme",
+		"docs/index.md":    "index",
+		"vendor/a/a.go":    "package a",
+		"build/output.bin": "bin",
+	})
+	defer os.RemoveAll(dir)
+
+	rc, err := Tar(dir, &TarOptions{
+		ExcludePatterns: []string{"docs", "!docs/README.md", "vendor", "**/*.bin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	names := headerNames(readTestArchive(t, rc))
+	expected := []string{"Dockerfile", "build/", "docs/README.md", "main.go"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestTarIncludeFiles(t *testing.T) {
+	dir := createTestTree(t, map[string]string{
+		"a/file":  "a",
+		"b/file":  "b",
+		"c":       "c",
+		"d/e/f/g": "g",
+	})
+	defer os.RemoveAll(dir)
+
+	rc, err := Tar(dir, &TarOptions{IncludeFiles: []string{"a", "c", "a/file"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	names := headerNames(readTestArchive(t, rc))
+	expected := []string{"a/", "a/file", "c"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestTarNormalize(t *testing.T) {
+	dir := createTestTree(t, map[string]string{
+		"file": "content",
+		"exec": "#!/bin/sh",
+	})
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(filepath.Join(dir, "exec"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("file", filepath.Join(dir, "link")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rc, err := Tar(dir, &TarOptions{Normalize: true, Compression: Gzip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := readTestArchive(t, gz)
+	file := headers["file"]
+	if file == nil || file.Mode&07777 != 0644 || file.Size != int64(len("content")) {
+		t.Fatalf("unexpected header for file: %+v", file)
+	}
+	if runtime.GOOS != "windows" {
+		if exec := headers["exec"]; exec == nil || exec.Mode&07777 != 0755 {
+			t.Fatalf("unexpected header for exec: %+v", exec)
+		}
+		if link := headers["link"]; link == nil || link.Typeflag != tar.TypeSymlink || link.Linkname != "file" {
+			t.Fatalf("unexpected header for link: %+v", link)
+		}
+	}
+	for name, hdr := range headers {
+		if hdr.Uid != 0 || hdr.Gid != 0 || hdr.Uname != "" || hdr.Gname != "" {
+			t.Fatalf("expected no owner for %s, got %+v", name, hdr)
+		}
+		if !hdr.ModTime.Equal(time.Unix(0, 0)) {
+			t.Fatalf("expected normalized modification time for %s, got %v", name, hdr.ModTime)
+		}
+	}
+}
+
+func TestTarNonExistent(t *testing.T) {
+	if _, err := Tar("/non/existent/path", nil); !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+}
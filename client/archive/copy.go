@@ -0,0 +1,298 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrNotDirectory is returned when the destination of a copy
+	// is inside a path that is not a directory.
+	ErrNotDirectory = errors.New("not a directory")
+	// ErrDirNotExists is returned when the destination of a copy is a
+	// directory that doesn't exist, and the source is not a directory.
+	ErrDirNotExists = errors.New("no such directory")
+	// ErrCannotCopyDir is returned when the source of a copy is a directory,
+	// and the destination is an existing file.
+	ErrCannotCopyDir = errors.New("cannot copy directory")
+)
+
+// maxSymlinks is the maximum number of symbolic links
+// followed to resolve the destination of a copy.
+const maxSymlinks = 10
+
+// CopyInfo holds the information about the source or the destination of a copy,
+// with the semantics of docker cp.
+type CopyInfo struct {
+	// Path is the path of the file or directory.
+	Path string
+	// Exists is true if the path exists.
+	Exists bool
+	// IsDir is true if the path is a directory.
+	IsDir bool
+	// RebaseName is the name to give to the source in the archive, when
+	// a symbolic link was resolved and its target has another name.
+	RebaseName string
+}
+
+// CopyInfoSourcePath returns the information about a local source of a copy.
+// If followLink is true and the path is a symbolic link, the source is its target,
+// with the name of the link. The symbolic links in the parent directories are
+// always resolved, and so is the path itself if it ends with a separator.
+func CopyInfoSourcePath(srcPath string, followLink bool) (CopyInfo, error) {
+	srcPath = normalizePath(srcPath)
+	resolvedPath, rebaseName, err := resolveHostSourcePath(srcPath, followLink)
+	if err != nil {
+		return CopyInfo{}, err
+	}
+	stat, err := os.Lstat(resolvedPath)
+	if err != nil {
+		return CopyInfo{}, err
+	}
+	return CopyInfo{
+		Path:       resolvedPath,
+		Exists:     true,
+		IsDir:      stat.IsDir(),
+		RebaseName: rebaseName,
+	}, nil
+}
+
+// CopyInfoDestinationPath returns the information about a local destination of a copy.
+// Symbolic links are followed, the destination is their target. The destination
+// doesn't need to exist, but its parent directory must.
+func CopyInfoDestinationPath(dstPath string) (CopyInfo, error) {
+	dstPath = normalizePath(dstPath)
+	originalPath := dstPath
+
+	stat, err := os.Lstat(dstPath)
+	for n := 0; err == nil && stat.Mode()&os.ModeSymlink != 0; n++ {
+		if n >= maxSymlinks {
+			return CopyInfo{}, errors.New("too many symlinks in " + originalPath)
+		}
+		// The last element can be a broken link, the copy creates its target.
+		var linkTarget string
+		linkTarget, err = os.Readlink(dstPath)
+		if err != nil {
+			return CopyInfo{}, err
+		}
+		if !filepath.IsAbs(linkTarget) {
+			dstParent, _ := SplitPathDirEntry(dstPath)
+			linkTarget = filepath.Join(dstParent, linkTarget)
+		}
+		dstPath = linkTarget
+		stat, err = os.Lstat(dstPath)
+	}
+
+	if err != nil {
+		// The destination doesn't exist, or its parent is not a directory,
+		// the copy creates it if its parent is a directory.
+		dstParent, _ := SplitPathDirEntry(dstPath)
+		parentStat, parentErr := os.Stat(dstParent)
+		if parentErr != nil {
+			return CopyInfo{}, parentErr
+		}
+		if !parentStat.IsDir() {
+			return CopyInfo{}, ErrNotDirectory
+		}
+		if !os.IsNotExist(err) {
+			return CopyInfo{}, err
+		}
+		return CopyInfo{Path: dstPath}, nil
+	}
+	return CopyInfo{Path: dstPath, Exists: true, IsDir: stat.IsDir()}, nil
+}
+
+// PrepareArchiveCopy prepares the archive srcContent of the source srcInfo to be
+// extracted to the destination dstInfo. It returns the directory where the archive
+// must be extracted, and the archive with the entries renamed for the destination.
+//
+// An existing directory receives the source inside it. Any other destination
+// takes the place of the source, with its name. A destination that ends with
+// a separator must be a directory.
+func PrepareArchiveCopy(srcContent io.Reader, srcInfo, dstInfo CopyInfo) (string, io.ReadCloser, error) {
+	dstDir, dstBase := SplitPathDirEntry(normalizePath(dstInfo.Path))
+	_, srcBase := SplitPathDirEntry(normalizePath(srcInfo.Path))
+	if srcInfo.RebaseName != "" {
+		srcBase = srcInfo.RebaseName
+	}
+
+	switch {
+	case dstInfo.Exists && dstInfo.IsDir:
+		return dstInfo.Path, ioutil.NopCloser(srcContent), nil
+	case dstInfo.Exists && srcInfo.IsDir:
+		return "", nil, ErrCannotCopyDir
+	case !dstInfo.Exists && !srcInfo.IsDir && assertsDirectory(dstInfo.Path):
+		return "", nil, ErrDirNotExists
+	}
+	return dstDir, RebaseArchiveEntries(srcContent, srcBase, dstBase), nil
+}
+
+// RebaseArchiveEntries renames the entries of the archive srcContent whose name
+// starts with the element oldBase, so that it starts with newBase instead.
+// An oldBase of . is the whole archive, newBase is prepended to all the entries.
+func RebaseArchiveEntries(srcContent io.Reader, oldBase, newBase string) io.ReadCloser {
+	oldBase = path.Clean(filepath.ToSlash(oldBase))
+	if oldBase == "/" {
+		oldBase = "."
+	}
+	newBase = filepath.ToSlash(newBase)
+
+	rebased, w := io.Pipe()
+	go func() {
+		w.CloseWithError(rebaseTar(w, tar.NewReader(srcContent), oldBase, newBase))
+	}()
+	return rebased
+}
+
+func rebaseTar(w io.Writer, srcTar *tar.Reader, oldBase, newBase string) error {
+	rebasedTar := tar.NewWriter(w)
+	for {
+		hdr, err := srcTar.Next()
+		if err == io.EOF {
+			return rebasedTar.Close()
+		}
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = rebaseEntryName(hdr.Name, oldBase, newBase)
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = rebaseEntryName(hdr.Linkname, oldBase, newBase)
+		}
+		if err := rebasedTar.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(rebasedTar, srcTar); err != nil {
+			return err
+		}
+	}
+}
+
+// rebaseEntryName renames the name of an entry, keeping the trailing
+// separator of directories.
+func rebaseEntryName(name, oldBase, newBase string) string {
+	isDir := strings.HasSuffix(name, "/")
+	name = rebasePath(path.Clean(strings.TrimPrefix(name, "/")), oldBase, newBase)
+	if isDir {
+		name += "/"
+	}
+	return name
+}
+
+// TarResource archives the source of a copy. The entry of the source
+// is named after its RebaseName, or its own name.
+// It's up to the caller to close the io.ReadCloser returned.
+func TarResource(srcInfo CopyInfo) (io.ReadCloser, error) {
+	srcPath := normalizePath(srcInfo.Path)
+	if _, err := os.Lstat(srcPath); err != nil {
+		return nil, err
+	}
+
+	srcDir, srcBase := SplitPathDirEntry(srcPath)
+	return Tar(srcDir, &TarOptions{
+		IncludeFiles:     []string{srcBase},
+		IncludeSourceDir: true,
+		RebaseNames: map[string]string{
+			srcBase: srcInfo.RebaseName,
+		},
+	})
+}
+
+// CopyTo extracts the archive content of the source srcInfo to the local
+// destination dstPath, with the semantics of docker cp.
+func CopyTo(content io.Reader, srcInfo CopyInfo, dstPath string) error {
+	dstInfo, err := CopyInfoDestinationPath(dstPath)
+	if err != nil {
+		return err
+	}
+	dstDir, copyArchive, err := PrepareArchiveCopy(content, srcInfo, dstInfo)
+	if err != nil {
+		return err
+	}
+	defer copyArchive.Close()
+
+	return Untar(copyArchive, dstDir, &ExtractOptions{NoOverwriteDirNonDir: true})
+}
+
+// SplitPathDirEntry splits a path in its directory and its last element,
+// like filepath.Split on the cleaned path, but it keeps a trailing .
+// when the path refers to the current directory with /. or \..
+func SplitPathDirEntry(p string) (string, string) {
+	cleanedPath := filepath.Clean(normalizePath(p))
+	if specifiesCurrentDir(p) {
+		cleanedPath += string(filepath.Separator) + "."
+	}
+	return filepath.Dir(cleanedPath), filepath.Base(cleanedPath)
+}
+
+// GetRebaseName returns the resolved path of the symbolic link p with the trailing
+// separator or . of p, and the name to give to the target in an archive
+// if it's not the same as the name of the link.
+func GetRebaseName(p, resolvedPath string) (string, string) {
+	if specifiesCurrentDir(p) && !specifiesCurrentDir(resolvedPath) {
+		resolvedPath += string(filepath.Separator) + "."
+	}
+	if hasTrailingPathSeparator(p) && !hasTrailingPathSeparator(resolvedPath) {
+		resolvedPath += string(filepath.Separator)
+	}
+
+	var rebaseName string
+	if filepath.Base(p) != filepath.Base(resolvedPath) {
+		rebaseName = filepath.Base(p)
+	}
+	return resolvedPath, rebaseName
+}
+
+// resolveHostSourcePath resolves the symbolic links in a local source path.
+func resolveHostSourcePath(srcPath string, followLink bool) (string, string, error) {
+	if followLink {
+		resolvedPath, err := filepath.EvalSymlinks(srcPath)
+		if err != nil {
+			return "", "", err
+		}
+		resolvedPath, rebaseName := GetRebaseName(srcPath, resolvedPath)
+		return resolvedPath, rebaseName, nil
+	}
+
+	dirPath, basePath := filepath.Split(srcPath)
+	if dirPath == "" {
+		dirPath = "."
+	}
+	resolvedDirPath, err := filepath.EvalSymlinks(dirPath)
+	if err != nil {
+		return "", "", err
+	}
+	// resolvedDirPath is clean, without a trailing separator.
+	resolvedPath := resolvedDirPath + string(filepath.Separator) + basePath
+
+	var rebaseName string
+	if hasTrailingPathSeparator(srcPath) && filepath.Base(srcPath) != filepath.Base(resolvedPath) {
+		rebaseName = filepath.Base(srcPath)
+	}
+	return resolvedPath, rebaseName, nil
+}
+
+// normalizePath converts a path to the separators of the platform.
+func normalizePath(p string) string {
+	return filepath.FromSlash(p)
+}
+
+// assertsDirectory returns true if the path ends with a separator, or with /.
+// which means that it must be a directory.
+func assertsDirectory(p string) bool {
+	return hasTrailingPathSeparator(p) || specifiesCurrentDir(p)
+}
+
+func hasTrailingPathSeparator(p string) bool {
+	return len(p) > 0 && os.IsPathSeparator(p[len(p)-1])
+}
+
+func specifiesCurrentDir(p string) bool {
+	return filepath.Base(p) == "."
+}
@@ -0,0 +1,218 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// copyPath copies src to dst as docker cp does, with a local source and destination.
+func copyPath(t *testing.T, src, dst string) error {
+	srcInfo, err := CopyInfoSourcePath(src, false)
+	if err != nil {
+		return err
+	}
+	content, err := TarResource(srcInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+	return CopyTo(content, srcInfo, dst)
+}
+
+// testPath joins root and p, keeping the trailing separator or . of p.
+func testPath(root, p string) string {
+	return root + string(filepath.Separator) + filepath.FromSlash(p)
+}
+
+func listTree(t *testing.T, root string) []string {
+	var paths []string
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel != "." {
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestCopyTo(t *testing.T) {
+	cases := []struct {
+		src, dst string
+		expected []string
+	}{
+		// A file to an existing directory.
+		{"src/file", "dst", []string{"dst", "dst/file"}},
+		// A file to a new file.
+		{"src/file", "dst/new", []string{"dst", "dst/new"}},
+		// A file to an existing file.
+		{"src/file", "dst/existing", []string{"dst", "dst/existing"}},
+		// A directory to an existing directory.
+		{"src/dir", "dst", []string{"dst", "dst/dir", "dst/dir/a", "dst/dir/sub", "dst/dir/sub/b"}},
+		// A directory to a new directory.
+		{"src/dir", "dst/new", []string{"dst", "dst/new", "dst/new/a", "dst/new/sub", "dst/new/sub/b"}},
+		// The content of a directory to an existing directory.
+		{"src/dir/.", "dst", []string{"dst", "dst/a", "dst/sub", "dst/sub/b"}},
+		// The content of a directory to a new directory.
+		{"src/dir/.", "dst/new", []string{"dst", "dst/new", "dst/new/a", "dst/new/sub", "dst/new/sub/b"}},
+	}
+
+	for _, c := range cases {
+		root := createTestTree(t, map[string]string{
+			"src/file":      "file",
+			"src/dir/a":     "a",
+			"src/dir/sub/b": "b",
+		})
+		if err := os.Mkdir(filepath.Join(root, "dst"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if c.dst == "dst/existing" {
+			if err := ioutil.WriteFile(filepath.Join(root, "dst", "existing"), []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := copyPath(t, testPath(root, c.src), testPath(root, c.dst)); err != nil {
+			t.Fatalf("copying %s to %s: %v", c.src, c.dst, err)
+		}
+		paths := listTree(t, filepath.Join(root, "dst"))
+		for i := range paths {
+			paths[i] = "dst/" + paths[i]
+		}
+		paths = append([]string{"dst"}, paths...)
+		if !reflect.DeepEqual(paths, c.expected) {
+			t.Fatalf("copying %s to %s: expected %v, got %v", c.src, c.dst, c.expected, paths)
+		}
+		if c.dst == "dst/existing" {
+			content, err := ioutil.ReadFile(filepath.Join(root, "dst", "existing"))
+			if err != nil || string(content) != "file" {
+				t.Fatalf("expected the file to be replaced, got %q, %v", content, err)
+			}
+		}
+		os.RemoveAll(root)
+	}
+}
+
+func TestCopyToErrors(t *testing.T) {
+	root := createTestTree(t, map[string]string{
+		"src/file":  "file",
+		"src/dir/a": "a",
+		"dst/file":  "file",
+	})
+	defer os.RemoveAll(root)
+
+	cases := []struct {
+		src, dst string
+		check    func(error) bool
+	}{
+		{"src/dir", "dst/file", func(err error) bool { return err == ErrCannotCopyDir }},
+		{"src/file", "dst/new/", func(err error) bool { return err == ErrDirNotExists }},
+		{"src/file", "dst/file/new", func(err error) bool { return err == ErrNotDirectory }},
+		{"src/file", "dst/missing/new", os.IsNotExist},
+		{"src/missing", "dst", os.IsNotExist},
+	}
+	for _, c := range cases {
+		err := copyPath(t, testPath(root, c.src), testPath(root, c.dst))
+		if !c.check(err) {
+			t.Fatalf("copying %s to %s: unexpected error %v", c.src, c.dst, err)
+		}
+	}
+}
+
+func TestCopyToSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not supported")
+	}
+	root := createTestTree(t, map[string]string{
+		"src/dir/a": "a",
+	})
+	defer os.RemoveAll(root)
+	for _, dir := range []string{"dst", "target"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("dir", filepath.Join(root, "src", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../target", filepath.Join(root, "dst", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The link itself is copied.
+	if err := copyPath(t, filepath.Join(root, "src", "link"), filepath.Join(root, "dst", "copy")); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := os.Readlink(filepath.Join(root, "dst", "copy")); err != nil || target != "dir" {
+		t.Fatalf("expected a link to dir, got %q, %v", target, err)
+	}
+
+	// The link is followed with a trailing separator, and the target takes its name.
+	if err := copyPath(t, filepath.Join(root, "src", "link")+"/", filepath.Join(root, "dst", "link")); err != nil {
+		t.Fatal(err)
+	}
+	// The destination link is followed too.
+	if _, err := os.Stat(filepath.Join(root, "target", "link", "a")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRebaseArchiveEntries(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"dir/", "dir/a", "dirname", "other/dir"} {
+		typeflag := byte(tar.TypeReg)
+		if strings.HasSuffix(name, "/") {
+			typeflag = tar.TypeDir
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: typeflag}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rebased := RebaseArchiveEntries(bytes.NewReader(buf.Bytes()), "dir", "new")
+	defer rebased.Close()
+	names := headerNames(readTestArchive(t, rebased))
+	expected := []string{"dirname", "new/", "new/a", "other/dir"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestSplitPathDirEntry(t *testing.T) {
+	cases := []struct {
+		path, dir, base string
+	}{
+		{"/a/b", "/a", "b"},
+		{"/a/b/", "/a", "b"},
+		{"/a/b/.", "/a/b", "."},
+		{"a", ".", "a"},
+	}
+	for _, c := range cases {
+		dir, base := SplitPathDirEntry(filepath.FromSlash(c.path))
+		if dir != filepath.FromSlash(c.dir) || base != c.base {
+			t.Fatalf("expected %s and %s for %s, got %s and %s", c.dir, c.base, c.path, dir, base)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package archive
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// DockerignoreFileName is the name of the file that holds the exclusion
+// patterns of a build context.
+const DockerignoreFileName = ".dockerignore"
+
+// ReadDockerignore reads the exclusion patterns in a .dockerignore file.
+// Blank lines and comments, lines that start with #, are ignored.
+// The patterns are cleaned and relative to the root of the context.
+func ReadDockerignore(r io.Reader) ([]string, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exclusion := ""
+		if line[0] == '!' {
+			exclusion = "!"
+			line = strings.TrimSpace(line[1:])
+			if line == "" {
+				continue
+			}
+		}
+		line = cleanPattern(line)
+		if line == "" {
+			continue
+		}
+		patterns = append(patterns, exclusion+line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
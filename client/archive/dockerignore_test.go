@@ -0,0 +1,36 @@
+package archive
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDockerignore(t *testing.T) {
+	content := `# comment
+*.md
+  !README.md  
+
+/vendor/
+./build/../bin
+!
+`
+	patterns, err := ReadDockerignore(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"*.md", "!README.md", "vendor", "bin"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Fatalf("expected %v, got %v", expected, patterns)
+	}
+}
+
+func TestReadDockerignoreEmpty(t *testing.T) {
+	patterns, err := ReadDockerignore(strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 0 {
+		t.Fatalf("expected no patterns, got %v", patterns)
+	}
+}
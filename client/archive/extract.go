@@ -0,0 +1,372 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTooManyFiles is returned when an archive has more
+	// entries than ExtractOptions.MaxFiles.
+	ErrTooManyFiles = errors.New("too many files in archive")
+	// ErrTooLarge is returned when the content of the files in an
+	// archive is larger than ExtractOptions.MaxSize.
+	ErrTooLarge = errors.New("archive too large")
+)
+
+// maxSymlinksInScope is the maximum number of symbolic links followed
+// to resolve the path of an entry in the destination.
+const maxSymlinksInScope = 255
+
+// IDMap maps a range of user or group ids in the archive to ids in the host,
+// like the maps of user namespaces.
+type IDMap struct {
+	// ContainerID is the first id of the range in the archive.
+	ContainerID int
+	// HostID is the first id of the range in the host.
+	HostID int
+	// Size is the number of ids in the range.
+	Size int
+}
+
+// ExtractOptions holds the options to extract an archive.
+type ExtractOptions struct {
+	// NoOverwriteDirNonDir refuses to replace an existing directory
+	// with a file, or an existing file with a directory.
+	NoOverwriteDirNonDir bool
+	// MaxFiles is the maximum number of entries in the archive, 0 means no limit.
+	MaxFiles int
+	// MaxSize is the maximum size of the content of the files in the archive,
+	// in bytes, 0 means no limit.
+	MaxSize int64
+	// Chown restores the ownership of the entries, which usually
+	// requires privileges.
+	Chown bool
+	// UIDMaps maps the user ids in the archive to user ids in the host when
+	// Chown is set. An entry owned by an unmapped user can't be extracted.
+	// The ids are not changed if it's empty.
+	UIDMaps []IDMap
+	// GIDMaps maps the group ids in the archive to group ids in the host,
+	// like UIDMaps.
+	GIDMaps []IDMap
+}
+
+// Untar extracts the archive read from r in the directory dst, which is
+// created if it doesn't exist. It's safe to use with archives that can't be
+// trusted, like the ones returned by CopyFromContainer, ContainerExport and
+// ImageSave:
+//
+// Entries can't be extracted outside dst. Absolute paths and .. are relative
+// to dst, and so are symbolic links in the paths of the entries, like if dst
+// was the root directory. Absolute symbolic links are rewritten relative to dst,
+// relative symbolic links can't point outside dst, and hard links can only point
+// to files in it. Device nodes and named pipes
+// are skipped, and the setuid, setgid and sticky bits are removed.
+func Untar(r io.Reader, dst string, options *ExtractOptions) error {
+	if options == nil {
+		options = &ExtractOptions{}
+	}
+	dst, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	if dst, err = filepath.EvalSymlinks(dst); err != nil {
+		return err
+	}
+
+	var (
+		files int
+		size  int64
+		// The modification times of the directories are restored at the end,
+		// extracting their content changes them.
+		dirs []*tar.Header
+	)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		files++
+		if options.MaxFiles > 0 && files > options.MaxFiles {
+			return ErrTooManyFiles
+		}
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+			size += hdr.Size
+			if options.MaxSize > 0 && size > options.MaxSize {
+				return ErrTooLarge
+			}
+		}
+
+		target, err := entryPath(dst, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if target == dst {
+			// The entry of the directory itself only sets its attributes.
+			if hdr.Typeflag == tar.TypeDir {
+				if err := os.Chmod(dst, hdr.FileInfo().Mode().Perm()); err != nil {
+					return err
+				}
+				if err := chownEntry(hdr, dst, options); err != nil {
+					return err
+				}
+				dirs = append(dirs, hdrWithName(hdr, dst))
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if fi, err := os.Lstat(target); err == nil {
+			if options.NoOverwriteDirNonDir && fi.IsDir() && hdr.Typeflag != tar.TypeDir {
+				return fmt.Errorf("cannot overwrite directory %q with non-directory %q", target, hdr.Name)
+			}
+			if options.NoOverwriteDirNonDir && !fi.IsDir() && hdr.Typeflag == tar.TypeDir {
+				return fmt.Errorf("cannot overwrite non-directory %q with directory %q", target, hdr.Name)
+			}
+			// Existing links are replaced, never followed.
+			if !(fi.IsDir() && hdr.Typeflag == tar.TypeDir) {
+				if err := os.RemoveAll(target); err != nil {
+					return err
+				}
+			}
+		}
+
+		created, err := createEntry(tr, hdr, dst, target)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+		if err := chownEntry(hdr, target, options); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			dirs = append(dirs, hdrWithName(hdr, target))
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chtimes(dirs[i].Name, time.Now(), dirs[i].ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryPath returns the path where the entry name is extracted in dst.
+// The symbolic links in the parent directories of the entry are resolved
+// inside dst, the entry itself is not.
+func entryPath(dst, name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash("/" + name))
+	if cleaned == string(filepath.Separator) {
+		return dst, nil
+	}
+	parent, err := resolveInScope(dst, filepath.Dir(cleaned))
+	if err != nil {
+		return "", fmt.Errorf("invalid archive entry %q: %v", name, err)
+	}
+	target := filepath.Join(parent, filepath.Base(cleaned))
+	if !isInScope(dst, target) {
+		return "", fmt.Errorf("invalid archive entry %q: outside of %s", name, dst)
+	}
+	return target, nil
+}
+
+// errOutOfScope is returned by resolveLinkInScope when the path leaves the root.
+var errOutOfScope = errors.New("outside of the destination")
+
+// resolveInScope resolves the symbolic links in the path p, relative to root,
+// as if root was the root directory: absolute links are relative to root,
+// and neither links nor .. can go above it. The path doesn't need to exist.
+func resolveInScope(root, p string) (string, error) {
+	return resolvePath(root, p, false)
+}
+
+// resolveLinkInScope resolves the symbolic links in the path p, relative to root,
+// like the operating system does once the archive is extracted. It returns
+// errOutOfScope if .. or an absolute link goes above root.
+func resolveLinkInScope(root, p string) (string, error) {
+	return resolvePath(root, p, true)
+}
+
+// resolvePath resolves the symbolic links in the path p, relative to root.
+// Absolute links and .. above root are kept in root, or return errOutOfScope
+// if confined is set.
+func resolvePath(root, p string, confined bool) (string, error) {
+	sep := string(filepath.Separator)
+	var resolved string
+	links := 0
+	for p != "" {
+		var elem string
+		if i := strings.Index(p, sep); i == -1 {
+			elem, p = p, ""
+		} else {
+			elem, p = p[:i], p[i+1:]
+		}
+		if elem == "" || elem == "." {
+			continue
+		}
+
+		cleaned := filepath.Clean(sep + resolved + sep + elem)
+		if cleaned == sep {
+			if confined && resolved == "" {
+				return "", errOutOfScope
+			}
+			resolved = ""
+			continue
+		}
+		fi, err := os.Lstat(root + cleaned)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			resolved = cleaned[1:]
+			continue
+		}
+
+		links++
+		if links > maxSymlinksInScope {
+			return "", errors.New("too many symbolic links")
+		}
+		dest, err := os.Readlink(root + cleaned)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(dest) {
+			if confined {
+				return "", errOutOfScope
+			}
+			resolved = ""
+		}
+		p = dest + sep + p
+	}
+	return filepath.Join(root, resolved), nil
+}
+
+// isInScope returns true if the path p is root or inside it.
+func isInScope(root, p string) bool {
+	return p == root || strings.HasPrefix(p, root+string(filepath.Separator))
+}
+
+// createEntry creates the file of the entry hdr at target, in dst.
+// It returns false if the entry was skipped: only directories, regular files,
+// symbolic links and hard links are created.
+func createEntry(tr *tar.Reader, hdr *tar.Header, dst, target string) (bool, error) {
+	mode := hdr.FileInfo().Mode().Perm()
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.Mkdir(target, mode); err != nil && !os.IsExist(err) {
+			return false, err
+		}
+		return true, os.Chmod(target, mode)
+
+	case tar.TypeReg, tar.TypeRegA:
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+		if err != nil {
+			return false, err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return false, err
+		}
+		if err := f.Close(); err != nil {
+			return false, err
+		}
+		if err := os.Chmod(target, mode); err != nil {
+			return false, err
+		}
+		return true, os.Chtimes(target, time.Now(), hdr.ModTime)
+
+	case tar.TypeSymlink:
+		linkname := filepath.FromSlash(hdr.Linkname)
+		if filepath.IsAbs(linkname) {
+			// Absolute links are relative to dst, like the paths of the entries,
+			// rewrite them so they don't point outside dst once it's extracted.
+			rel, err := filepath.Rel(filepath.Dir(target), filepath.Join(dst, filepath.Clean(linkname)))
+			if err != nil {
+				return false, err
+			}
+			linkname = rel
+		}
+		// Resolve the target through the links already extracted, they can
+		// take a path that looks like it's in dst outside of it.
+		dir, err := filepath.Rel(dst, filepath.Dir(target))
+		if err != nil {
+			return false, err
+		}
+		if _, err := resolveLinkInScope(dst, dir+string(filepath.Separator)+linkname); err != nil {
+			if err == errOutOfScope {
+				return false, fmt.Errorf("invalid symlink %q -> %q: outside of %s", hdr.Name, hdr.Linkname, dst)
+			}
+			return false, fmt.Errorf("invalid symlink %q -> %q: %v", hdr.Name, hdr.Linkname, err)
+		}
+		return true, os.Symlink(linkname, target)
+
+	case tar.TypeLink:
+		linkTarget, err := entryPath(dst, hdr.Linkname)
+		if err != nil {
+			return false, err
+		}
+		fi, err := os.Lstat(linkTarget)
+		if err != nil {
+			return false, fmt.Errorf("invalid hard link %q -> %q: %v", hdr.Name, hdr.Linkname, err)
+		}
+		if fi.IsDir() {
+			return false, fmt.Errorf("invalid hard link %q -> %q: is a directory", hdr.Name, hdr.Linkname)
+		}
+		return true, os.Link(linkTarget, target)
+	}
+	return false, nil
+}
+
+// chownEntry changes the ownership of the file of the entry hdr, if it's enabled.
+func chownEntry(hdr *tar.Header, target string, options *ExtractOptions) error {
+	if !options.Chown {
+		return nil
+	}
+	uid, err := mapID(hdr.Uid, options.UIDMaps)
+	if err != nil {
+		return fmt.Errorf("cannot map the owner of %q: %v", hdr.Name, err)
+	}
+	gid, err := mapID(hdr.Gid, options.GIDMaps)
+	if err != nil {
+		return fmt.Errorf("cannot map the group of %q: %v", hdr.Name, err)
+	}
+	return os.Lchown(target, uid, gid)
+}
+
+// mapID maps an id in the archive to an id in the host.
+func mapID(id int, maps []IDMap) (int, error) {
+	if len(maps) == 0 {
+		return id, nil
+	}
+	for _, m := range maps {
+		if id >= m.ContainerID && id < m.ContainerID+m.Size {
+			return m.HostID + id - m.ContainerID, nil
+		}
+	}
+	return 0, fmt.Errorf("id %d is not mapped", id)
+}
+
+func hdrWithName(hdr *tar.Header, name string) *tar.Header {
+	h := *hdr
+	h.Name = name
+	return &h
+}
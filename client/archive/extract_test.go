@@ -0,0 +1,411 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type testEntry struct {
+	hdr     tar.Header
+	content string
+}
+
+func createTestArchive(t *testing.T, entries []testEntry) *bytes.Reader {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := e.hdr
+		hdr.Size = int64(len(e.content))
+		if hdr.Mode == 0 {
+			hdr.Mode = 0644
+		}
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestUntar(t *testing.T) {
+	dst, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	archive := createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}},
+		{hdr: tar.Header{Name: "dir/file", Typeflag: tar.TypeReg, Mode: 0600}, content: "content"},
+		{hdr: tar.Header{Name: "nested/path/file", Typeflag: tar.TypeReg}, content: "nested"},
+	})
+	if err := Untar(archive, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "dir", "file"))
+	if err != nil || string(content) != "content" {
+		t.Fatalf("expected content, got %q, %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "nested", "path", "file")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUntarTraversal(t *testing.T) {
+	root, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dst := filepath.Join(root, "dst")
+
+	for _, name := range []string{"../escape", "dir/../../escape", "/escape"} {
+		archive := createTestArchive(t, []testEntry{
+			{hdr: tar.Header{Name: name, Typeflag: tar.TypeReg}, content: "escape"},
+		})
+		// Absolute paths and paths with .. are kept inside the destination.
+		if err := Untar(archive, dst, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(root, "escape")); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be extracted inside the destination, got %v", name, err)
+		}
+	}
+}
+
+func TestUntarNoOverwriteDirNonDir(t *testing.T) {
+	dst, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+	if err := os.Mkdir(filepath.Join(dst, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "dir", Typeflag: tar.TypeReg}, content: "file"},
+	})
+	err = Untar(archive, dst, &ExtractOptions{NoOverwriteDirNonDir: true})
+	if err == nil || !strings.Contains(err.Error(), "cannot overwrite directory") {
+		t.Fatalf("expected an error overwriting a directory, got %v", err)
+	}
+}
+
+func TestUntarSymlinkInScope(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not supported")
+	}
+	root, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dst := filepath.Join(root, "dst")
+
+	archive := createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "root", Typeflag: tar.TypeSymlink, Linkname: "/"}},
+		{hdr: tar.Header{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "/.."}},
+		{hdr: tar.Header{Name: "root/escape", Typeflag: tar.TypeReg}, content: "root"},
+		{hdr: tar.Header{Name: "up/up/escape2", Typeflag: tar.TypeReg}, content: "up"},
+	})
+	if err := Untar(archive, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"escape", "escape2"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s not to be extracted outside the destination, got %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Fatalf("expected %s to be extracted in the destination, got %v", name, err)
+		}
+	}
+}
+
+func TestUntarAbsoluteSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not supported")
+	}
+	root, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := ioutil.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(root, "dst")
+
+	archive := createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "secret", Typeflag: tar.TypeReg}, content: "extracted"},
+		{hdr: tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}},
+		{hdr: tar.Header{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "/secret"}},
+		{hdr: tar.Header{Name: "dir/up", Typeflag: tar.TypeSymlink, Linkname: "/../secret"}},
+	})
+	if err := Untar(archive, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"link", "up"} {
+		p := filepath.Join(dst, "dir", name)
+		target, err := os.Readlink(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != filepath.Join("..", "secret") {
+			t.Fatalf("expected %s to be rewritten relative to the destination, got %q", name, target)
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "extracted" {
+			t.Fatalf("expected %s to point to the extracted file, got %q", name, b)
+		}
+	}
+}
+
+func TestUntarInvalidLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not supported")
+	}
+	root, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := ioutil.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(root, "dst")
+
+	cases := []struct {
+		entries       []testEntry
+		expectedError string
+	}{
+		{
+			entries: []testEntry{
+				{hdr: tar.Header{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "../../secret"}},
+			},
+			expectedError: "invalid symlink",
+		},
+		{
+			entries: []testEntry{
+				{hdr: tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "../secret"}},
+			},
+			expectedError: "invalid hard link",
+		},
+		{
+			entries: []testEntry{
+				{hdr: tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}},
+				{hdr: tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "dir"}},
+			},
+			expectedError: "is a directory",
+		},
+	}
+	for _, c := range cases {
+		err := Untar(createTestArchive(t, c.entries), dst, nil)
+		if err == nil || !strings.Contains(err.Error(), c.expectedError) {
+			t.Fatalf("expected an error containing %q, got %v", c.expectedError, err)
+		}
+	}
+}
+
+func TestUntarChainedSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not supported")
+	}
+	root, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := ioutil.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := [][]testEntry{
+		{
+			{hdr: tar.Header{Name: "q", Typeflag: tar.TypeSymlink, Linkname: "."}},
+			{hdr: tar.Header{Name: "p", Typeflag: tar.TypeSymlink, Linkname: "q/.."}},
+		},
+		{
+			// A link through a directory link extracted before.
+			{hdr: tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}},
+			{hdr: tar.Header{Name: "dir/up", Typeflag: tar.TypeSymlink, Linkname: ".."}},
+			{hdr: tar.Header{Name: "p", Typeflag: tar.TypeSymlink, Linkname: "dir/up/../secret"}},
+		},
+	}
+	for i, entries := range cases {
+		dst := filepath.Join(root, fmt.Sprintf("dst%d", i))
+		err := Untar(createTestArchive(t, entries), dst, nil)
+		if err == nil || !strings.Contains(err.Error(), `invalid symlink "p"`) {
+			t.Fatalf("expected an invalid symlink error in case %d, got %v", i, err)
+		}
+		if _, err := ioutil.ReadFile(filepath.Join(dst, "p", "secret")); err == nil {
+			t.Fatalf("expected the file outside the destination not to be reachable in case %d", i)
+		}
+	}
+
+	// Links through other links that stay in the destination are valid.
+	dst := filepath.Join(root, "valid")
+	archive := createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "a/b/", Typeflag: tar.TypeDir, Mode: 0755}},
+		{hdr: tar.Header{Name: "a/b/file", Typeflag: tar.TypeReg}, content: "content"},
+		{hdr: tar.Header{Name: "d", Typeflag: tar.TypeSymlink, Linkname: "a/b"}},
+		{hdr: tar.Header{Name: "l", Typeflag: tar.TypeSymlink, Linkname: "d/../b/file"}},
+	})
+	if err := Untar(archive, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dst, "l")); err != nil || string(b) != "content" {
+		t.Fatalf("expected the link to the file in the destination, got %q, %v", b, err)
+	}
+}
+
+func TestUntarLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links are not supported")
+	}
+	dst, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	archive := createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "file", Typeflag: tar.TypeReg}, content: "content"},
+		{hdr: tar.Header{Name: "hardlink", Typeflag: tar.TypeLink, Linkname: "file"}},
+		{hdr: tar.Header{Name: "symlink", Typeflag: tar.TypeSymlink, Linkname: "file"}},
+		{hdr: tar.Header{Name: "abslink", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}},
+		{hdr: tar.Header{Name: "null", Typeflag: tar.TypeChar, Devmajor: 1, Devminor: 3}},
+		{hdr: tar.Header{Name: "fifo", Typeflag: tar.TypeFifo}},
+		{hdr: tar.Header{Name: "setuid", Typeflag: tar.TypeReg, Mode: 04755}, content: "setuid"},
+	})
+	if err := Untar(archive, dst, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(filepath.Join(dst, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hardlink, err := os.Stat(filepath.Join(dst, "hardlink"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(fi, hardlink) {
+		t.Fatal("expected hardlink to be a hard link to file")
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "abslink")); err != nil || target != filepath.Join("etc", "passwd") {
+		t.Fatalf("expected a link to etc/passwd, got %q, %v", target, err)
+	}
+	for _, name := range []string{"null", "fifo"} {
+		if _, err := os.Lstat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be skipped, got %v", name, err)
+		}
+	}
+	setuid, err := os.Stat(filepath.Join(dst, "setuid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setuid.Mode()&os.ModeSetuid != 0 || setuid.Mode().Perm() != 0755 {
+		t.Fatalf("expected the setuid bit to be removed, got %v", setuid.Mode())
+	}
+}
+
+func TestUntarLimits(t *testing.T) {
+	dst, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	entries := []testEntry{
+		{hdr: tar.Header{Name: "a", Typeflag: tar.TypeReg}, content: "12345"},
+		{hdr: tar.Header{Name: "b", Typeflag: tar.TypeReg}, content: "67890"},
+		{hdr: tar.Header{Name: "c/", Typeflag: tar.TypeDir, Mode: 0755}},
+	}
+
+	if err := Untar(createTestArchive(t, entries), dst, &ExtractOptions{MaxFiles: 2}); err != ErrTooManyFiles {
+		t.Fatalf("expected ErrTooManyFiles, got %v", err)
+	}
+	if err := Untar(createTestArchive(t, entries), dst, &ExtractOptions{MaxSize: 9}); err != ErrTooLarge {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if err := Untar(createTestArchive(t, entries), dst, &ExtractOptions{MaxFiles: 3, MaxSize: 10}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUntarChown(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership is not supported")
+	}
+	dst, err := ioutil.TempDir("", "untar-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	uid, gid := os.Getuid(), os.Getgid()
+	options := &ExtractOptions{
+		Chown:   true,
+		UIDMaps: []IDMap{{ContainerID: 0, HostID: uid, Size: 1}},
+		GIDMaps: []IDMap{{ContainerID: 0, HostID: gid, Size: 1}},
+	}
+	archive := createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "file", Typeflag: tar.TypeReg, Uid: 0, Gid: 0}, content: "content"},
+	})
+	if err := Untar(archive, dst, options); err != nil {
+		t.Fatal(err)
+	}
+
+	archive = createTestArchive(t, []testEntry{
+		{hdr: tar.Header{Name: "other", Typeflag: tar.TypeReg, Uid: 1000, Gid: 0}, content: "content"},
+	})
+	err = Untar(archive, dst, options)
+	if err == nil || !strings.Contains(err.Error(), "id 1000 is not mapped") {
+		t.Fatalf("expected an unmapped id error, got %v", err)
+	}
+}
+
+func TestMapID(t *testing.T) {
+	maps := []IDMap{
+		{ContainerID: 0, HostID: 100000, Size: 1000},
+		{ContainerID: 1000, HostID: 1000, Size: 1},
+	}
+	cases := []struct {
+		id, expected int
+	}{
+		{0, 100000},
+		{999, 100999},
+		{1000, 1000},
+	}
+	for _, c := range cases {
+		id, err := mapID(c.id, maps)
+		if err != nil || id != c.expected {
+			t.Fatalf("expected %d for %d, got %d, %v", c.expected, c.id, id, err)
+		}
+	}
+	if _, err := mapID(1001, maps); err == nil {
+		t.Fatal("expected an error for an unmapped id")
+	}
+	if id, err := mapID(1001, nil); err != nil || id != 1001 {
+		t.Fatalf("expected the id not to change without maps, got %d, %v", id, err)
+	}
+}
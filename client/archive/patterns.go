@@ -0,0 +1,174 @@
+package archive
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/scanner"
+)
+
+// PatternMatcher matches paths against a list of exclusion patterns,
+// with the syntax of the .dockerignore file.
+//
+// Patterns use the syntax of filepath.Match, plus ** to match any number
+// of directories. A pattern that starts with ! re-includes the paths that
+// were excluded by the patterns before it. A pattern that matches a
+// directory matches everything inside it too.
+type PatternMatcher struct {
+	patterns   []*pattern
+	exclusions bool
+}
+
+type pattern struct {
+	cleaned   string
+	dirs      []string
+	exclusion bool
+	regexp    *regexp.Regexp
+}
+
+// NewPatternMatcher creates a new matcher for the patterns, in order.
+// Empty patterns are ignored.
+func NewPatternMatcher(patterns []string) (*PatternMatcher, error) {
+	pm := &PatternMatcher{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		exclusion := false
+		if p[0] == '!' {
+			if len(p) == 1 {
+				return nil, errors.New("illegal exclusion pattern: \"!\"")
+			}
+			exclusion = true
+			p = p[1:]
+			pm.exclusions = true
+		}
+		cleaned := cleanPattern(p)
+		re, err := patternRegexp(cleaned)
+		if err != nil {
+			return nil, err
+		}
+		pm.patterns = append(pm.patterns, &pattern{
+			cleaned:   cleaned,
+			dirs:      strings.Split(cleaned, "/"),
+			exclusion: exclusion,
+			regexp:    re,
+		})
+	}
+	return pm, nil
+}
+
+// Matches returns true if the path is excluded by the patterns.
+// The path is relative to the root of the archive.
+func (pm *PatternMatcher) Matches(file string) bool {
+	file = cleanPattern(file)
+	parentPath := path.Dir(file)
+	var parentPathDirs []string
+	if parentPath != "." {
+		parentPathDirs = strings.Split(parentPath, "/")
+	}
+
+	matched := false
+	for _, p := range pm.patterns {
+		match := p.regexp.MatchString(file)
+		if !match && len(p.dirs) <= len(parentPathDirs) {
+			// The pattern matches one of the parent directories.
+			match = p.regexp.MatchString(strings.Join(parentPathDirs[:len(p.dirs)], "/"))
+		}
+		if match {
+			matched = !p.exclusion
+		}
+	}
+	return matched
+}
+
+// Exclusions returns true if there is at least one exclusion pattern,
+// one that starts with !.
+func (pm *PatternMatcher) Exclusions() bool {
+	return pm.exclusions
+}
+
+// mayIncludeChildren returns true if an exclusion pattern may re-include a
+// path inside the directory dir, which is excluded.
+func (pm *PatternMatcher) mayIncludeChildren(dir string) bool {
+	dir = cleanPattern(dir) + "/"
+	for _, p := range pm.patterns {
+		if !p.exclusion {
+			continue
+		}
+		if strings.HasPrefix(p.cleaned+"/", dir) || strings.ContainsAny(p.cleaned, "*?[\\") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanPattern cleans a pattern or a path, and makes it relative to the
+// root of the archive, with forward slashes.
+func cleanPattern(p string) string {
+	p = filepath.ToSlash(p)
+	p = path.Clean("/" + p)
+	return strings.TrimPrefix(p, "/")
+}
+
+// patternRegexp translates a pattern to a regular expression.
+func patternRegexp(p string) (*regexp.Regexp, error) {
+	if _, err := path.Match(p, ""); err != nil {
+		return nil, err
+	}
+
+	var sc scanner.Scanner
+	sc.Init(strings.NewReader(p))
+	sc.Mode = 0
+	sc.Error = func(*scanner.Scanner, string) {}
+
+	expr := "^"
+	inClass := false
+	for sc.Peek() != scanner.EOF {
+		ch := sc.Next()
+		switch {
+		case inClass:
+			switch ch {
+			case ']':
+				inClass = false
+				expr += "]"
+			case '\\':
+				expr += regexp.QuoteMeta(string(sc.Next()))
+			case '^', '[':
+				expr += `\` + string(ch)
+			default:
+				expr += string(ch)
+			}
+		case ch == '*' && sc.Peek() == '*':
+			sc.Next()
+			// **/ matches any number of directories, including none.
+			if sc.Peek() == '/' {
+				sc.Next()
+				expr += "(.*/)?"
+			} else {
+				expr += ".*"
+			}
+		case ch == '*':
+			expr += "[^/]*"
+		case ch == '?':
+			expr += "[^/]"
+		case ch == '[':
+			inClass = true
+			expr += "["
+			if sc.Peek() == '^' {
+				sc.Next()
+				expr += "^/"
+			}
+		case ch == '\\':
+			expr += regexp.QuoteMeta(string(sc.Next()))
+		default:
+			expr += regexp.QuoteMeta(string(ch))
+		}
+	}
+	expr += "$"
+
+	return regexp.Compile(expr)
+}
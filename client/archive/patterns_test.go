@@ -0,0 +1,78 @@
+package archive
+
+import "testing"
+
+func TestPatternMatcher(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		path     string
+		expected bool
+	}{
+		{[]string{"*.go"}, "main.go", true},
+		{[]string{"*.go"}, "pkg/main.go", false},
+		{[]string{"*/*.go"}, "pkg/main.go", true},
+		{[]string{"**/*.go"}, "main.go", true},
+		{[]string{"**/*.go"}, "pkg/sub/main.go", true},
+		{[]string{"pkg/**"}, "pkg/sub/main.go", true},
+		{[]string{"pkg/**/main.go"}, "pkg/main.go", true},
+		{[]string{"pkg"}, "pkg/sub/main.go", true},
+		{[]string{"pkg"}, "pkgs/main.go", false},
+		{[]string{"/pkg/"}, "pkg/main.go", true},
+		{[]string{"./pkg"}, "pkg", true},
+		{[]string{"ma?n.go"}, "main.go", true},
+		{[]string{"ma?n.go"}, "ma/n.go", false},
+		{[]string{"[a-m]ain.go"}, "main.go", true},
+		{[]string{"[^a-m]ain.go"}, "main.go", false},
+		{[]string{"[^a-m]ain.go"}, "rain.go", true},
+		{[]string{`\*.go`}, "*.go", true},
+		{[]string{`\*.go`}, "main.go", false},
+		{[]string{"a.b"}, "axb", false},
+		{[]string{"docs", "!docs/README.md"}, "docs/README.md", false},
+		{[]string{"docs", "!docs/README.md"}, "docs/index.md", true},
+		{[]string{"*.md", "!README.md", "README.md"}, "README.md", true},
+		{[]string{"!README.md"}, "README.md", false},
+		{[]string{"", "  "}, "README.md", false},
+	}
+
+	for _, c := range cases {
+		pm, err := NewPatternMatcher(c.patterns)
+		if err != nil {
+			t.Fatalf("%v: %v", c.patterns, err)
+		}
+		if m := pm.Matches(c.path); m != c.expected {
+			t.Fatalf("expected %v matching %s with %v, got %v", c.expected, c.path, c.patterns, m)
+		}
+	}
+}
+
+func TestPatternMatcherExclusions(t *testing.T) {
+	pm, err := NewPatternMatcher([]string{"docs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.Exclusions() {
+		t.Fatal("expected no exclusions")
+	}
+
+	pm, err = NewPatternMatcher([]string{"docs", "!docs/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pm.Exclusions() {
+		t.Fatal("expected exclusions")
+	}
+	if !pm.mayIncludeChildren("docs") {
+		t.Fatal("expected docs to include children")
+	}
+	if pm.mayIncludeChildren("vendor") {
+		t.Fatal("expected vendor not to include children")
+	}
+}
+
+func TestPatternMatcherErrors(t *testing.T) {
+	for _, patterns := range [][]string{{"!"}, {"[a-"}, {`a\`}} {
+		if _, err := NewPatternMatcher(patterns); err == nil {
+			t.Fatalf("expected an error for %v", patterns)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Package auth loads the credentials to authenticate with docker registries
+// from the docker cli configuration file and from credential helpers.
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+)
+
+// ConfigFileName is the name of the docker cli configuration file.
+const ConfigFileName = "config.json"
+
+// ConfigFile holds the registry credentials in the docker cli configuration file.
+type ConfigFile struct {
+	// AuthConfigs holds the credentials stored in the configuration file, by registry.
+	AuthConfigs map[string]types.AuthConfig `json:"auths"`
+	// CredentialsStore is the name of the credential helper that stores
+	// the credentials for all registries, i.e. osxkeychain.
+	CredentialsStore string `json:"credsStore,omitempty"`
+	// CredentialHelpers holds the name of the credential helper to use, by registry.
+	// They take precedence over the credentials store.
+	CredentialHelpers map[string]string `json:"credHelpers,omitempty"`
+
+	filename string
+	// raw holds the other settings in the file, they are kept to save them back.
+	raw map[string]*json.RawMessage
+}
+
+// ConfigDir returns the directory where the docker cli configuration is.
+// It uses DOCKER_CONFIG if it's set, and ~/.docker otherwise.
+func ConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	return filepath.Join(homeDir(), ".docker")
+}
+
+// Load reads the configuration file in the given directory.
+// It uses ConfigDir if the directory is empty.
+func Load(dir string) (*ConfigFile, error) {
+	if dir == "" {
+		dir = ConfigDir()
+	}
+	return LoadFile(filepath.Join(dir, ConfigFileName))
+}
+
+// LoadFile reads the configuration file with the given name.
+// It returns an empty configuration if the file doesn't exist.
+func LoadFile(filename string) (*ConfigFile, error) {
+	c := &ConfigFile{
+		AuthConfigs: make(map[string]types.AuthConfig),
+		filename:    filename,
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &c.raw); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", filename, err)
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", filename, err)
+	}
+	if c.AuthConfigs == nil {
+		c.AuthConfigs = make(map[string]types.AuthConfig)
+	}
+
+	for server, authConfig := range c.AuthConfigs {
+		authConfig, err := decodeAuth(authConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode the credentials for %s in %s: %v", server, filename, err)
+		}
+		authConfig.ServerAddress = server
+		c.AuthConfigs[server] = authConfig
+	}
+	return c, nil
+}
+
+// Filename returns the name of the configuration file.
+func (c *ConfigFile) Filename() string {
+	return c.filename
+}
+
+// Save writes the configuration back to its file.
+// The credentials are encoded like the docker cli does,
+// and the settings unknown to this package are preserved.
+func (c *ConfigFile) Save() error {
+	if c.filename == "" {
+		return fmt.Errorf("unable to save the configuration, its file name is not set")
+	}
+
+	auths := make(map[string]types.AuthConfig, len(c.AuthConfigs))
+	for server, authConfig := range c.AuthConfigs {
+		auths[server] = encodeAuth(authConfig)
+	}
+
+	raw := make(map[string]interface{}, len(c.raw)+3)
+	for k, v := range c.raw {
+		raw[k] = v
+	}
+	raw["auths"] = auths
+	delete(raw, "credsStore")
+	if c.CredentialsStore != "" {
+		raw["credsStore"] = c.CredentialsStore
+	}
+	delete(raw, "credHelpers")
+	if len(c.CredentialHelpers) > 0 {
+		raw["credHelpers"] = c.CredentialHelpers
+	}
+
+	b, err := json.MarshalIndent(raw, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.filename), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.filename, b, 0600)
+}
+
+// GetAuthConfig returns the credentials for the registry with the given hostname.
+// It uses the credential helper configured for the registry, the credentials store,
+// or the credentials in the file, in that order. It returns empty credentials,
+// with the server address, if there are none.
+func (c *ConfigFile) GetAuthConfig(hostname string) (types.AuthConfig, error) {
+	server := serverAddress(hostname)
+	if helper := c.helper(server); helper != nil {
+		return helper.Get(server)
+	}
+
+	// Several addresses can have the same hostname, like host and https://host.
+	// The exact address takes precedence, then the first one in sorted order.
+	if authConfig, ok := c.AuthConfigs[server]; ok {
+		authConfig.ServerAddress = server
+		return authConfig, nil
+	}
+	var addresses []string
+	for s := range c.AuthConfigs {
+		addresses = append(addresses, s)
+	}
+	sort.Strings(addresses)
+	for _, s := range addresses {
+		if ConvertToHostname(s) == ConvertToHostname(server) {
+			authConfig := c.AuthConfigs[s]
+			authConfig.ServerAddress = server
+			return authConfig, nil
+		}
+	}
+	return types.AuthConfig{ServerAddress: server}, nil
+}
+
+// GetAllAuthConfigs returns the credentials for all the registries known
+// to the configuration, by registry. It's meant to be used to build images,
+// which may pull images from any registry.
+func (c *ConfigFile) GetAllAuthConfigs() (map[string]types.AuthConfig, error) {
+	auths := make(map[string]types.AuthConfig, len(c.AuthConfigs))
+	for server, authConfig := range c.AuthConfigs {
+		auths[server] = authConfig
+	}
+
+	if c.CredentialsStore != "" {
+		servers, err := NewHelper(c.CredentialsStore).List()
+		if err != nil {
+			return nil, err
+		}
+		for server := range servers {
+			authConfig, err := NewHelper(c.CredentialsStore).Get(server)
+			if err != nil {
+				return nil, err
+			}
+			auths[server] = authConfig
+		}
+	}
+
+	for server, name := range c.CredentialHelpers {
+		authConfig, err := NewHelper(name).Get(server)
+		if err != nil {
+			return nil, err
+		}
+		auths[server] = authConfig
+	}
+	return auths, nil
+}
+
+// StoreAuthConfig stores the credentials for the registry in their server address.
+// They are stored in the credential helper configured for the registry, in the credentials
+// store, or in the configuration, in that order. Call Save to write the configuration
+// when they are stored in it.
+func (c *ConfigFile) StoreAuthConfig(authConfig types.AuthConfig) error {
+	authConfig.ServerAddress = serverAddress(authConfig.ServerAddress)
+	if helper := c.helper(authConfig.ServerAddress); helper != nil {
+		return helper.Store(authConfig)
+	}
+	c.AuthConfigs[authConfig.ServerAddress] = authConfig
+	return nil
+}
+
+// EraseAuthConfig removes the credentials for the registry with the given hostname.
+// Call Save to write the configuration when they are stored in it.
+func (c *ConfigFile) EraseAuthConfig(hostname string) error {
+	server := serverAddress(hostname)
+	if helper := c.helper(server); helper != nil {
+		return helper.Erase(server)
+	}
+	for s := range c.AuthConfigs {
+		if ConvertToHostname(s) == ConvertToHostname(server) {
+			delete(c.AuthConfigs, s)
+		}
+	}
+	return nil
+}
+
+// GetAuthConfigForReference returns the credentials for the registry
+// in the given image reference.
+func (c *ConfigFile) GetAuthConfigForReference(ref string) (types.AuthConfig, error) {
+	hostname, err := ParseRegistryHostname(ref)
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+	return c.GetAuthConfig(hostname)
+}
+
+// EncodedAuthForReference returns the credentials for the registry in the
+// given image reference, encoded to be sent in the X-Registry-Auth header.
+// Use it as the RegistryAuth in the options to pull and push images.
+func (c *ConfigFile) EncodedAuthForReference(ref string) (string, error) {
+	authConfig, err := c.GetAuthConfigForReference(ref)
+	if err != nil {
+		return "", err
+	}
+	return EncodeAuthConfig(authConfig)
+}
+
+// helper returns the credential helper for the registry,
+// it returns nil if the credentials are stored in the configuration.
+func (c *ConfigFile) helper(server string) *Helper {
+	for s, name := range c.CredentialHelpers {
+		if ConvertToHostname(s) == ConvertToHostname(server) {
+			return NewHelper(name)
+		}
+	}
+	if c.CredentialsStore != "" {
+		return NewHelper(c.CredentialsStore)
+	}
+	return nil
+}
+
+// EncodeAuthConfig encodes the credentials to be sent in the X-Registry-Auth header.
+func EncodeAuthConfig(authConfig types.AuthConfig) (string, error) {
+	buf, err := json.Marshal(authConfig)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeAuthConfig decodes the credentials sent in the X-Registry-Auth header.
+// It also accepts credentials encoded with the standard base64 encoding,
+// which some clients use instead of the url encoding.
+func DecodeAuthConfig(encoded string) (types.AuthConfig, error) {
+	var authConfig types.AuthConfig
+	if encoded == "" {
+		return authConfig, nil
+	}
+
+	buf, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		var stdErr error
+		if buf, stdErr = base64.StdEncoding.DecodeString(encoded); stdErr != nil {
+			return authConfig, fmt.Errorf("invalid registry credentials: %v", err)
+		}
+	}
+	if err := json.Unmarshal(buf, &authConfig); err != nil {
+		return authConfig, fmt.Errorf("invalid registry credentials: %v", err)
+	}
+	return authConfig, nil
+}
+
+// decodeAuth decodes the username and password in the auth field of the configuration file.
+func decodeAuth(authConfig types.AuthConfig) (types.AuthConfig, error) {
+	if authConfig.Auth == "" {
+		return authConfig, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(authConfig.Auth)
+	if err != nil {
+		return authConfig, err
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return authConfig, fmt.Errorf("invalid auth configuration")
+	}
+	authConfig.Username = parts[0]
+	authConfig.Password = strings.Trim(parts[1], "\x00")
+	authConfig.Auth = ""
+	return authConfig, nil
+}
+
+// encodeAuth encodes the username and password in the auth field, like the docker cli does.
+func encodeAuth(authConfig types.AuthConfig) types.AuthConfig {
+	encoded := types.AuthConfig{
+		Email:         authConfig.Email,
+		IdentityToken: authConfig.IdentityToken,
+	}
+	if authConfig.Username != "" || authConfig.Password != "" {
+		encoded.Auth = base64.StdEncoding.EncodeToString([]byte(authConfig.Username + ":" + authConfig.Password))
+	}
+	return encoded
+}
+
+// homeDir returns the home directory of the current user.
+func homeDir() string {
+	if home := os.Getenv("HOME"); home != "" {
+		return home
+	}
+	return os.Getenv("USERPROFILE")
+}
@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	c, err := Load(filepath.Join(os.TempDir(), "docker-config-does-not-exist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authConfig, err := c.GetAuthConfig("registry.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authConfig != (types.AuthConfig{ServerAddress: "registry.example.com"}) {
+		t.Fatalf("expected empty credentials, got %v", authConfig)
+	}
+}
+
+func TestLoadFromDockerConfigEnv(t *testing.T) {
+	dir := writeConfigFile(t, `{"auths": {}}`)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", dir)
+
+	c, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Filename() != filepath.Join(dir, ConfigFileName) {
+		t.Fatalf("expected the file in DOCKER_CONFIG, got %s", c.Filename())
+	}
+}
+
+func TestGetAuthConfigFromAuths(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	dir := writeConfigFile(t, `{
+	"auths": {
+		"https://index.docker.io/v1/": {"auth": "`+auth+`", "email": "user@example.com"},
+		"http://registry.example.com/v2/": {"identitytoken": "token"}
+	}
+}`)
+	defer os.RemoveAll(dir)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		ref      string
+		expected types.AuthConfig
+	}{
+		{"ubuntu", types.AuthConfig{Username: "user", Password: "secret", Email: "user@example.com", ServerAddress: IndexServer}},
+		{"registry.example.com/app:latest", types.AuthConfig{IdentityToken: "token", ServerAddress: "registry.example.com"}},
+		{"localhost:5000/app", types.AuthConfig{ServerAddress: "localhost:5000"}},
+	}
+	for _, cs := range cases {
+		authConfig, err := c.GetAuthConfigForReference(cs.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authConfig != cs.expected {
+			t.Fatalf("expected %v for %s, got %v", cs.expected, cs.ref, authConfig)
+		}
+	}
+}
+
+func TestGetAuthConfigCollidingAddresses(t *testing.T) {
+	dir := writeConfigFile(t, `{
+	"auths": {
+		"https://index.docker.io/v1/": {"identitytoken": "index"},
+		"index.docker.io": {"identitytoken": "hostname"},
+		"registry.example.com": {"identitytoken": "exact"},
+		"https://registry.example.com": {"identitytoken": "https"},
+		"https://localhost:5000": {"identitytoken": "https"},
+		"http://localhost:5000/v2/": {"identitytoken": "http"}
+	}
+}`)
+	defer os.RemoveAll(dir)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"docker.io":            "index",
+		"registry.example.com": "exact",
+		"localhost:5000":       "http",
+	}
+	// The map of addresses is iterated in a different order every time.
+	for i := 0; i < 20; i++ {
+		for hostname, expected := range cases {
+			authConfig, err := c.GetAuthConfig(hostname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if authConfig.IdentityToken != expected {
+				t.Fatalf("expected the %s credentials for %s, got %v", expected, hostname, authConfig)
+			}
+		}
+	}
+}
+
+func TestGetAuthConfigFromHelpers(t *testing.T) {
+	resetFakeHelper(t)
+	dir := writeConfigFile(t, `{
+	"auths": {"registry.example.com": {"auth": "`+base64.StdEncoding.EncodeToString([]byte("file:secret"))+`"}},
+	"credsStore": "fake",
+	"credHelpers": {"gcr.io": "missing"}
+}`)
+	defer os.RemoveAll(dir)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewHelper("fake").Store(types.AuthConfig{Username: "store", Password: "secret", ServerAddress: "registry.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The credentials store takes precedence over the file.
+	authConfig, err := c.GetAuthConfig("registry.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authConfig.Username != "store" {
+		t.Fatalf("expected the credentials from the store, got %v", authConfig)
+	}
+
+	// The registry helper takes precedence over the credentials store.
+	if _, err := c.GetAuthConfig("gcr.io"); err == nil {
+		t.Fatal("expected an error running the missing helper, got nil")
+	}
+}
+
+func TestStoreAndEraseAuthConfig(t *testing.T) {
+	dir := writeConfigFile(t, `{"auths": {}, "detachKeys": "ctrl-q"}`)
+	defer os.RemoveAll(dir)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.StoreAuthConfig(types.AuthConfig{Username: "user", Password: "secret", ServerAddress: "docker.io"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(c.Filename())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Auths      map[string]map[string]string `json:"auths"`
+		DetachKeys string                       `json:"detachKeys"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	expected := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	if raw.Auths[IndexServer]["auth"] != expected || len(raw.Auths[IndexServer]) != 1 {
+		t.Fatalf("expected encoded credentials for %s, got %v", IndexServer, raw.Auths)
+	}
+	if raw.DetachKeys != "ctrl-q" {
+		t.Fatalf("expected other settings to be preserved, got %s", b)
+	}
+
+	c, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EraseAuthConfig("docker.io"); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.AuthConfigs) != 0 {
+		t.Fatalf("expected the credentials to be erased, got %v", c.AuthConfigs)
+	}
+}
+
+func TestEncodedAuthForReference(t *testing.T) {
+	resetFakeHelper(t)
+	c := &ConfigFile{CredentialsStore: "fake"}
+	if err := c.StoreAuthConfig(types.AuthConfig{Username: "user", Password: "secret", ServerAddress: "localhost:5000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := c.EncodedAuthForReference("localhost:5000/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var authConfig types.AuthConfig
+	if err := json.Unmarshal(b, &authConfig); err != nil {
+		t.Fatal(err)
+	}
+	expected := types.AuthConfig{Username: "user", Password: "secret", ServerAddress: "localhost:5000"}
+	if authConfig != expected {
+		t.Fatalf("expected %v, got %v", expected, authConfig)
+	}
+
+	all, err := c.GetAllAuthConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all["localhost:5000"] != expected {
+		t.Fatalf("expected the credentials from the store, got %v", all)
+	}
+}
+
+func TestDecodeAuthConfig(t *testing.T) {
+	expected := types.AuthConfig{Username: "user", Password: "secret?>", ServerAddress: "localhost:5000"}
+	buf, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, encoded := range []string{
+		base64.URLEncoding.EncodeToString(buf),
+		base64.StdEncoding.EncodeToString(buf),
+	} {
+		authConfig, err := DecodeAuthConfig(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authConfig != expected {
+			t.Fatalf("expected %v, got %v", expected, authConfig)
+		}
+	}
+
+	if _, err := DecodeAuthConfig("not base64!"); err == nil {
+		t.Fatal("expected an error decoding invalid credentials, got nil")
+	}
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+)
+
+// helperPrefix is the prefix of the name of the credential helper programs.
+const helperPrefix = "docker-credential-"
+
+// tokenUsername is the username that helpers store with identity tokens.
+const tokenUsername = "<token>"
+
+// errCredentialsNotFoundMessage is the message that helpers print
+// when they don't have the credentials for a server.
+const errCredentialsNotFoundMessage = "credentials not found in native keychain"
+
+// ErrCredentialsNotFound is returned when a credential helper
+// doesn't have the credentials for a server.
+var ErrCredentialsNotFound = errors.New(errCredentialsNotFoundMessage)
+
+// helperCredentials is the message helpers exchange with their callers.
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// Helper runs a docker credential helper, i.e. docker-credential-osxkeychain,
+// to get and store the credentials for registries.
+type Helper struct {
+	name string
+}
+
+// NewHelper creates a new credential helper with the given name.
+// It runs the program docker-credential-<name>, it must be in the PATH.
+func NewHelper(name string) *Helper {
+	return &Helper{name: name}
+}
+
+// Get returns the credentials for the given server address.
+// It returns empty credentials if the helper doesn't have them.
+func (h *Helper) Get(server string) (types.AuthConfig, error) {
+	out, err := h.run("get", strings.NewReader(server))
+	if err != nil {
+		if err == ErrCredentialsNotFound {
+			return types.AuthConfig{ServerAddress: server}, nil
+		}
+		return types.AuthConfig{}, err
+	}
+
+	var creds helperCredentials
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return types.AuthConfig{}, fmt.Errorf("unable to decode the credentials from %s%s: %v", helperPrefix, h.name, err)
+	}
+
+	authConfig := types.AuthConfig{ServerAddress: server}
+	if creds.Username == tokenUsername {
+		authConfig.IdentityToken = creds.Secret
+	} else {
+		authConfig.Username = creds.Username
+		authConfig.Password = creds.Secret
+	}
+	return authConfig, nil
+}
+
+// Store saves the credentials for their server address.
+func (h *Helper) Store(authConfig types.AuthConfig) error {
+	creds := helperCredentials{
+		ServerURL: authConfig.ServerAddress,
+		Username:  authConfig.Username,
+		Secret:    authConfig.Password,
+	}
+	if authConfig.IdentityToken != "" {
+		creds.Username = tokenUsername
+		creds.Secret = authConfig.IdentityToken
+	}
+
+	buf, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	_, err = h.run("store", bytes.NewReader(buf))
+	return err
+}
+
+// Erase removes the credentials for the given server address.
+func (h *Helper) Erase(server string) error {
+	_, err := h.run("erase", strings.NewReader(server))
+	return err
+}
+
+// List returns the usernames stored in the helper, by server address.
+func (h *Helper) List() (map[string]string, error) {
+	out, err := h.run("list", strings.NewReader(""))
+	if err != nil {
+		return nil, err
+	}
+	servers := make(map[string]string)
+	if err := json.Unmarshal(out, &servers); err != nil {
+		return nil, fmt.Errorf("unable to decode the servers from %s%s: %v", helperPrefix, h.name, err)
+	}
+	return servers, nil
+}
+
+// run executes the helper's action with the given input,
+// and returns what the helper prints to its standard output.
+func (h *Helper) run(action string, input io.Reader) ([]byte, error) {
+	program := helperPrefix + h.name
+	cmd := exec.Command(program, action)
+	cmd.Stdin = input
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+
+	if _, ok := err.(*exec.Error); ok {
+		return nil, fmt.Errorf("credential helper %s is not installed: %v", program, err)
+	}
+	msg := strings.TrimSpace(string(out))
+	if msg == errCredentialsNotFoundMessage {
+		return nil, ErrCredentialsNotFound
+	}
+	if msg == "" {
+		msg = err.Error()
+	}
+	return nil, fmt.Errorf("error running %s %s: %s", program, action, msg)
+}
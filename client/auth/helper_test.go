@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+)
+
+// fakeHelperStoreEnv holds the file where the fake helper stores the credentials.
+const fakeHelperStoreEnv = "FAKE_CREDENTIAL_HELPER_STORE"
+
+// TestMain runs the test binary as a fake credential helper when it's called
+// docker-credential-fake, and it puts a copy with that name in the PATH for the tests.
+func TestMain(m *testing.M) {
+	if strings.HasPrefix(filepath.Base(os.Args[0]), helperPrefix+"fake") {
+		if err := fakeHelper(os.Args[1], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprint(os.Stdout, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	dir, err := ioutil.TempDir("", "docker-credential-helper")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := installFakeHelper(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	os.Setenv(fakeHelperStoreEnv, filepath.Join(dir, "store.json"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func installFakeHelper(dir string) error {
+	b, err := ioutil.ReadFile(os.Args[0])
+	if err != nil {
+		return err
+	}
+	name := helperPrefix + "fake"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return ioutil.WriteFile(filepath.Join(dir, name), b, 0700)
+}
+
+// fakeHelper implements the credential helpers protocol,
+// it stores the credentials in a json file.
+func fakeHelper(action string, in io.Reader, out io.Writer) error {
+	storeFile := os.Getenv(fakeHelperStoreEnv)
+	store := make(map[string]helperCredentials)
+	if b, err := ioutil.ReadFile(storeFile); err == nil {
+		if err := json.Unmarshal(b, &store); err != nil {
+			return err
+		}
+	}
+	input, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "get":
+		creds, ok := store[string(input)]
+		if !ok {
+			return fmt.Errorf(errCredentialsNotFoundMessage)
+		}
+		return json.NewEncoder(out).Encode(creds)
+	case "store":
+		var creds helperCredentials
+		if err := json.Unmarshal(input, &creds); err != nil {
+			return err
+		}
+		store[creds.ServerURL] = creds
+	case "erase":
+		if _, ok := store[string(input)]; !ok {
+			return fmt.Errorf(errCredentialsNotFoundMessage)
+		}
+		delete(store, string(input))
+	case "list":
+		servers := make(map[string]string)
+		for server, creds := range store {
+			servers[server] = creds.Username
+		}
+		return json.NewEncoder(out).Encode(servers)
+	default:
+		return fmt.Errorf("unknown action %s", action)
+	}
+
+	b, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(storeFile, b, 0600)
+}
+
+func resetFakeHelper(t *testing.T) {
+	if err := os.RemoveAll(os.Getenv(fakeHelperStoreEnv)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHelperStoreGetErase(t *testing.T) {
+	resetFakeHelper(t)
+	h := NewHelper("fake")
+
+	authConfig := types.AuthConfig{
+		Username:      "user",
+		Password:      "secret",
+		ServerAddress: "registry.example.com",
+	}
+	if err := h.Store(authConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := h.Get("registry.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != authConfig {
+		t.Fatalf("expected %v, got %v", authConfig, got)
+	}
+
+	servers, err := h.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 1 || servers["registry.example.com"] != "user" {
+		t.Fatalf("expected the server in the list, got %v", servers)
+	}
+
+	if err := h.Erase("registry.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = h.Get("registry.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Username != "" || got.Password != "" {
+		t.Fatalf("expected empty credentials after erasing them, got %v", got)
+	}
+}
+
+func TestHelperIdentityToken(t *testing.T) {
+	resetFakeHelper(t)
+	h := NewHelper("fake")
+
+	authConfig := types.AuthConfig{
+		IdentityToken: "token",
+		ServerAddress: "registry.example.com",
+	}
+	if err := h.Store(authConfig); err != nil {
+		t.Fatal(err)
+	}
+	got, err := h.Get("registry.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != authConfig {
+		t.Fatalf("expected %v, got %v", authConfig, got)
+	}
+}
+
+func TestHelperErrors(t *testing.T) {
+	resetFakeHelper(t)
+
+	err := NewHelper("fake").Erase("registry.example.com")
+	if err != ErrCredentialsNotFound {
+		t.Fatalf("expected ErrCredentialsNotFound, got %v", err)
+	}
+
+	_, err = NewHelper("missing").Get("registry.example.com")
+	if err == nil || !strings.Contains(err.Error(), "docker-credential-missing is not installed") {
+		t.Fatalf("expected a not installed error, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+// Provider provides the credentials in the docker cli configuration to the client.
+// It implements the client.AuthProvider interface. The configuration is loaded
+// every time the credentials are requested, to use the credentials stored
+// by other programs, like the docker cli, after the client was created.
+type Provider struct {
+	dir string
+}
+
+// NewProvider creates a new provider with the configuration in the given directory.
+// It uses ConfigDir if the directory is empty.
+func NewProvider(dir string) *Provider {
+	return &Provider{dir: dir}
+}
+
+// AuthConfig returns the credentials for the registry with the given hostname.
+func (p *Provider) AuthConfig(ctx context.Context, hostname string) (types.AuthConfig, error) {
+	c, err := Load(p.dir)
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+	return c.GetAuthConfig(hostname)
+}
+
+// RefreshAuthConfig returns the credentials for the registry with the given hostname
+// after the registry rejected them. Credential helpers that issue short lived tokens
+// return new ones, the rejected credentials are returned otherwise.
+func (p *Provider) RefreshAuthConfig(ctx context.Context, hostname string, rejected types.AuthConfig) (types.AuthConfig, error) {
+	return p.AuthConfig(ctx, hostname)
+}
+
+// AllAuthConfigs returns the credentials for all the registries in the configuration.
+func (p *Provider) AllAuthConfigs(ctx context.Context) (map[string]types.AuthConfig, error) {
+	c, err := Load(p.dir)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetAllAuthConfigs()
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	distreference "github.com/docker/distribution/reference"
+)
+
+const (
+	// IndexServer is the server address of the docker hub
+	// used to store its credentials.
+	IndexServer = "https://index.docker.io/v1/"
+	// IndexHostname is the hostname of the docker hub in image references.
+	IndexHostname = "docker.io"
+)
+
+// ParseRegistryHostname returns the hostname of the registry that
+// stores the image with the given reference, i.e. "localhost:5000"
+// for "localhost:5000/foo:latest". It returns IndexHostname for
+// images in the docker hub, like "ubuntu" or "docker/compose".
+func ParseRegistryHostname(ref string) (string, error) {
+	r, err := distreference.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	named, ok := r.(distreference.Named)
+	if !ok {
+		return "", fmt.Errorf("reference %s has no repository name", ref)
+	}
+
+	return splitHostname(named.Name()), nil
+}
+
+// ParseSearchTermHostname returns the hostname of the registry to search
+// images in with the given term, i.e. "registry.example.com" for
+// "registry.example.com/app". It returns IndexHostname to search
+// in the docker hub, like for "ubuntu".
+func ParseSearchTermHostname(term string) string {
+	return splitHostname(term)
+}
+
+// splitHostname returns the hostname of the registry in a repository name,
+// it's IndexHostname if the first component of the name is not a hostname.
+func splitHostname(name string) string {
+	i := strings.IndexRune(name, '/')
+	if i == -1 {
+		return IndexHostname
+	}
+	hostname := name[:i]
+	// The first component is the repository owner in the docker hub,
+	// unless it looks like a hostname.
+	if !strings.ContainsAny(hostname, ".:") && hostname != "localhost" {
+		return IndexHostname
+	}
+	if hostname == "index.docker.io" {
+		return IndexHostname
+	}
+	return hostname
+}
+
+// ConvertToHostname removes the scheme and the path from
+// a registry server address, i.e. https://example.com/v1/.
+func ConvertToHostname(server string) string {
+	hostname := server
+	if i := strings.Index(hostname, "://"); i != -1 {
+		hostname = hostname[i+3:]
+	}
+	if i := strings.IndexRune(hostname, '/'); i != -1 {
+		hostname = hostname[:i]
+	}
+	return hostname
+}
+
+// serverAddress returns the address used to store the credentials
+// for the registry with the given hostname or server address.
+// The credentials for the docker hub are stored in IndexServer.
+func serverAddress(hostname string) string {
+	switch ConvertToHostname(hostname) {
+	case "", IndexHostname, "index.docker.io", "registry-1.docker.io":
+		return IndexServer
+	}
+	return hostname
+}
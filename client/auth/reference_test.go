@@ -0,0 +1,66 @@
+package auth
+
+import "testing"
+
+func TestParseRegistryHostname(t *testing.T) {
+	cases := []struct {
+		ref      string
+		hostname string
+		err      bool
+	}{
+		{"ubuntu", IndexHostname, false},
+		{"ubuntu:14.04", IndexHostname, false},
+		{"docker/compose", IndexHostname, false},
+		{"docker.io/library/ubuntu", IndexHostname, false},
+		{"index.docker.io/library/ubuntu", IndexHostname, false},
+		{"localhost/foo", "localhost", false},
+		{"localhost:5000/foo:latest", "localhost:5000", false},
+		{"registry.example.com/team/app@sha256:9b93ef9f1c0b8af5a55b1f1e21a2b3d1be4f1c8d2a3b6e4f1c2d3e4f5a6b7c8d", "registry.example.com", false},
+		{"", "", true},
+		{"UPPERCASE", "", true},
+	}
+
+	for _, cs := range cases {
+		hostname, err := ParseRegistryHostname(cs.ref)
+		if cs.err {
+			if err == nil {
+				t.Fatalf("expected error for %q, got nil", cs.ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hostname != cs.hostname {
+			t.Fatalf("expected hostname %s for %s, got %s", cs.hostname, cs.ref, hostname)
+		}
+	}
+}
+
+func TestConvertToHostname(t *testing.T) {
+	cases := map[string]string{
+		"https://index.docker.io/v1/": "index.docker.io",
+		"http://localhost:5000":       "localhost:5000",
+		"registry.example.com/v2/":    "registry.example.com",
+		"registry.example.com":        "registry.example.com",
+	}
+	for server, hostname := range cases {
+		if h := ConvertToHostname(server); h != hostname {
+			t.Fatalf("expected %s for %s, got %s", hostname, server, h)
+		}
+	}
+}
+
+func TestParseSearchTermHostname(t *testing.T) {
+	cases := map[string]string{
+		"ubuntu":                   IndexHostname,
+		"docker/compose":           IndexHostname,
+		"registry.example.com/app": "registry.example.com",
+		"localhost:5000/app":       "localhost:5000",
+	}
+	for term, hostname := range cases {
+		if h := ParseSearchTermHostname(term); h != hostname {
+			t.Fatalf("expected %s for %s, got %s", hostname, term, h)
+		}
+	}
+}
@@ -1,20 +1,34 @@
 package client
 
 import (
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/engine-api/client/transport"
-	"github.com/docker/go-connections/tlsconfig"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/versions"
+	"github.com/docker/go-connections/sockets"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/context"
 )
 
+// DefaultVersion is the highest API version supported by this client,
+// the version of the newest options it checks, like HostConfig.AutoRemove.
+// It's used as the upper bound for API version negotiation.
+const DefaultVersion = "1.25"
+
 // Client is the API client that performs all operations
 // against a docker server.
 type Client struct {
+	// host holds the server address as it was configured, i.e. unix:///var/run/docker.sock.
+	host string
 	// proto holds the client protocol i.e. unix.
 	proto string
 	// addr holds the client address.
@@ -27,39 +41,118 @@ type Client struct {
 	version string
 	// custom http headers configured by users.
 	customHTTPHeaders map[string]string
+	// negotiateVersion indicates if the client must negotiate
+	// the API version with the server before the first request.
+	negotiateVersion bool
+	// negotiated indicates if the API version has already been negotiated.
+	negotiated bool
+	// negotiating is closed when the negotiation in progress ends, it's nil
+	// when there is none.
+	negotiating chan struct{}
+	// negotiateMu guards the API version and the state of the negotiation.
+	negotiateMu sync.Mutex
+
+	// client is the http client configured by users, if any.
+	client *http.Client
+	// tlsConfig is the TLS configuration used to connect to the server.
+	tlsConfig *tls.Config
+	// dialer is used to open connections to the server, if it's set.
+	dialer *net.Dialer
+	// timeout is the time limit for requests made by the http client.
+	timeout time.Duration
+	// handshakeTimeout is the time limit to dial the server and upgrade
+	// the connection before hijacking it.
+	handshakeTimeout time.Duration
+	// interceptors are called with every request sent to the server.
+	interceptors []Interceptor
+	// sshConfig is the ssh configuration used to connect to ssh hosts.
+	sshConfig *ssh.ClientConfig
+	// sshTunnel opens the connections to the server when the host is an ssh host.
+	sshTunnel *sshTunnel
+	// authProvider provides the credentials to authenticate with registries.
+	authProvider AuthProvider
 }
 
-// NewEnvClient initializes a new API client based on environment variables.
-// Use DOCKER_HOST to set the url to the docker server.
-// Use DOCKER_API_VERSION to set the version of the API to reach, leave empty for latest.
-// Use DOCKER_CERT_PATH to load the tls certificates from.
-// Use DOCKER_TLS_VERIFY to enable or disable TLS verification, off by default.
-func NewEnvClient() (*Client, error) {
-	var client *http.Client
-	if dockerCertPath := os.Getenv("DOCKER_CERT_PATH"); dockerCertPath != "" {
-		options := tlsconfig.Options{
-			CAFile:             filepath.Join(dockerCertPath, "ca.pem"),
-			CertFile:           filepath.Join(dockerCertPath, "cert.pem"),
-			KeyFile:            filepath.Join(dockerCertPath, "key.pem"),
-			InsecureSkipVerify: os.Getenv("DOCKER_TLS_VERIFY") == "",
+// NewClientWithOpts initializes a new API client with the default configuration,
+// and applies the given options to it. The default configuration connects
+// to DefaultDockerHost, and it doesn't send any version information.
+// It uses Docker's default http transport configuration unless
+// a custom http client is provided with WithHTTPClient.
+// Custom http clients are not supported with ssh hosts.
+// The transport of a custom http client can be any http.RoundTripper,
+// use WithTLSClientConfig to tell the client that it connects over TLS.
+func NewClientWithOpts(opts ...Opt) (*Client, error) {
+	cli := &Client{}
+	if err := WithHost(DefaultDockerHost)(cli); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		if err := opt(cli); err != nil {
+			return nil, err
 		}
-		tlsc, err := tlsconfig.Client(options)
+	}
+
+	if cli.proto == "ssh" {
+		if cli.client != nil {
+			return nil, fmt.Errorf("a custom http client cannot be used with the ssh host `%s`, the requests must go through the ssh tunnel", cli.host)
+		}
+		tunnel, err := newSSHTunnel(cli.host, cli.sshConfig, cli.dialer)
 		if err != nil {
 			return nil, err
 		}
+		cli.sshTunnel = tunnel
+		// The ssh connection is already secure, TLS is not used on top of it.
+		cli.tlsConfig = nil
+	}
 
+	client := cli.client
+	if client == nil {
+		var tr *http.Transport
+		if cli.sshTunnel != nil {
+			tr = &http.Transport{
+				Dial:               cli.sshTunnel.Dial,
+				DisableCompression: true,
+			}
+		} else {
+			var err error
+			tr, err = newHTTPTransport(cli.proto, cli.addr, cli.dialer, cli.tlsConfig)
+			if err != nil {
+				return nil, err
+			}
+		}
 		client = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: tlsc,
-			},
+			Transport: tr,
 		}
 	}
 
-	host := os.Getenv("DOCKER_HOST")
-	if host == "" {
-		host = DefaultDockerHost
+	if cli.timeout != 0 {
+		// Make a copy to avoid modifying the client provided by the user.
+		c := *client
+		c.Timeout = cli.timeout
+		client = &c
+	}
+
+	var (
+		tr  transport.Client
+		err error
+	)
+	if cli.tlsConfig != nil {
+		tr, err = transport.NewTransportWithTLSConfig(cli.proto, cli.addr, client, cli.tlsConfig)
+	} else {
+		tr, err = transport.NewTransportWithHTTP(cli.proto, cli.addr, client)
 	}
-	return NewClient(host, os.Getenv("DOCKER_API_VERSION"), client, nil)
+	if err != nil {
+		return nil, err
+	}
+	cli.transport = tr
+	return cli, nil
+}
+
+// NewEnvClient initializes a new API client based on environment variables.
+// See FromEnv for the list of variables it uses.
+func NewEnvClient() (*Client, error) {
+	return NewClientWithOpts(FromEnv)
 }
 
 // NewClient initializes a new API client for the given host and API version.
@@ -67,32 +160,64 @@ func NewEnvClient() (*Client, error) {
 // It uses the given http client as transport.
 // It also initializes the custom http headers to add to each request.
 func NewClient(host string, version string, client *http.Client, httpHeaders map[string]string) (*Client, error) {
-	proto, addr, basePath, err := ParseHost(host)
-	if err != nil {
-		return nil, err
-	}
+	return NewClientWithOpts(
+		WithHost(host),
+		WithVersion(version),
+		WithHTTPClient(client),
+		WithHTTPHeaders(httpHeaders),
+	)
+}
 
-	transport, err := transport.NewTransportWithHTTP(proto, addr, client)
-	if err != nil {
+// NewVersionNegotiatingClient initializes a new API client for the given host
+// that negotiates the API version with the server.
+// The client pings the server before sending its first request and
+// it uses the highest API version that both, client and server, support.
+// It uses the given http client as transport.
+// It also initializes the custom http headers to add to each request.
+func NewVersionNegotiatingClient(host string, client *http.Client, httpHeaders map[string]string) (*Client, error) {
+	return NewClientWithOpts(
+		WithHost(host),
+		WithHTTPClient(client),
+		WithHTTPHeaders(httpHeaders),
+		WithAPIVersionNegotiation(),
+	)
+}
+
+// newHTTPTransport creates a new http.Transport with Docker's
+// default transport configuration for the given proto and address.
+func newHTTPTransport(proto, addr string, dialer *net.Dialer, tlsConfig *tls.Config) (*http.Transport, error) {
+	tr := &http.Transport{
+		TLSClientConfig: tlsConfig,
+	}
+	if err := sockets.ConfigureTransport(tr, proto, addr); err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		proto:             proto,
-		addr:              addr,
-		basePath:          basePath,
-		transport:         transport,
-		version:           version,
-		customHTTPHeaders: httpHeaders,
-	}, nil
+	if dialer != nil {
+		switch proto {
+		case "unix":
+			tr.Dial = func(_, _ string) (net.Conn, error) {
+				return dialer.Dial(proto, addr)
+			}
+		case "npipe":
+			// Named pipes are not dialed with a net.Dialer.
+		default:
+			proxyDialer, err := sockets.DialerFromEnvironment(dialer)
+			if err != nil {
+				return nil, err
+			}
+			tr.Dial = proxyDialer.Dial
+		}
+	}
+	return tr, nil
 }
 
 // getAPIPath returns the versioned request path to call the api.
 // It appends the query parameters to the path if they are not empty.
 func (cli *Client) getAPIPath(p string, query url.Values) string {
 	var apiPath string
-	if cli.version != "" {
-		v := strings.TrimPrefix(cli.version, "v")
+	if version := cli.ClientVersion(); version != "" {
+		v := strings.TrimPrefix(version, "v")
 		apiPath = fmt.Sprintf("%s/v%s%s", cli.basePath, v, p)
 	} else {
 		apiPath = fmt.Sprintf("%s%s", cli.basePath, p)
@@ -111,13 +236,114 @@ func (cli *Client) getAPIPath(p string, query url.Values) string {
 // instance of the Client. Note that this value can be changed
 // via the DOCKER_API_VERSION env var.
 func (cli *Client) ClientVersion() string {
+	cli.negotiateMu.Lock()
+	defer cli.negotiateMu.Unlock()
 	return cli.version
 }
 
 // UpdateClientVersion updates the version string associated with this
-// instance of the Client.
+// instance of the Client. The version is not negotiated with the server
+// after calling this function.
 func (cli *Client) UpdateClientVersion(v string) {
+	cli.negotiateMu.Lock()
 	cli.version = v
+	cli.negotiated = true
+	cli.negotiateMu.Unlock()
+}
+
+// NegotiateAPIVersion pings the server and updates the version of the client
+// to the highest API version that both, client and server, support.
+// The version of the client is never upgraded, it's downgraded to the
+// version of the server when the server doesn't support it.
+func (cli *Client) NegotiateAPIVersion(ctx context.Context) error {
+	version, err := cli.negotiateAPIVersion(ctx, cli.ClientVersion())
+	if err != nil {
+		return err
+	}
+	cli.negotiateMu.Lock()
+	cli.version = version
+	cli.negotiated = true
+	cli.negotiateMu.Unlock()
+	return nil
+}
+
+// checkAPIVersion negotiates the API version with the server if the client
+// was configured to do it and the version has not been negotiated yet.
+// Only one request pings the server, the others wait for the negotiation
+// until their context is done. When the negotiation fails, the request
+// that pinged the server returns the error and the client keeps its
+// configured version, it doesn't ping the server again.
+func (cli *Client) checkAPIVersion(ctx context.Context) error {
+	if !cli.negotiateVersion {
+		return nil
+	}
+
+	cli.negotiateMu.Lock()
+	if cli.negotiated {
+		cli.negotiateMu.Unlock()
+		return nil
+	}
+	if done := cli.negotiating; done != nil {
+		cli.negotiateMu.Unlock()
+		select {
+		case <-done:
+			// Negotiate again if the context of the request
+			// that pinged the server was done.
+			return cli.checkAPIVersion(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	cli.negotiating = done
+	configured := cli.version
+	cli.negotiateMu.Unlock()
+
+	version, err := cli.negotiateAPIVersion(ctx, configured)
+
+	cli.negotiateMu.Lock()
+	defer cli.negotiateMu.Unlock()
+	cli.negotiating = nil
+	close(done)
+	if err != nil && ctx.Err() != nil {
+		return err
+	}
+	if err == nil && !cli.negotiated {
+		cli.version = version
+	}
+	cli.negotiated = true
+	return err
+}
+
+// negotiateAPIVersion pings the server and returns the highest API version
+// that both, the server and the client with the given version, support.
+func (cli *Client) negotiateAPIVersion(ctx context.Context, clientVersion string) (string, error) {
+	// The ping goes through the interceptors like any other request,
+	// they can retry it or add the headers that a proxy requires.
+	apiReq := cli.newAPIRequest("GET", "/version", nil, nil, nil)
+	resp, err := cli.intercept(ctx, apiReq, cli.sendUnversionedRequest)
+	if err != nil {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var server types.Version
+	if err := json.NewDecoder(resp.Body).Decode(&server); err != nil {
+		return "", fmt.Errorf("Error reading remote version: %v", err)
+	}
+
+	version := strings.TrimPrefix(clientVersion, "v")
+	if version == "" {
+		version = DefaultVersion
+	}
+	serverVersion := strings.TrimPrefix(server.APIVersion, "v")
+	if serverVersion != "" && versions.LessThan(serverVersion, version) {
+		version = serverVersion
+	}
+	return version, nil
 }
 
 // ParseHost verifies that the given host strings is valid.
@@ -129,6 +355,13 @@ func ParseHost(host string) (string, string, string, error) {
 
 	var basePath string
 	proto, addr := protoAddrParts[0], protoAddrParts[1]
+	if proto == "ssh" {
+		_, addr, _, err := parseSSHHost(host)
+		if err != nil {
+			return "", "", "", err
+		}
+		return proto, addr, "", nil
+	}
 	if proto == "tcp" {
 		parsed, err := url.Parse("tcp://" + addr)
 		if err != nil {
@@ -3,11 +3,16 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/docker/engine-api/types"
 	"golang.org/x/net/context"
@@ -57,6 +62,9 @@ func TestParseHost(t *testing.T) {
 		{"foo://bar", "foo", "bar", "", false},
 		{"tcp://localhost:2476", "tcp", "localhost:2476", "", false},
 		{"tcp://localhost:2476/path", "tcp", "localhost:2476", "/path", false},
+		{"ssh://me@example.com", "ssh", "example.com:22", "", false},
+		{"ssh://me@example.com:2222/var/run/docker.sock", "ssh", "example.com:2222", "", false},
+		{"ssh://", "", "", "", true},
 	}
 
 	for _, cs := range cases {
@@ -117,3 +125,268 @@ func TestUpdateClientVersion(t *testing.T) {
 		}
 	}
 }
+
+func versionMock(serverVersion string, pings *int32) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/version" {
+			atomic.AddInt32(pings, 1)
+			b, err := json.Marshal(types.Version{
+				APIVersion: serverVersion,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(req.URL.Path))),
+		}, nil
+	}
+}
+
+func TestNegotiateAPIVersion(t *testing.T) {
+	cases := []struct {
+		clientVersion string
+		serverVersion string
+		expected      string
+	}{
+		{"", "1.20", "1.20"},
+		{"", "1.30", DefaultVersion},
+		{"", "", DefaultVersion},
+		{"1.22", "1.21", "1.21"},
+		{"v1.22", "1.23", "1.22"},
+		{"1.22", "v1.21", "1.21"},
+	}
+
+	for _, cs := range cases {
+		var pings int32
+		client := &Client{
+			transport: newMockClient(nil, versionMock(cs.serverVersion, &pings)),
+			version:   cs.clientVersion,
+		}
+		if err := client.NegotiateAPIVersion(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if v := client.ClientVersion(); v != cs.expected {
+			t.Fatalf("expected version %s for client %q and server %q, got %s", cs.expected, cs.clientVersion, cs.serverVersion, v)
+		}
+	}
+}
+
+func TestNegotiateAPIVersionError(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, errorMock(http.StatusInternalServerError, "Server error")),
+		version:   "1.22",
+	}
+	err := client.NegotiateAPIVersion(context.Background())
+	if err == nil || err.Error() != "Error response from daemon: Server error" {
+		t.Fatalf("expected a Server Error, got %v", err)
+	}
+	if client.ClientVersion() != "1.22" {
+		t.Fatalf("expected version 1.22, got %s", client.ClientVersion())
+	}
+}
+
+func TestVersionNegotiationOnFirstRequest(t *testing.T) {
+	var pings int32
+	client, err := NewVersionNegotiatingClient("tcp://localhost:2375", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.transport = newMockClient(nil, versionMock("1.21", &pings))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.get(context.Background(), "/containers/json", nil, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer ensureReaderClosed(resp)
+			path, err := ioutil.ReadAll(resp.body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(path) != "/v1.21/containers/json" {
+				t.Errorf("expected /v1.21/containers/json, got %s", path)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if pings != 1 {
+		t.Fatalf("expected the server to be pinged once, got %d", pings)
+	}
+}
+
+func TestVersionNegotiationWaitRespectsContext(t *testing.T) {
+	var pings int32
+	release := make(chan struct{})
+	ping := versionMock("1.21", &pings)
+	client, err := NewVersionNegotiatingClient("tcp://localhost:2375", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.transport = newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/version" {
+			<-release
+		}
+		return ping(req)
+	})
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.get(context.Background(), "/containers/json", nil, nil)
+		first <- err
+	}()
+	// Wait for the first request to ping the server.
+	for {
+		client.negotiateMu.Lock()
+		negotiating := client.negotiating != nil
+		client.negotiateMu.Unlock()
+		if negotiating {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.get(ctx, "/containers/json", nil, nil); err != context.DeadlineExceeded {
+		t.Fatalf("expected the request to stop waiting for the negotiation, got %v", err)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+	if v := client.ClientVersion(); v != "1.21" {
+		t.Fatalf("expected version 1.21, got %s", v)
+	}
+}
+
+func TestVersionNegotiationFailureIsRemembered(t *testing.T) {
+	var pings int
+	client := &Client{
+		negotiateVersion: true,
+		version:          "1.22",
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/version" {
+				pings++
+				return errorMock(http.StatusInternalServerError, "Server error")(req)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(req.URL.Path))),
+			}, nil
+		}),
+	}
+
+	if _, err := client.get(context.Background(), "/containers/json", nil, nil); err == nil {
+		t.Fatal("expected the negotiation error, got nil")
+	}
+	resp, err := client.get(context.Background(), "/containers/json", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ensureReaderClosed(resp)
+	path, err := ioutil.ReadAll(resp.body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(path) != "/v1.22/containers/json" {
+		t.Fatalf("expected the configured version, got %s", path)
+	}
+	if pings != 1 {
+		t.Fatalf("expected the server to be pinged once, got %d", pings)
+	}
+}
+
+func TestUpdateClientVersionDisablesNegotiation(t *testing.T) {
+	var pings int32
+	client, err := NewVersionNegotiatingClient("tcp://localhost:2375", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.transport = newMockClient(nil, versionMock("1.21", &pings))
+	client.UpdateClientVersion("1.23")
+
+	resp, err := client.get(context.Background(), "/containers/json", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ensureReaderClosed(resp)
+	if pings != 0 {
+		t.Fatalf("expected the server not to be pinged, got %d pings", pings)
+	}
+	if client.ClientVersion() != "1.23" {
+		t.Fatalf("expected version 1.23, got %s", client.ClientVersion())
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewClientWithCustomRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Wrapped") != "1" {
+			http.Error(w, "missing header", http.StatusBadRequest)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/attach") {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			fmt.Fprint(conn, "HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\nattached")
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	var requests int32
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requests, 1)
+			req.Header.Set("X-Wrapped", "1")
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	}
+	client, err := NewClient("tcp://"+server.Listener.Addr().String(), "1.22", httpClient, map[string]string{"X-Wrapped": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.ContainerList(context.Background(), types.ContainerListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected the request to go through the round tripper, got %d requests", requests)
+	}
+
+	resp, err := client.ContainerAttach(context.Background(), "container_id", types.ContainerAttachOptions{Stream: true, Stdout: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+	out, err := ioutil.ReadAll(resp.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "attached" {
+		t.Fatalf("expected `attached`, got %q", out)
+	}
+}
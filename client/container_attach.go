@@ -12,6 +12,13 @@ import (
 // and the a reader to get output. It's up to the called to close
 // the hijacked connection by calling types.HijackedResponse.Close.
 func (cli *Client) ContainerAttach(ctx context.Context, container string, options types.ContainerAttachOptions) (types.HijackedResponse, error) {
+	query := containerAttachQuery(options)
+	headers := map[string][]string{"Content-Type": {"text/plain"}}
+	return cli.postHijacked(ctx, "/containers/"+container+"/attach", query, nil, headers)
+}
+
+// containerAttachQuery returns the query parameters to attach to a container.
+func containerAttachQuery(options types.ContainerAttachOptions) url.Values {
 	query := url.Values{}
 	if options.Stream {
 		query.Set("stream", "1")
@@ -28,7 +35,5 @@ func (cli *Client) ContainerAttach(ctx context.Context, container string, option
 	if options.DetachKeys != "" {
 		query.Set("detachKeys", options.DetachKeys)
 	}
-
-	headers := map[string][]string{"Content-Type": {"text/plain"}}
-	return cli.postHijacked(ctx, "/containers/"+container+"/attach", query, nil, headers)
+	return query
 }
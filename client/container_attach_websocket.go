@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+)
+
+// maxRecordedHandshake is the maximum number of bytes recorded
+// from the server's response to the websocket handshake.
+const maxRecordedHandshake = 64 * 1024
+
+// ContainerAttachWebsocket attaches a connection to a container in the server over a websocket.
+// It's an alternative to ContainerAttach for networks where proxies or load balancers don't
+// allow to upgrade the connection to raw tcp. It returns a types.HijackedResponse with the
+// websocket connection and a reader to get output, the output is not multiplexed.
+// It's up to the caller to close the connection by calling types.HijackedResponse.Close.
+// Websockets cannot be closed for writing, types.HijackedResponse.CloseWrite does nothing.
+func (cli *Client) ContainerAttachWebsocket(ctx context.Context, container string, options types.ContainerAttachOptions) (types.HijackedResponse, error) {
+	if err := cli.checkAPIVersion(ctx); err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	apiReq := &APIRequest{
+		Method: "GET",
+		Path:   "/containers/" + container + "/attach/ws",
+		Query:  containerAttachQuery(options),
+		Header: cli.requestHeaders(nil),
+		Hijack: true,
+	}
+
+	resp, err := cli.intercept(ctx, apiReq, cli.websocketAPIRequest)
+	if resp == nil {
+		return types.HijackedResponse{}, err
+	}
+	return resp.Hijacked, err
+}
+
+// websocketAPIRequest opens a websocket with the docker API,
+// it's the last step in the chain of interceptors.
+func (cli *Client) websocketAPIRequest(ctx context.Context, apiReq *APIRequest) (*APIResponse, error) {
+	host := cli.addr
+	if cli.proto == "unix" || cli.proto == "npipe" || cli.proto == "ssh" {
+		// See doRequest, the host doesn't matter for local communications.
+		host = "docker"
+	}
+	scheme, origin := "ws", "http"
+	if cli.transport.Secure() {
+		scheme, origin = "wss", "https"
+	}
+
+	location, err := url.Parse(scheme + "://" + host + cli.getAPIPath(apiReq.Path, apiReq.Query))
+	if err != nil {
+		return nil, err
+	}
+	config, err := websocket.NewConfig(location.String(), origin+"://"+host)
+	if err != nil {
+		return nil, err
+	}
+	config.Header = apiReq.Header
+
+	handshakeCtx, cancel, timeout := cli.handshakeContext(ctx)
+	defer cancel()
+
+	conn, err := cli.dialHijack(handshakeCtx)
+	if err != nil {
+		if ctxErr := handshakeError(ctx, handshakeCtx, timeout); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	recorder := &recordingConn{Conn: conn}
+	handshakeDone := closeOnDone(handshakeCtx, conn)
+	ws, err := websocket.NewClient(config, recorder)
+	handshakeDone()
+	if ctxErr := handshakeError(ctx, handshakeCtx, timeout); ctxErr != nil {
+		conn.Close()
+		return nil, ctxErr
+	}
+	if err != nil {
+		conn.Close()
+		if err == websocket.ErrBadStatus {
+			// The websocket package doesn't return the response,
+			// read it from the data received from the server.
+			if resp, respErr := http.ReadResponse(bufio.NewReader(bytes.NewReader(recorder.recorded.Bytes())), nil); respErr == nil {
+				body, _ := ioutil.ReadAll(resp.Body)
+				return &APIResponse{
+					StatusCode: resp.StatusCode,
+					Header:     resp.Header,
+				}, newServerError(resp.StatusCode, apiReq.Method, apiReq.Path, cli.ClientVersion(), location.String(), body)
+			}
+		}
+		return nil, err
+	}
+	recorder.stopRecording()
+
+	return &APIResponse{
+		StatusCode: http.StatusSwitchingProtocols,
+		Hijacked: types.HijackedResponse{
+			Conn:   newHijackedConn(ctx, ws),
+			Reader: bufio.NewReader(ws),
+		},
+	}, nil
+}
+
+// recordingConn records the data read from a connection during
+// the websocket handshake, to report the server's errors.
+type recordingConn struct {
+	net.Conn
+	recorded bytes.Buffer
+	stopped  bool
+}
+
+// Read reads data from the connection and records it.
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if !c.stopped && c.recorded.Len() < maxRecordedHandshake {
+		c.recorded.Write(b[:n])
+	}
+	return n, err
+}
+
+// stopRecording stops recording the data read from the connection.
+func (c *recordingConn) stopRecording() {
+	c.stopped = true
+	c.recorded = bytes.Buffer{}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+)
+
+// websocketAttachHandler echoes the input of the attached containers,
+// it returns 404 for unknown containers.
+func websocketAttachHandler() http.Handler {
+	echo := websocket.Handler(func(ws *websocket.Conn) {
+		io.Copy(ws, ws)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/container_id/attach/ws" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"No such container: unknown"}`)
+			return
+		}
+		if r.URL.Query().Get("stdin") != "1" || r.URL.Query().Get("stream") != "1" {
+			http.Error(w, "expected stream and stdin", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Custom") != "value" {
+			http.Error(w, "expected custom header", http.StatusBadRequest)
+			return
+		}
+		echo.ServeHTTP(w, r)
+	})
+}
+
+func assertWebsocketEcho(t *testing.T, client *Client) {
+	resp, err := client.ContainerAttachWebsocket(context.Background(), "container_id", types.ContainerAttachOptions{Stream: true, Stdin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+
+	if _, err := resp.Conn.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := resp.Reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "hello\n" {
+		t.Fatalf("expected hello, got %q", line)
+	}
+	if err := resp.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContainerAttachWebsocket(t *testing.T) {
+	server := httptest.NewServer(websocketAttachHandler())
+	defer server.Close()
+
+	client, err := NewClientWithOpts(WithHost("tcp://"+server.Listener.Addr().String()), WithHTTPHeaders(map[string]string{"Custom": "value"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertWebsocketEcho(t, client)
+}
+
+func TestContainerAttachWebsocketTLS(t *testing.T) {
+	server := httptest.NewTLSServer(websocketAttachHandler())
+	defer server.Close()
+
+	// The server's client trusts its certificate.
+	client, err := NewClientWithOpts(
+		WithHost("tcp://"+server.Listener.Addr().String()),
+		WithHTTPClient(server.Client()),
+		WithHTTPHeaders(map[string]string{"Custom": "value"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertWebsocketEcho(t, client)
+}
+
+func TestContainerAttachWebsocketUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-client-websocket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "docker.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(websocketAttachHandler())
+	server.Listener = l
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClientWithOpts(WithHost("unix://"+socket), WithHTTPHeaders(map[string]string{"Custom": "value"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertWebsocketEcho(t, client)
+}
+
+func TestContainerAttachWebsocketError(t *testing.T) {
+	server := httptest.NewServer(websocketAttachHandler())
+	defer server.Close()
+
+	client, err := NewClientWithOpts(WithHost("tcp://" + server.Listener.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.ContainerAttachWebsocket(context.Background(), "unknown", types.ContainerAttachOptions{})
+	if !IsErrNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "No such container: unknown") {
+		t.Fatalf("expected the message from the server, got %v", err)
+	}
+}
+
+func TestContainerAttachWebsocketInterceptor(t *testing.T) {
+	var seen *APIRequest
+	client := &Client{
+		transport: newMockClient(nil, nil),
+		interceptors: []Interceptor{InterceptorFunc(func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+			seen = req
+			return &APIResponse{StatusCode: http.StatusForbidden}, nil
+		})},
+	}
+	_, err := client.ContainerAttachWebsocket(context.Background(), "container_id", types.ContainerAttachOptions{Stdout: true})
+	if !IsErrForbidden(err) {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+	if seen == nil || !seen.Hijack || seen.Method != "GET" || seen.Path != "/containers/container_id/attach/ws" || seen.Query.Get("stdout") != "1" {
+		t.Fatalf("expected the interceptor to see the websocket request, got %+v", seen)
+	}
+}
@@ -55,12 +55,13 @@ func (cli *Client) CopyToContainer(ctx context.Context, container, path string,
 
 // CopyFromContainer gets the content from the container and returns it as a Reader
 // to manipulate it in the host. It's up to the caller to close the reader.
+// The content is a tar archive, archive.Untar extracts it safely.
 func (cli *Client) CopyFromContainer(ctx context.Context, container, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
 	query := make(url.Values, 1)
 	query.Set("path", filepath.ToSlash(srcPath)) // Normalize the paths used in the API.
 
 	apiPath := fmt.Sprintf("/containers/%s/archive", container)
-	response, err := cli.get(ctx, apiPath, query, nil)
+	response, err := cli.getStream(ctx, apiPath, query, nil)
 	if err != nil {
 		return nil, types.ContainerPathStat{}, err
 	}
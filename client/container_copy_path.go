@@ -0,0 +1,115 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/engine-api/client/archive"
+	"github.com/docker/engine-api/types"
+)
+
+// CopyPathFromContainer copies the file or directory srcPath in the container
+// to the local path dstPath, with the semantics of docker cp:
+//
+// If dstPath is an existing directory, the source is copied inside it,
+// unless srcPath ends with /., then the content of the source directory is
+// copied to it. Otherwise the source is copied as dstPath, which must be in an
+// existing directory. A directory can't replace an existing file, and a file
+// can't be copied to a dstPath that ends with a separator and doesn't exist.
+//
+// Symbolic links in dstPath are followed. Symbolic links in srcPath are copied
+// as links, unless srcPath ends with a separator or with /..
+func (cli *Client) CopyPathFromContainer(ctx context.Context, containerID, srcPath, dstPath string) error {
+	content, stat, err := cli.CopyFromContainer(ctx, containerID, srcPath)
+	if err != nil {
+		if IsErrNotFound(err) {
+			return containerPathNotFoundError{containerID, srcPath}
+		}
+		return err
+	}
+	defer content.Close()
+
+	srcInfo := archive.CopyInfo{
+		Path:   srcPath,
+		Exists: true,
+		IsDir:  stat.Mode.IsDir(),
+	}
+	return archive.CopyTo(content, srcInfo, dstPath)
+}
+
+// CopyPathToContainer copies the local file or directory srcPath to dstPath
+// in the container, with the same semantics as CopyPathFromContainer.
+func (cli *Client) CopyPathToContainer(ctx context.Context, containerID, srcPath, dstPath string) error {
+	dstInfo, err := cli.containerCopyInfoDestinationPath(ctx, containerID, dstPath)
+	if err != nil {
+		return err
+	}
+
+	srcInfo, err := archive.CopyInfoSourcePath(srcPath, false)
+	if err != nil {
+		return err
+	}
+	srcArchive, err := archive.TarResource(srcInfo)
+	if err != nil {
+		return err
+	}
+	defer srcArchive.Close()
+
+	dstDir, preparedArchive, err := archive.PrepareArchiveCopy(srcArchive, srcInfo, dstInfo)
+	if err != nil {
+		return err
+	}
+	defer preparedArchive.Close()
+
+	err = cli.CopyToContainer(ctx, containerID, dstDir, preparedArchive, types.CopyToContainerOptions{})
+	if IsErrNotFound(err) {
+		return containerPathNotFoundError{containerID, dstDir}
+	}
+	return err
+}
+
+// containerCopyInfoDestinationPath returns the information about a destination of
+// a copy in the container, like archive.CopyInfoDestinationPath does for local paths.
+func (cli *Client) containerCopyInfoDestinationPath(ctx context.Context, containerID, dstPath string) (archive.CopyInfo, error) {
+	dstInfo := archive.CopyInfo{Path: dstPath}
+
+	stat, err := cli.ContainerStatPath(ctx, containerID, dstPath)
+	if err == nil && stat.Mode&os.ModeSymlink != 0 {
+		linkTarget := stat.LinkTarget
+		if !path.IsAbs(filepath.ToSlash(linkTarget)) {
+			dstParent, _ := archive.SplitPathDirEntry(dstPath)
+			linkTarget = filepath.Join(dstParent, linkTarget)
+		}
+		dstInfo.Path = linkTarget
+		stat, err = cli.ContainerStatPath(ctx, containerID, linkTarget)
+	}
+
+	switch {
+	case err == nil:
+		if !stat.Mode.IsDir() && !stat.Mode.IsRegular() {
+			return archive.CopyInfo{}, fmt.Errorf("destination %s:%s must be a directory or a regular file", containerID, dstPath)
+		}
+		dstInfo.Exists, dstInfo.IsDir = true, stat.Mode.IsDir()
+		return dstInfo, nil
+	case !IsErrNotFound(err):
+		return archive.CopyInfo{}, err
+	}
+
+	// The destination doesn't exist, the copy creates it in its parent directory.
+	dstParent, _ := archive.SplitPathDirEntry(dstInfo.Path)
+	parentStat, err := cli.ContainerStatPath(ctx, containerID, dstParent)
+	if err != nil {
+		if IsErrNotFound(err) {
+			return archive.CopyInfo{}, containerPathNotFoundError{containerID, filepath.ToSlash(dstParent)}
+		}
+		return archive.CopyInfo{}, err
+	}
+	if !parentStat.Mode.IsDir() {
+		return archive.CopyInfo{}, archive.ErrNotDirectory
+	}
+	return dstInfo, nil
+}
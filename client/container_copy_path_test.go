@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/engine-api/client/archive"
+	"github.com/docker/engine-api/types"
+)
+
+// containerFilesystemMock serves the archive endpoints of the container
+// container_id from the local directory root.
+func containerFilesystemMock(root string) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/containers/container_id/archive" {
+			return errorMock(http.StatusNotFound, "No such container")(req)
+		}
+		p := req.URL.Query().Get("path")
+		localPath := root + string(filepath.Separator) + filepath.FromSlash(p)
+
+		if req.Method == "PUT" {
+			if fi, err := os.Stat(localPath); err != nil || !fi.IsDir() {
+				return errorMock(http.StatusNotFound, "Could not find the file")(req)
+			}
+			if err := archive.Untar(req.Body, localPath, nil); err != nil {
+				return errorMock(http.StatusInternalServerError, err.Error())(req)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+		}
+
+		fi, err := os.Lstat(localPath)
+		if err != nil {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+		}
+		stat := types.ContainerPathStat{Name: fi.Name(), Size: fi.Size(), Mode: fi.Mode()}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			stat.LinkTarget, _ = os.Readlink(localPath)
+		}
+		content, err := json.Marshal(stat)
+		if err != nil {
+			return nil, err
+		}
+		header := http.Header{
+			"X-Docker-Container-Path-Stat": []string{base64.StdEncoding.EncodeToString(content)},
+		}
+
+		body := ioutil.NopCloser(bytes.NewReader(nil))
+		if req.Method == "GET" {
+			if body, err = archive.TarResource(archive.CopyInfo{Path: localPath}); err != nil {
+				return nil, err
+			}
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: body}, nil
+	}
+}
+
+func createCopyPathTree(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "copy-path-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if content == "" {
+			if err := os.Mkdir(p, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func assertFileContent(t *testing.T, p, expected string) {
+	content, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expected {
+		t.Fatalf("expected %q in %s, got %q", expected, p, content)
+	}
+}
+
+func TestCopyPathFromContainer(t *testing.T) {
+	container := createCopyPathTree(t, map[string]string{
+		"etc/hosts":      "hosts",
+		"app/main.go":    "main",
+		"app/lib/lib.go": "lib",
+	})
+	defer os.RemoveAll(container)
+	local := createCopyPathTree(t, map[string]string{"dst/": ""})
+	defer os.RemoveAll(local)
+
+	client := &Client{
+		transport: newMockClient(nil, containerFilesystemMock(container)),
+	}
+	ctx := context.Background()
+
+	if err := client.CopyPathFromContainer(ctx, "container_id", "/etc/hosts", filepath.Join(local, "dst")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(local, "dst", "hosts"), "hosts")
+
+	if err := client.CopyPathFromContainer(ctx, "container_id", "/app/.", filepath.Join(local, "src")); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(local, "src", "main.go"), "main")
+	assertFileContent(t, filepath.Join(local, "src", "lib", "lib.go"), "lib")
+}
+
+func TestCopyPathFromContainerErrors(t *testing.T) {
+	container := createCopyPathTree(t, map[string]string{
+		"app/main.go": "main",
+	})
+	defer os.RemoveAll(container)
+	local := createCopyPathTree(t, map[string]string{"file": "file"})
+	defer os.RemoveAll(local)
+
+	client := &Client{
+		transport: newMockClient(nil, containerFilesystemMock(container)),
+	}
+	ctx := context.Background()
+
+	err := client.CopyPathFromContainer(ctx, "container_id", "/missing", local)
+	if !IsErrContainerPathNotFound(err) || !IsErrNotFound(err) {
+		t.Fatalf("expected a container path not found error, got %v", err)
+	}
+	if err.Error() != "Error: No such container:path: container_id:/missing" {
+		t.Fatalf("unexpected error message %q", err.Error())
+	}
+
+	err = client.CopyPathFromContainer(ctx, "container_id", "/app/main.go", filepath.Join(local, "file", "main.go"))
+	if err != archive.ErrNotDirectory {
+		t.Fatalf("expected a not a directory error, got %v", err)
+	}
+
+	err = client.CopyPathFromContainer(ctx, "container_id", "/app", filepath.Join(local, "file"))
+	if err != archive.ErrCannotCopyDir {
+		t.Fatalf("expected a cannot copy directory error, got %v", err)
+	}
+}
+
+func TestCopyPathToContainer(t *testing.T) {
+	container := createCopyPathTree(t, map[string]string{
+		"etc/":  "",
+		"tmp/":  "",
+		"file":  "file",
+		"data/": "",
+	})
+	defer os.RemoveAll(container)
+	local := createCopyPathTree(t, map[string]string{
+		"config":       "config",
+		"app/main.go":  "main",
+		"app/lib/a.go": "a",
+	})
+	defer os.RemoveAll(local)
+
+	client := &Client{
+		transport: newMockClient(nil, containerFilesystemMock(container)),
+	}
+	ctx := context.Background()
+
+	if err := client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "config"), "/etc"); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(container, "etc", "config"), "config")
+
+	if err := client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "config"), "/etc/renamed"); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(container, "etc", "renamed"), "config")
+
+	if err := client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "app"), "/tmp/app"); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(container, "tmp", "app", "lib", "a.go"), "a")
+
+	if runtime.GOOS != "windows" {
+		// The link in the container is followed.
+		if err := os.Symlink("/data", filepath.Join(container, "link")); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "config"), "/link"); err != nil {
+			t.Fatal(err)
+		}
+		assertFileContent(t, filepath.Join(container, "data", "config"), "config")
+	}
+}
+
+func TestCopyPathToContainerErrors(t *testing.T) {
+	container := createCopyPathTree(t, map[string]string{
+		"file": "file",
+		"etc/": "",
+	})
+	defer os.RemoveAll(container)
+	local := createCopyPathTree(t, map[string]string{
+		"config": "config",
+		"app/a":  "a",
+	})
+	defer os.RemoveAll(local)
+
+	client := &Client{
+		transport: newMockClient(nil, containerFilesystemMock(container)),
+	}
+	ctx := context.Background()
+
+	err := client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "config"), "/file/config")
+	if err != archive.ErrNotDirectory {
+		t.Fatalf("expected a not a directory error, got %v", err)
+	}
+
+	err = client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "config"), "/missing/config")
+	if !IsErrContainerPathNotFound(err) || !strings.Contains(err.Error(), "container_id:/missing") {
+		t.Fatalf("expected a container path not found error, got %v", err)
+	}
+
+	err = client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "app"), "/file")
+	if err != archive.ErrCannotCopyDir {
+		t.Fatalf("expected a cannot copy directory error, got %v", err)
+	}
+
+	err = client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "config"), "/etc/new/")
+	if err != archive.ErrDirNotExists {
+		t.Fatalf("expected a no such directory error, got %v", err)
+	}
+
+	err = client.CopyPathToContainer(ctx, "container_id", filepath.Join(local, "missing"), "/etc")
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+
+	err = client.CopyPathToContainer(ctx, "other_container", filepath.Join(local, "config"), "/etc")
+	if !IsErrContainerPathNotFound(err) {
+		t.Fatalf("expected a container path not found error, got %v", err)
+	}
+}
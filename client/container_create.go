@@ -19,6 +19,10 @@ type configWrapper struct {
 
 // ContainerCreate creates a new container based in the given configuration.
 // It can be associated with a name, but it's not mandatory.
+// The request is encoded with MarshalContainerCreate for the API version
+// of the client: it returns an ErrUnsupportedByVersion if the configuration
+// sets options that the version doesn't support, and the networking config
+// dropped for older versions is reported in the warnings.
 func (cli *Client) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, containerName string) (types.ContainerCreateResponse, error) {
 	var response types.ContainerCreateResponse
 	query := url.Values{}
@@ -26,13 +30,16 @@ func (cli *Client) ContainerCreate(ctx context.Context, config *container.Config
 		query.Set("name", containerName)
 	}
 
-	body := configWrapper{
-		Config:           config,
-		HostConfig:       hostConfig,
-		NetworkingConfig: networkingConfig,
+	// MarshalContainerCreate rejects the options that the version doesn't support.
+	if err := cli.checkAPIVersion(ctx); err != nil {
+		return response, err
+	}
+	body, warnings, err := MarshalContainerCreate(cli.ClientVersion(), config, hostConfig, networkingConfig)
+	if err != nil {
+		return response, err
 	}
 
-	serverResp, err := cli.post(ctx, "/containers/create", query, body, nil)
+	serverResp, err := cli.post(ctx, "/containers/create", query, json.RawMessage(body), nil)
 	if err != nil {
 		if serverResp != nil && serverResp.statusCode == 404 && strings.Contains(err.Error(), "No such image") {
 			return response, imageNotFoundError{config.Image}
@@ -42,5 +49,14 @@ func (cli *Client) ContainerCreate(ctx context.Context, config *container.Config
 
 	err = json.NewDecoder(serverResp.body).Decode(&response)
 	ensureReaderClosed(serverResp)
+	response.Warnings = append(warnings, response.Warnings...)
 	return response, err
 }
+
+// containerCreateOptions returns the versioned options of a container create request.
+func containerCreateOptions(config *container.Config, hostConfig *container.HostConfig) []versionedOptions {
+	return []versionedOptions{
+		{"Config", config, []map[string]string{configVersions}},
+		{"HostConfig", hostConfig, []map[string]string{hostConfigVersions, resourcesVersions}},
+	}
+}
@@ -9,9 +9,10 @@ import (
 
 // ContainerExport retrieves the raw contents of a container
 // and returns them as an io.ReadCloser. It's up to the caller
-// to close the stream.
+// to close the stream. The content is a tar archive, archive.Untar
+// extracts it safely.
 func (cli *Client) ContainerExport(ctx context.Context, containerID string) (io.ReadCloser, error) {
-	serverResp, err := cli.get(ctx, "/containers/"+containerID+"/export", url.Values{}, nil)
+	serverResp, err := cli.getStream(ctx, "/containers/"+containerID+"/export", url.Values{}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2,7 +2,6 @@ package client
 
 import (
 	"bytes"
-	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -12,6 +11,8 @@ import (
 )
 
 // ContainerInspect returns the container information.
+// The information returned by daemons older than API 1.21
+// is converted to the current types.
 func (cli *Client) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
 	serverResp, err := cli.get(ctx, "/containers/"+containerID+"/json", nil, nil)
 	if err != nil {
@@ -21,13 +22,13 @@ func (cli *Client) ContainerInspect(ctx context.Context, containerID string) (ty
 		return types.ContainerJSON{}, err
 	}
 
-	var response types.ContainerJSON
-	err = json.NewDecoder(serverResp.body).Decode(&response)
+	response, err := decodeContainerJSON(cli.ClientVersion(), serverResp.body)
 	ensureReaderClosed(serverResp)
 	return response, err
 }
 
 // ContainerInspectWithRaw returns the container information and it's raw representation.
+// The raw representation is not converted for daemons older than API 1.21.
 func (cli *Client) ContainerInspectWithRaw(ctx context.Context, containerID string, getSize bool) (types.ContainerJSON, []byte, error) {
 	query := url.Values{}
 	if getSize {
@@ -47,9 +48,7 @@ func (cli *Client) ContainerInspectWithRaw(ctx context.Context, containerID stri
 		return types.ContainerJSON{}, nil, err
 	}
 
-	var response types.ContainerJSON
-	rdr := bytes.NewReader(body)
-	err = json.NewDecoder(rdr).Decode(&response)
+	response, err := decodeContainerJSON(cli.ClientVersion(), bytes.NewReader(body))
 	return response, body, err
 }
 
@@ -59,7 +58,6 @@ func (cli *Client) containerInspectWithResponse(ctx context.Context, containerID
 		return types.ContainerJSON{}, serverResp, err
 	}
 
-	var response types.ContainerJSON
-	err = json.NewDecoder(serverResp.body).Decode(&response)
+	response, err := decodeContainerJSON(cli.ClientVersion(), serverResp.body)
 	return response, serverResp, err
 }
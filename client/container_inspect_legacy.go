@@ -0,0 +1,195 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/network"
+	"github.com/docker/engine-api/types/versions"
+	"github.com/docker/engine-api/types/versions/v1p19"
+	"github.com/docker/engine-api/types/versions/v1p20"
+	"github.com/docker/go-connections/nat"
+)
+
+// legacyDefaultNetwork is the network that containers created with the
+// default network mode are connected to in daemons older than API 1.21.
+const legacyDefaultNetwork = "bridge"
+
+// decodeContainerJSON decodes the container information returned by the daemon.
+// Daemons older than API 1.21 return it with a different shape, it's decoded
+// with the types in types/versions and converted to the current types.
+func decodeContainerJSON(version string, r io.Reader) (types.ContainerJSON, error) {
+	version = strings.TrimPrefix(version, "v")
+	switch {
+	case version == "" || versions.GreaterThanOrEqualTo(version, "1.21"):
+		var response types.ContainerJSON
+		err := json.NewDecoder(r).Decode(&response)
+		return response, err
+	case versions.LessThan(version, "1.20"):
+		var response v1p19.ContainerJSON
+		if err := json.NewDecoder(r).Decode(&response); err != nil {
+			return types.ContainerJSON{}, err
+		}
+		return convertV1p19ContainerJSON(response), nil
+	default:
+		var response v1p20.ContainerJSON
+		if err := json.NewDecoder(r).Decode(&response); err != nil {
+			return types.ContainerJSON{}, err
+		}
+		return convertV1p20ContainerJSON(response), nil
+	}
+}
+
+// convertV1p20ContainerJSON converts the container information returned by
+// the API 1.20. The volume driver moved from the config to the host config,
+// and the network settings of the only network moved to Networks.
+func convertV1p20ContainerJSON(c v1p20.ContainerJSON) types.ContainerJSON {
+	response := types.ContainerJSON{
+		ContainerJSONBase: c.ContainerJSONBase,
+		Mounts:            c.Mounts,
+	}
+	if response.ContainerJSONBase == nil {
+		response.ContainerJSONBase = &types.ContainerJSONBase{}
+	}
+
+	if c.Config != nil {
+		response.Config = convertLegacyConfig(c.Config.Config, c.Config.MacAddress, c.Config.NetworkDisabled, c.Config.ExposedPorts)
+		if c.Config.VolumeDriver != "" {
+			hostConfig := legacyHostConfig(response.ContainerJSONBase)
+			if hostConfig.VolumeDriver == "" {
+				hostConfig.VolumeDriver = c.Config.VolumeDriver
+			}
+		}
+	}
+
+	response.NetworkSettings = convertLegacyNetworkSettings(c.NetworkSettings, response.HostConfig)
+	return response
+}
+
+// convertV1p19ContainerJSON converts the container information returned by
+// APIs older than 1.20. On top of the changes in 1.20, the volumes were
+// reported as maps instead of mount points, and some resources were
+// reported in the config instead of the host config.
+func convertV1p19ContainerJSON(c v1p19.ContainerJSON) types.ContainerJSON {
+	response := types.ContainerJSON{
+		ContainerJSONBase: c.ContainerJSONBase,
+		Mounts:            convertLegacyVolumes(c.Volumes, c.VolumesRW),
+	}
+	if response.ContainerJSONBase == nil {
+		response.ContainerJSONBase = &types.ContainerJSONBase{}
+	}
+
+	if c.Config != nil {
+		response.Config = convertLegacyConfig(c.Config.Config, c.Config.MacAddress, c.Config.NetworkDisabled, c.Config.ExposedPorts)
+
+		hostConfig := legacyHostConfig(response.ContainerJSONBase)
+		if hostConfig.VolumeDriver == "" {
+			hostConfig.VolumeDriver = c.Config.VolumeDriver
+		}
+		if hostConfig.Memory == 0 {
+			hostConfig.Memory = c.Config.Memory
+		}
+		if hostConfig.MemorySwap == 0 {
+			hostConfig.MemorySwap = c.Config.MemorySwap
+		}
+		if hostConfig.CPUShares == 0 {
+			hostConfig.CPUShares = c.Config.CPUShares
+		}
+		if hostConfig.CpusetCpus == "" {
+			hostConfig.CpusetCpus = c.Config.CPUSet
+		}
+	}
+
+	response.NetworkSettings = convertLegacyNetworkSettings(c.NetworkSettings, response.HostConfig)
+	return response
+}
+
+// legacyHostConfig returns the host config of the container,
+// it creates an empty one if the daemon didn't send it.
+func legacyHostConfig(base *types.ContainerJSONBase) *container.HostConfig {
+	if base.HostConfig == nil {
+		base.HostConfig = &container.HostConfig{}
+	}
+	return base.HostConfig
+}
+
+// convertLegacyConfig returns the current config from a legacy one.
+// The fields that the legacy config declares again shadow the ones
+// in the embedded config, so they are copied back into it.
+func convertLegacyConfig(config *container.Config, macAddress string, networkDisabled bool, exposedPorts map[nat.Port]struct{}) *container.Config {
+	var c container.Config
+	if config != nil {
+		c = *config
+	}
+	c.MacAddress = macAddress
+	c.NetworkDisabled = networkDisabled
+	c.ExposedPorts = exposedPorts
+	return &c
+}
+
+// convertLegacyVolumes returns the mount points from the volumes maps returned
+// by APIs older than 1.20, sorted by destination. The maps go from the
+// destination in the container to the source in the host.
+func convertLegacyVolumes(volumes map[string]string, volumesRW map[string]bool) []types.MountPoint {
+	if len(volumes) == 0 {
+		return nil
+	}
+	destinations := make([]string, 0, len(volumes))
+	for destination := range volumes {
+		destinations = append(destinations, destination)
+	}
+	sort.Strings(destinations)
+
+	mounts := make([]types.MountPoint, 0, len(volumes))
+	for _, destination := range destinations {
+		mounts = append(mounts, types.MountPoint{
+			Source:      volumes[destination],
+			Destination: destination,
+			RW:          volumesRW[destination],
+		})
+	}
+	return mounts
+}
+
+// convertLegacyNetworkSettings returns the current network settings from the
+// ones returned by APIs older than 1.21. Those daemons only connect containers
+// to one network, named after the network mode of the container.
+// Containers that share the network stack of another container are not
+// connected to any network.
+func convertLegacyNetworkSettings(settings *v1p20.NetworkSettings, hostConfig *container.HostConfig) *types.NetworkSettings {
+	if settings == nil {
+		return nil
+	}
+	response := &types.NetworkSettings{
+		NetworkSettingsBase:    settings.NetworkSettingsBase,
+		DefaultNetworkSettings: settings.DefaultNetworkSettings,
+		Networks:               make(map[string]*network.EndpointSettings),
+	}
+
+	name := legacyDefaultNetwork
+	if hostConfig != nil {
+		switch mode := string(hostConfig.NetworkMode); {
+		case strings.HasPrefix(mode, "container:"):
+			return response
+		case mode != "" && mode != "default":
+			name = mode
+		}
+	}
+
+	defaults := settings.DefaultNetworkSettings
+	response.Networks[name] = &network.EndpointSettings{
+		EndpointID:          defaults.EndpointID,
+		Gateway:             defaults.Gateway,
+		IPAddress:           defaults.IPAddress,
+		IPPrefixLen:         defaults.IPPrefixLen,
+		IPv6Gateway:         defaults.IPv6Gateway,
+		GlobalIPv6Address:   defaults.GlobalIPv6Address,
+		GlobalIPv6PrefixLen: defaults.GlobalIPv6PrefixLen,
+		MacAddress:          defaults.MacAddress,
+	}
+	return response
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+const v1p19ContainerJSON = `{
+	"Id": "container_id",
+	"Name": "/name",
+	"HostConfig": {"NetworkMode": "default", "Memory": 0},
+	"Config": {
+		"Image": "busybox",
+		"Cmd": ["top"],
+		"MacAddress": "02:42:ac:11:00:02",
+		"ExposedPorts": {"80/tcp": {}},
+		"VolumeDriver": "local",
+		"Memory": 1024,
+		"MemorySwap": 2048,
+		"CpuShares": 512,
+		"Cpuset": "0,1"
+	},
+	"Volumes": {"/data": "/var/lib/docker/vfs/dir/abc", "/config": "/etc/app"},
+	"VolumesRW": {"/data": true, "/config": false},
+	"NetworkSettings": {
+		"Bridge": "docker0",
+		"Ports": {"80/tcp": [{"HostIp": "0.0.0.0", "HostPort": "8080"}]},
+		"EndpointID": "endpoint_id",
+		"Gateway": "172.17.0.1",
+		"IPAddress": "172.17.0.2",
+		"IPPrefixLen": 16,
+		"MacAddress": "02:42:ac:11:00:02"
+	}
+}`
+
+const v1p20ContainerJSON = `{
+	"Id": "container_id",
+	"HostConfig": {"NetworkMode": "host"},
+	"Config": {"Image": "busybox", "VolumeDriver": "flocker", "NetworkDisabled": true},
+	"Mounts": [{"Name": "volume", "Source": "/src", "Destination": "/dst", "Driver": "flocker", "RW": true}],
+	"NetworkSettings": {"Bridge": "", "EndpointID": "endpoint_id"}
+}`
+
+func TestDecodeV1p19ContainerJSON(t *testing.T) {
+	c, err := decodeContainerJSON("1.19", strings.NewReader(v1p19ContainerJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "container_id" || c.Name != "/name" {
+		t.Fatalf("unexpected container base %+v", c.ContainerJSONBase)
+	}
+
+	if c.Config.Image != "busybox" || len(c.Config.Cmd) != 1 {
+		t.Fatalf("unexpected config %+v", c.Config)
+	}
+	if c.Config.MacAddress != "02:42:ac:11:00:02" {
+		t.Fatalf("expected the mac address in the config, got %q", c.Config.MacAddress)
+	}
+	if _, ok := c.Config.ExposedPorts["80/tcp"]; !ok {
+		t.Fatalf("expected the exposed ports in the config, got %v", c.Config.ExposedPorts)
+	}
+
+	hostConfig := c.HostConfig
+	if hostConfig.VolumeDriver != "local" || hostConfig.Memory != 1024 || hostConfig.MemorySwap != 2048 {
+		t.Fatalf("expected the legacy config fields in the host config, got %+v", hostConfig)
+	}
+	if hostConfig.CPUShares != 512 || hostConfig.CpusetCpus != "0,1" {
+		t.Fatalf("expected the cpu fields in the host config, got %+v", hostConfig.Resources)
+	}
+
+	if len(c.Mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got %v", c.Mounts)
+	}
+	if m := c.Mounts[0]; m.Destination != "/config" || m.Source != "/etc/app" || m.RW {
+		t.Fatalf("unexpected mount %+v", m)
+	}
+	if m := c.Mounts[1]; m.Destination != "/data" || m.Source != "/var/lib/docker/vfs/dir/abc" || !m.RW {
+		t.Fatalf("unexpected mount %+v", m)
+	}
+
+	settings := c.NetworkSettings
+	if settings.Bridge != "docker0" || settings.IPAddress != "172.17.0.2" || len(settings.Ports["80/tcp"]) != 1 {
+		t.Fatalf("unexpected network settings %+v", settings)
+	}
+	endpoint, ok := settings.Networks["bridge"]
+	if !ok || len(settings.Networks) != 1 {
+		t.Fatalf("expected the bridge network, got %v", settings.Networks)
+	}
+	if endpoint.EndpointID != "endpoint_id" || endpoint.IPAddress != "172.17.0.2" || endpoint.IPPrefixLen != 16 || endpoint.Gateway != "172.17.0.1" {
+		t.Fatalf("unexpected endpoint settings %+v", endpoint)
+	}
+}
+
+func TestDecodeV1p20ContainerJSON(t *testing.T) {
+	c, err := decodeContainerJSON("1.20", strings.NewReader(v1p20ContainerJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Config.Image != "busybox" || !c.Config.NetworkDisabled {
+		t.Fatalf("unexpected config %+v", c.Config)
+	}
+	if c.HostConfig.VolumeDriver != "flocker" {
+		t.Fatalf("expected the volume driver in the host config, got %q", c.HostConfig.VolumeDriver)
+	}
+	if len(c.Mounts) != 1 || c.Mounts[0].Name != "volume" || c.Mounts[0].Driver != "flocker" {
+		t.Fatalf("unexpected mounts %v", c.Mounts)
+	}
+	if endpoint, ok := c.NetworkSettings.Networks["host"]; !ok || endpoint.EndpointID != "endpoint_id" {
+		t.Fatalf("expected the host network, got %v", c.NetworkSettings.Networks)
+	}
+}
+
+func TestDecodeLegacyContainerJSONSharedNetwork(t *testing.T) {
+	body := `{"Id": "container_id", "HostConfig": {"NetworkMode": "container:other"}, "NetworkSettings": {}}`
+	c, err := decodeContainerJSON("1.20", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.NetworkSettings == nil || len(c.NetworkSettings.Networks) != 0 {
+		t.Fatalf("expected no networks, got %+v", c.NetworkSettings)
+	}
+	if c.Config != nil {
+		t.Fatalf("expected no config, got %+v", c.Config)
+	}
+}
+
+func TestDecodeCurrentContainerJSON(t *testing.T) {
+	body := `{"Id": "container_id", "Config": {"Image": "busybox"}, "NetworkSettings": {"Networks": {"custom": {"IPAddress": "10.0.0.2"}}}}`
+	for _, version := range []string{"", "1.21", "v1.24"} {
+		c, err := decodeContainerJSON(version, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if endpoint, ok := c.NetworkSettings.Networks["custom"]; !ok || endpoint.IPAddress != "10.0.0.2" {
+			t.Fatalf("expected the custom network with version %q, got %v", version, c.NetworkSettings.Networks)
+		}
+	}
+}
+
+func TestContainerInspectLegacyVersion(t *testing.T) {
+	client := &Client{
+		version: "1.19",
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.Path, "/v1.19/") {
+				return nil, fmt.Errorf("expected a versioned request, got %s", req.URL.Path)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(v1p19ContainerJSON))),
+			}, nil
+		}),
+	}
+
+	c, err := client.ContainerInspect(context.Background(), "container_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.NetworkSettings.Networks["bridge"]; !ok {
+		t.Fatalf("expected the bridge network, got %v", c.NetworkSettings.Networks)
+	}
+	if len(c.Mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got %v", c.Mounts)
+	}
+
+	c, raw, err := client.ContainerInspectWithRaw(context.Background(), "container_id", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.HostConfig.Memory != 1024 {
+		t.Fatalf("expected the memory in the host config, got %d", c.HostConfig.Memory)
+	}
+	if string(raw) != v1p19ContainerJSON {
+		t.Fatalf("expected the raw response to be unchanged, got %s", raw)
+	}
+}
@@ -35,7 +35,12 @@ func (cli *Client) ContainerList(ctx context.Context, options types.ContainerLis
 	}
 
 	if options.Filter.Len() > 0 {
-		filterJSON, err := filters.ToParamWithVersion(cli.version, options.Filter)
+		// The filters format depends on the API version,
+		// it must be negotiated before encoding them.
+		if err := cli.checkAPIVersion(ctx); err != nil {
+			return nil, err
+		}
+		filterJSON, err := filters.ToParamWithVersion(cli.ClientVersion(), options.Filter)
 
 		if err != nil {
 			return nil, err
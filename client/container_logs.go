@@ -40,7 +40,7 @@ func (cli *Client) ContainerLogs(ctx context.Context, container string, options
 	}
 	query.Set("tail", options.Tail)
 
-	resp, err := cli.get(ctx, "/containers/"+container+"/logs", query, nil)
+	resp, err := cli.getStream(ctx, "/containers/"+container+"/logs", query, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -43,3 +43,18 @@ func TestContainerStart(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestContainerStartAlreadyRunning(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusNotModified,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}),
+	}
+
+	if err := client.ContainerStart(context.Background(), "container_id"); err != nil {
+		t.Fatalf("expected starting a running container to succeed, got %v", err)
+	}
+}
@@ -9,16 +9,33 @@ import (
 
 // ContainerStats returns near realtime stats for a given container.
 // It's up to the caller to close the io.ReadCloser returned.
+// Use ContainerStatsDecoder to decode the stats with the version of the client,
+// and CalculateStatsMetrics to derive metrics like the CPU and memory usage from them.
 func (cli *Client) ContainerStats(ctx context.Context, containerID string, stream bool) (io.ReadCloser, error) {
 	query := url.Values{}
 	query.Set("stream", "0")
+	get := cli.get
 	if stream {
 		query.Set("stream", "1")
+		get = cli.getStream
 	}
 
-	resp, err := cli.get(ctx, "/containers/"+containerID+"/stats", query, nil)
+	resp, err := get(ctx, "/containers/"+containerID+"/stats", query, nil)
 	if err != nil {
 		return nil, err
 	}
 	return resp.body, err
 }
+
+// ContainerStatsDecoder returns a decoder of the near realtime stats for a given container,
+// in the shape sent by the daemon with the API version negotiated by the client.
+// It's up to the caller to close the decoder.
+func (cli *Client) ContainerStatsDecoder(ctx context.Context, containerID string, stream bool) (*StatsDecoder, error) {
+	body, err := cli.ContainerStats(ctx, containerID, stream)
+	if err != nil {
+		return nil, err
+	}
+	dec := NewStatsDecoderWithVersion(body, cli.ClientVersion())
+	dec.body = body
+	return dec, nil
+}
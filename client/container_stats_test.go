@@ -1,6 +1,10 @@
 package client
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"testing"
 
@@ -16,3 +20,45 @@ func TestContainerStatsError(t *testing.T) {
 		t.Fatalf("expected a Server Error, got %v", err)
 	}
 }
+
+func TestContainerStatsDecoder(t *testing.T) {
+	body := `{"read":"2016-01-01T00:00:00Z","network":{"rx_bytes":10,"tx_bytes":20},"memory_stats":{"usage":100}}
+{"read":"2016-01-01T00:00:01Z","network":{"rx_bytes":30,"tx_bytes":40},"memory_stats":{"usage":200}}
+`
+	client := &Client{
+		negotiateVersion: true,
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/version" {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"ApiVersion":"1.20"}`))),
+				}, nil
+			}
+			if req.URL.Path != "/v1.20/containers/container_id/stats" {
+				return nil, fmt.Errorf("expected URL '/v1.20/containers/container_id/stats', got '%s'", req.URL.Path)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		}),
+	}
+
+	dec, err := client.ContainerStatsDecoder(context.Background(), "container_id", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+	for _, expected := range []uint64{10, 30} {
+		stats, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.Networks) != 1 || stats.Networks["eth0"].RxBytes != expected {
+			t.Fatalf("expected eth0 network with %d bytes received, got %v", expected, stats.Networks)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("expected the end of the stream, got %v", err)
+	}
+}
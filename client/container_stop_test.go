@@ -39,3 +39,18 @@ func TestContainerStop(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestContainerStopAlreadyStopped(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusNotModified,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(""))),
+			}, nil
+		}),
+	}
+
+	if err := client.ContainerStop(context.Background(), "container_id", 10); err != nil {
+		t.Fatalf("expected stopping a stopped container to succeed, got %v", err)
+	}
+}
@@ -5,8 +5,16 @@ import (
 	"golang.org/x/net/context"
 )
 
-// ContainerUpdate updates resources of a container
+// ContainerUpdate updates resources of a container.
+// It returns an ErrUnsupportedByVersion if the configuration sets options
+// that the API version of the client doesn't support.
 func (cli *Client) ContainerUpdate(ctx context.Context, containerID string, updateConfig container.UpdateConfig) error {
+	if err := cli.checkOptionsVersion(ctx,
+		versionedOptions{"UpdateConfig", updateConfig, []map[string]string{updateConfigVersions, resourcesVersions}},
+	); err != nil {
+		return err
+	}
+
 	resp, err := cli.post(ctx, "/containers/"+containerID+"/update", nil, updateConfig, nil)
 	ensureReaderClosed(resp)
 	return err
@@ -1,13 +1,136 @@
 package client
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 )
 
 // ErrConnectionFailed is an error raised when the connection between the client and the server failed.
 var ErrConnectionFailed = errors.New("Cannot connect to the Docker daemon. Is the docker daemon running on this host?")
 
+// connectionError implements an error returned when the request
+// could not be sent to the server.
+type connectionError struct {
+	cause error
+}
+
+// Error returns a string representation of a connectionError
+func (e connectionError) Error() string {
+	return fmt.Sprintf("An error occurred trying to connect: %v", e.cause)
+}
+
+// IsErrConnectionFailed returns true if the error is caused
+// when the connection between the client and the server failed.
+func IsErrConnectionFailed(err error) bool {
+	if err == ErrConnectionFailed {
+		return true
+	}
+	_, ok := err.(connectionError)
+	return ok
+}
+
+// ServerError holds the information about an unsuccessful
+// response returned by the docker daemon.
+type ServerError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Method is the HTTP method of the request.
+	Method string
+	// Path is the API path of the request, without the version prefix.
+	Path string
+	// Message is the error message returned by the daemon.
+	Message string
+	// APIVersion is the API version the client used to send the request.
+	// It's empty when the request was not versioned.
+	APIVersion string
+
+	// requestURL holds the full url of the request for error reporting.
+	requestURL string
+}
+
+// newServerError builds a ServerError from the body of a response.
+// The daemon sends errors as JSON documents with a `message` field,
+// older versions send them as plain text.
+func newServerError(statusCode int, method, path, version, requestURL string, body []byte) ServerError {
+	var errorResponse struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &errorResponse); err == nil && errorResponse.Message != "" {
+		message = errorResponse.Message
+	}
+
+	return ServerError{
+		StatusCode: statusCode,
+		Method:     method,
+		Path:       path,
+		Message:    message,
+		APIVersion: version,
+		requestURL: requestURL,
+	}
+}
+
+// Error returns a string representation of a ServerError
+func (e ServerError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(e.StatusCode), e.requestURL)
+	}
+	return fmt.Sprintf("Error response from daemon: %s", e.Message)
+}
+
+// serverErrorStatus returns the status code of an error returned by the daemon.
+// It returns 0 if the error was not returned by the daemon.
+func serverErrorStatus(err error) int {
+	switch e := err.(type) {
+	case ServerError:
+		return e.StatusCode
+	case *ServerError:
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsErrNotFound returns true if the error is caused
+// when the object requested is not found in the docker host.
+func IsErrNotFound(err error) bool {
+	switch err.(type) {
+	case imageNotFoundError, containerNotFoundError, containerPathNotFoundError, networkNotFoundError, volumeNotFoundError:
+		return true
+	}
+	return serverErrorStatus(err) == http.StatusNotFound
+}
+
+// IsErrConflict returns true if the error is caused
+// when the request conflicts with the state of the docker host,
+// like removing a running container.
+func IsErrConflict(err error) bool {
+	return serverErrorStatus(err) == http.StatusConflict
+}
+
+// IsErrForbidden returns true if the error is caused
+// when the docker host refuses to execute the request.
+func IsErrForbidden(err error) bool {
+	return serverErrorStatus(err) == http.StatusForbidden
+}
+
+// IsErrNotModified returns true if the error is caused
+// when the request doesn't modify the state of the docker host.
+// The methods of the client treat not modified responses as
+// successful, like starting a container that is already running,
+// so only errors built by interceptors from those responses match.
+func IsErrNotModified(err error) bool {
+	return serverErrorStatus(err) == http.StatusNotModified
+}
+
+// IsErrUnavailable returns true if the error is caused
+// when the docker host is temporarily unable to handle the request.
+func IsErrUnavailable(err error) bool {
+	return serverErrorStatus(err) == http.StatusServiceUnavailable
+}
+
 // imageNotFoundError implements an error returned when an image is not in the docker host.
 type imageNotFoundError struct {
 	imageID string
@@ -42,6 +165,24 @@ func IsErrContainerNotFound(err error) bool {
 	return ok
 }
 
+// containerPathNotFoundError implements an error returned when a path is not in a container.
+type containerPathNotFoundError struct {
+	containerID string
+	path        string
+}
+
+// Error returns a string representation of a containerPathNotFoundError
+func (e containerPathNotFoundError) Error() string {
+	return fmt.Sprintf("Error: No such container:path: %s:%s", e.containerID, e.path)
+}
+
+// IsErrContainerPathNotFound returns true if the error is caused
+// when a path, or the container, is not found in the docker host.
+func IsErrContainerPathNotFound(err error) bool {
+	_, ok := err.(containerPathNotFoundError)
+	return ok
+}
+
 // networkNotFoundError implements an error returned when a network is not in the docker host.
 type networkNotFoundError struct {
 	networkID string
@@ -89,6 +230,31 @@ func (u unauthorizedError) Error() string {
 // IsErrUnauthorized returns true if the error is caused
 // when a remote registry authentication fails
 func IsErrUnauthorized(err error) bool {
-	_, ok := err.(unauthorizedError)
+	if _, ok := err.(unauthorizedError); ok {
+		return true
+	}
+	return serverErrorStatus(err) == http.StatusUnauthorized
+}
+
+// ErrUnsupportedByVersion is returned when a request sets an option
+// that the API version used by the client doesn't support.
+type ErrUnsupportedByVersion struct {
+	// Field is the name of the option, like `HostConfig.AutoRemove`.
+	Field string
+	// MinVersion is the first API version that supports the option.
+	MinVersion string
+	// Version is the API version used by the client.
+	Version string
+}
+
+// Error returns a string representation of an ErrUnsupportedByVersion
+func (e ErrUnsupportedByVersion) Error() string {
+	return fmt.Sprintf("%s requires API version %s, but the Docker daemon API version is %s", e.Field, e.MinVersion, e.Version)
+}
+
+// IsErrUnsupportedByVersion returns true if the error is caused
+// when a request sets an option that the API version doesn't support.
+func IsErrUnsupportedByVersion(err error) bool {
+	_, ok := err.(ErrUnsupportedByVersion)
 	return ok
 }
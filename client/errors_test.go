@@ -0,0 +1,118 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+func TestServerErrorMessage(t *testing.T) {
+	cases := []struct {
+		body     string
+		expected string
+	}{
+		{"Server error", "Error response from daemon: Server error"},
+		{"Server error\n", "Error response from daemon: Server error"},
+		{`{"message":"No such container: nothing"}`, "Error response from daemon: No such container: nothing"},
+		{`{"other":"field"}`, `Error response from daemon: {"other":"field"}`},
+		{"", "Error: request returned Internal Server Error for API route and version http:///v1.22/containers/nothing/json, check if the server supports the requested API version"},
+	}
+
+	for _, cs := range cases {
+		client := &Client{
+			transport: newMockClient(nil, errorMock(http.StatusInternalServerError, cs.body)),
+			version:   "1.22",
+		}
+		_, err := client.ContainerInspect(context.Background(), "nothing")
+		if err == nil || err.Error() != cs.expected {
+			t.Fatalf("expected %q, got %v", cs.expected, err)
+		}
+	}
+}
+
+func TestServerErrorFields(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, errorMock(http.StatusConflict, `{"message":"container is running"}`)),
+		version:   "1.22",
+	}
+	err := client.ContainerRemove(context.Background(), "container_id", types.ContainerRemoveOptions{})
+	serverErr, ok := err.(ServerError)
+	if !ok {
+		t.Fatalf("expected a ServerError, got %T: %v", err, err)
+	}
+	if serverErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d, got %d", http.StatusConflict, serverErr.StatusCode)
+	}
+	if serverErr.Method != "DELETE" {
+		t.Fatalf("expected method DELETE, got %s", serverErr.Method)
+	}
+	if serverErr.Path != "/containers/container_id" {
+		t.Fatalf("expected path /containers/container_id, got %s", serverErr.Path)
+	}
+	if serverErr.Message != "container is running" {
+		t.Fatalf("expected message `container is running`, got %s", serverErr.Message)
+	}
+	if serverErr.APIVersion != "1.22" {
+		t.Fatalf("expected API version 1.22, got %s", serverErr.APIVersion)
+	}
+}
+
+func TestServerErrorPredicates(t *testing.T) {
+	predicates := map[string]func(error) bool{
+		"IsErrNotFound":     IsErrNotFound,
+		"IsErrConflict":     IsErrConflict,
+		"IsErrForbidden":    IsErrForbidden,
+		"IsErrNotModified":  IsErrNotModified,
+		"IsErrUnavailable":  IsErrUnavailable,
+		"IsErrUnauthorized": IsErrUnauthorized,
+	}
+	cases := []struct {
+		statusCode int
+		predicate  string
+	}{
+		{http.StatusNotFound, "IsErrNotFound"},
+		{http.StatusConflict, "IsErrConflict"},
+		{http.StatusForbidden, "IsErrForbidden"},
+		{http.StatusServiceUnavailable, "IsErrUnavailable"},
+		{http.StatusUnauthorized, "IsErrUnauthorized"},
+		{http.StatusInternalServerError, ""},
+	}
+
+	for _, cs := range cases {
+		client := &Client{
+			transport: newMockClient(nil, errorMock(cs.statusCode, "")),
+		}
+		err := client.ContainerStart(context.Background(), "container_id")
+		if err == nil {
+			t.Fatalf("expected an error for status code %d, got nil", cs.statusCode)
+		}
+		for name, predicate := range predicates {
+			if predicate(err) != (name == cs.predicate) {
+				t.Fatalf("expected %s to return %v for status code %d", name, name == cs.predicate, cs.statusCode)
+			}
+		}
+	}
+
+	if !IsErrNotModified(ServerError{StatusCode: http.StatusNotModified}) {
+		t.Fatal("expected IsErrNotModified to return true for status code 304")
+	}
+}
+
+func TestIsErrNotFoundTypedErrors(t *testing.T) {
+	errs := []error{
+		imageNotFoundError{"image"},
+		containerNotFoundError{"container"},
+		networkNotFoundError{"network"},
+		volumeNotFoundError{"volume"},
+	}
+	for _, err := range errs {
+		if !IsErrNotFound(err) {
+			t.Fatalf("expected %T to be a not found error", err)
+		}
+	}
+	if IsErrNotFound(ErrConnectionFailed) {
+		t.Fatalf("expected ErrConnectionFailed not to be a not found error")
+	}
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/url"
 	"time"
@@ -8,15 +10,204 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/events"
 	"github.com/docker/engine-api/types/filters"
 	timetypes "github.com/docker/engine-api/types/time"
 )
 
+const (
+	// eventsInitialBackoff is the time to wait before reconnecting
+	// to the events stream the first time after it's lost.
+	eventsInitialBackoff = 100 * time.Millisecond
+	// eventsMaxBackoff is the maximum time to wait between two
+	// attempts to reconnect to the events stream.
+	eventsMaxBackoff = 5 * time.Second
+)
+
 // Events returns a stream of events in the daemon in a ReadCloser.
 // It's up to the caller to close the stream.
 func (cli *Client) Events(ctx context.Context, options types.EventsOptions) (io.ReadCloser, error) {
+	query, err := eventsQuery(options, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	serverResponse, err := cli.getStream(ctx, "/events", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return serverResponse.body, nil
+}
+
+// SubscribeEvents subscribes to the events in the daemon that match the options.
+// It returns a channel with the events and a channel to report the error that
+// ends the subscription. When the connection with the daemon is lost, it reconnects
+// and it resumes the subscription after the last event received, so no events are
+// missed or received twice. Both channels are closed when the subscription ends:
+// when the context is done, when the Until time is reached, or when the daemon
+// rejects the subscription. The error is not reported when the context is done.
+func (cli *Client) SubscribeEvents(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+	messages := make(chan events.Message)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(messages)
+
+		if err := cli.subscribeEvents(ctx, options, messages); err != nil && ctx.Err() == nil {
+			errs <- err
+		}
+	}()
+	return messages, errs
+}
+
+// eventsCursor keeps track of the last events received in a subscription,
+// to resume it without receiving them again.
+type eventsCursor struct {
+	// start is the time the subscription starts from,
+	// it's used to resume it until an event is received.
+	start int64
+	// timeNano is the time of the last event received.
+	timeNano int64
+	// seen holds the events received at timeNano, the daemon sends
+	// them again when the subscription resumes since that time.
+	seen map[string]bool
+}
+
+// skip returns true if the event was already received.
+// It records the event as received otherwise.
+func (c *eventsCursor) skip(m events.Message) bool {
+	t := eventTimeNano(m)
+	key := fmt.Sprintf("%s/%s/%s/%s/%s", m.Type, m.Action, m.Actor.ID, m.Status, m.ID)
+	switch {
+	case t < c.timeNano:
+		return true
+	case t == c.timeNano:
+		if c.seen[key] {
+			return true
+		}
+	default:
+		c.timeNano = t
+		c.seen = make(map[string]bool)
+	}
+	c.seen[key] = true
+	return false
+}
+
+// since returns the timestamp to resume the subscription, the time of the last
+// event received or the start of the subscription if no event was received.
+func (c *eventsCursor) since() string {
+	t := c.timeNano
+	if t == 0 {
+		t = c.start
+	}
+	return fmt.Sprintf("%d.%09d", t/int64(time.Second), t%int64(time.Second))
+}
+
+// subscribeEvents sends the events to the channel until the subscription ends.
+func (cli *Client) subscribeEvents(ctx context.Context, options types.EventsOptions, messages chan<- events.Message) error {
+	// Resolve relative times once, they must not change when the subscription resumes.
+	now := time.Now()
+	query, err := eventsQuery(options, now)
+	if err != nil {
+		return err
+	}
+	var until time.Time
+	if u := query.Get("until"); u != "" {
+		sec, nsec, err := timetypes.ParseTimestamps(u, 0)
+		if err != nil {
+			return err
+		}
+		until = time.Unix(sec, nsec)
+	}
+
+	// Events that happen before the first connection, or while it's
+	// established again before the first event, must not be missed.
+	cursor := &eventsCursor{start: now.UnixNano()}
+	if s := query.Get("since"); s != "" {
+		sec, nsec, err := timetypes.ParseTimestamps(s, 0)
+		if err != nil {
+			return err
+		}
+		cursor.start = time.Unix(sec, nsec).UnixNano()
+	}
+	backoff := eventsInitialBackoff
+	for {
+		query.Set("since", cursor.since())
+
+		resp, err := cli.getStream(ctx, "/events", query, nil)
+		if err == nil {
+			backoff = eventsInitialBackoff
+			err = decodeEvents(ctx, resp.body, cursor, messages)
+			resp.body.Close()
+			if _, isSyntaxErr := err.(*json.SyntaxError); isSyntaxErr {
+				return err
+			}
+		} else if !isEventsServerLost(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil && !until.IsZero() && !time.Now().Before(until) {
+			// The daemon ends the stream when the until time is reached.
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > eventsMaxBackoff {
+			backoff = eventsMaxBackoff
+		}
+	}
+}
+
+// decodeEvents sends the events in the stream to the channel,
+// skipping the ones already received. It returns nil when the stream ends.
+func decodeEvents(ctx context.Context, body io.Reader, cursor *eventsCursor, messages chan<- events.Message) error {
+	dec := json.NewDecoder(body)
+	for {
+		var m events.Message
+		if err := dec.Decode(&m); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if cursor.skip(m) {
+			continue
+		}
+		select {
+		case messages <- m:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// isEventsServerLost returns true if the error means that the client cannot
+// connect to the daemon, or that the daemon cannot serve the events for now.
+func isEventsServerLost(err error) bool {
+	return IsErrConnectionFailed(err) || serverErrorStatus(err) >= 500
+}
+
+// eventTimeNano returns the time of the event in nanoseconds.
+// Old daemons only send the time in seconds.
+func eventTimeNano(m events.Message) int64 {
+	if m.TimeNano != 0 {
+		return m.TimeNano
+	}
+	return m.Time * int64(time.Second)
+}
+
+// eventsQuery returns the query parameters to get the events
+// that match the options. Relative times are relative to ref.
+func eventsQuery(options types.EventsOptions, ref time.Time) (url.Values, error) {
 	query := url.Values{}
-	ref := time.Now()
 
 	if options.Since != "" {
 		ts, err := timetypes.GetTimestamp(options.Since, ref)
@@ -39,10 +230,5 @@ func (cli *Client) Events(ctx context.Context, options types.EventsOptions) (io.
 		}
 		query.Set("filters", filterJSON)
 	}
-
-	serverResponse, err := cli.get(ctx, "/events", query, nil)
-	if err != nil {
-		return nil, err
-	}
-	return serverResponse.body, nil
+	return query, nil
 }
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+func eventJSON(action string, timeNano int64) string {
+	b, _ := json.Marshal(events.Message{
+		Type:     events.ContainerEventType,
+		Action:   action,
+		Actor:    events.Actor{ID: "container_id"},
+		Time:     timeNano / int64(time.Second),
+		TimeNano: timeNano,
+	})
+	return string(b) + "\n"
+}
+
+// eventsMock answers each request to the events endpoint with the next response.
+type eventsMock struct {
+	mu        sync.Mutex
+	responses []func(req *http.Request) (*http.Response, error)
+	queries   []string
+}
+
+func (m *eventsMock) do(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !strings.HasSuffix(req.URL.Path, "/events") {
+		return nil, fmt.Errorf("expected URL '/events', got %s", req.URL.Path)
+	}
+	m.queries = append(m.queries, req.URL.RawQuery)
+	if len(m.responses) == 0 {
+		return nil, fmt.Errorf("unexpected request %s", req.URL)
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp(req)
+}
+
+func eventsResponse(body string) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func collectEvents(t *testing.T, messages <-chan events.Message, errs <-chan error) ([]string, error) {
+	var actions []string
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				return actions, <-errs
+			}
+			actions = append(actions, m.Action)
+		case <-timeout:
+			t.Fatal("timed out waiting for the subscription to end")
+		}
+	}
+}
+
+func TestSubscribeEventsResumes(t *testing.T) {
+	t1 := time.Date(2016, 6, 1, 0, 0, 0, 100, time.UTC).UnixNano()
+	t2 := t1 + 500
+	until := time.Now().Add(-time.Second).Unix()
+
+	mock := &eventsMock{responses: []func(req *http.Request) (*http.Response, error){
+		// The connection is lost in the middle of an event.
+		eventsResponse(eventJSON("create", t1) + eventJSON("start", t2) + `{"Type":"contai`),
+		// The daemon cannot be reached for a while.
+		func(req *http.Request) (*http.Response, error) {
+			return nil, fmt.Errorf("dial tcp: connection refused")
+		},
+		errorMock(http.StatusServiceUnavailable, "Server unavailable"),
+		// The events since the last one received are sent again.
+		eventsResponse(eventJSON("start", t2) + eventJSON("attach", t2) + eventJSON("die", t2+1)),
+	}}
+	client := &Client{
+		transport: newMockClient(nil, mock.do),
+	}
+
+	f := filters.NewArgs()
+	f.Add("type", "container")
+	messages, errs := client.SubscribeEvents(context.Background(), types.EventsOptions{
+		Until:   fmt.Sprintf("%d", until),
+		Filters: f,
+	})
+	actions, err := collectEvents(t, messages, errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(actions, ",") != "create,start,attach,die" {
+		t.Fatalf("expected each event once, got %v", actions)
+	}
+
+	if len(mock.queries) != 4 {
+		t.Fatalf("expected 4 requests, got %v", mock.queries)
+	}
+	for i, q := range mock.queries {
+		if !strings.Contains(q, "filters=") || !strings.Contains(q, fmt.Sprintf("until=%d", until)) {
+			t.Fatalf("expected the filters and until in request %d, got %s", i, q)
+		}
+	}
+	if !strings.Contains(mock.queries[0], "since=") {
+		t.Fatalf("expected the start of the subscription in the first request, got %s", mock.queries[0])
+	}
+	expectedSince := fmt.Sprintf("since=%d.%09d", t2/int64(time.Second), t2%int64(time.Second))
+	if !strings.Contains(mock.queries[3], expectedSince) {
+		t.Fatalf("expected %s when resuming, got %s", expectedSince, mock.queries[3])
+	}
+}
+
+func TestSubscribeEventsResumesBeforeFirstEvent(t *testing.T) {
+	t1 := time.Date(2016, 6, 1, 0, 0, 0, 100, time.UTC).UnixNano()
+	since := time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	mock := &eventsMock{responses: []func(req *http.Request) (*http.Response, error){
+		// The connection is lost before the first event.
+		eventsResponse(`{"Type":"contai`),
+		eventsResponse(eventJSON("create", t1)),
+	}}
+	client := &Client{
+		transport: newMockClient(nil, mock.do),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages, errs := client.SubscribeEvents(ctx, types.EventsOptions{
+		Since: fmt.Sprintf("%d", since),
+	})
+	if m := <-messages; m.Action != "create" {
+		t.Fatalf("expected the create event, got %+v", m)
+	}
+	cancel()
+	if _, err := collectEvents(t, messages, errs); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	if len(mock.queries) != 2 {
+		t.Fatalf("expected 2 requests, got %v", mock.queries)
+	}
+	expectedSince := fmt.Sprintf("since=%d.000000000", since)
+	for i, q := range mock.queries {
+		if !strings.Contains(q, expectedSince) {
+			t.Fatalf("expected %s in request %d, got %s", expectedSince, i, q)
+		}
+	}
+}
+
+func TestSubscribeEventsServerError(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, errorMock(http.StatusBadRequest, `{"message":"invalid filter"}`)),
+	}
+	messages, errs := client.SubscribeEvents(context.Background(), types.EventsOptions{})
+	_, err := collectEvents(t, messages, errs)
+	if err == nil || !strings.Contains(err.Error(), "invalid filter") {
+		t.Fatalf("expected the server error, got %v", err)
+	}
+}
+
+func TestSubscribeEventsContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &Client{
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			// Send an event in every connection until the context is done.
+			return eventsResponse(eventJSON("start", time.Now().UnixNano()))(req)
+		}),
+	}
+	messages, errs := client.SubscribeEvents(ctx, types.EventsOptions{})
+
+	if m := <-messages; m.Action != "start" {
+		t.Fatalf("expected an event, got %+v", m)
+	}
+	cancel()
+	if _, err := collectEvents(t, messages, errs); err != nil {
+		t.Fatalf("expected no error after cancelling the context, got %v", err)
+	}
+}
+
+func TestEventsCursor(t *testing.T) {
+	c := &eventsCursor{}
+	m := events.Message{Type: "container", Action: "start", Actor: events.Actor{ID: "a"}, Time: 10}
+	if c.skip(m) {
+		t.Fatal("expected the first event not to be skipped")
+	}
+	if !c.skip(m) {
+		t.Fatal("expected the same event to be skipped")
+	}
+	m.Actor.ID = "b"
+	if c.skip(m) {
+		t.Fatal("expected another event at the same time not to be skipped")
+	}
+	m.Time = 9
+	if !c.skip(m) {
+		t.Fatal("expected older events to be skipped")
+	}
+	if since := c.since(); since != "10.000000000" {
+		t.Fatalf("expected since 10.000000000, got %s", since)
+	}
+}
@@ -0,0 +1,301 @@
+package fakedaemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/engine-api/types"
+)
+
+// fileEntry is a file, a directory or a symbolic link of a filesystem.
+type fileEntry struct {
+	mode       os.FileMode
+	content    []byte
+	mtime      time.Time
+	linkTarget string
+	// added is true for the files copied to the container.
+	added bool
+}
+
+// filesystem is the in-memory filesystem of a container,
+// indexed by the absolute paths of the files.
+type filesystem map[string]*fileEntry
+
+// newFilesystem returns the filesystem of a new container.
+func newFilesystem() filesystem {
+	fs := make(filesystem)
+	for _, p := range []string{"/", "/etc", "/tmp"} {
+		fs[p] = &fileEntry{mode: os.ModeDir | 0755, mtime: now()}
+	}
+	return fs
+}
+
+// clone returns a copy of the filesystem.
+func (fs filesystem) clone() filesystem {
+	c := make(filesystem, len(fs))
+	for p, e := range fs {
+		copied := *e
+		c[p] = &copied
+	}
+	return c
+}
+
+// size returns the size of the files of the filesystem.
+func (fs filesystem) size() int64 {
+	var size int64
+	for _, e := range fs {
+		size += int64(len(e.content))
+	}
+	return size
+}
+
+// sortedPaths returns the paths of the filesystem in order.
+func (fs filesystem) sortedPaths() []string {
+	paths := make([]string, 0, len(fs))
+	for p := range fs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// changes returns the files added to the filesystem, and the directories
+// modified by adding them.
+func (fs filesystem) changes() []types.ContainerChange {
+	kinds := make(map[string]int)
+	for p, e := range fs {
+		if !e.added {
+			continue
+		}
+		kinds[p] = 1
+		for dir := path.Dir(p); dir != "/"; dir = path.Dir(dir) {
+			if parent, ok := fs[dir]; ok && !parent.added {
+				kinds[dir] = 0
+			}
+		}
+	}
+	changes := []types.ContainerChange{}
+	for _, p := range fs.sortedPaths() {
+		if kind, ok := kinds[p]; ok {
+			changes = append(changes, types.ContainerChange{Kind: kind, Path: p})
+		}
+	}
+	return changes
+}
+
+// stat returns the information about the file at the path.
+func (fs filesystem) stat(p string) (types.ContainerPathStat, bool) {
+	e, ok := fs[path.Clean("/"+p)]
+	if !ok {
+		return types.ContainerPathStat{}, false
+	}
+	return types.ContainerPathStat{
+		Name:       path.Base(path.Clean("/" + p)),
+		Size:       int64(len(e.content)),
+		Mode:       e.mode,
+		Mtime:      e.mtime,
+		LinkTarget: e.linkTarget,
+	}, true
+}
+
+// writeTar writes the file at root, and the files in it if it's a directory,
+// to a tar archive. The names of the files in the archive are relative to
+// root and they start with prefix. The root itself isn't in the archive
+// if prefix is empty.
+func (fs filesystem) writeTar(w io.Writer, root, prefix string) error {
+	tw := tar.NewWriter(w)
+	for _, p := range fs.sortedPaths() {
+		if !(root == "/" || p == root || strings.HasPrefix(p, root+"/")) {
+			continue
+		}
+		name := path.Join(prefix, strings.TrimPrefix(p, root))
+		name = strings.TrimPrefix(name, "/")
+		if name == "" {
+			continue
+		}
+		e := fs[p]
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     int64(e.mode.Perm()),
+			Size:     int64(len(e.content)),
+			ModTime:  e.mtime,
+			Typeflag: tar.TypeReg,
+		}
+		switch {
+		case e.mode.IsDir():
+			hdr.Name += "/"
+			hdr.Typeflag = tar.TypeDir
+			hdr.Size = 0
+		case e.mode&os.ModeSymlink != 0:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = e.linkTarget
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write(e.content); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
+
+// extract extracts the tar archive in the directory dst. With
+// noOverwriteDirNonDir, directories can't replace other files
+// and other files can't replace directories.
+func (fs filesystem) extract(r io.Reader, dst string, noOverwriteDirNonDir bool) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errBadRequest("invalid archive: %v", err)
+		}
+		p := path.Join(dst, path.Clean("/"+hdr.Name))
+		if p == "/" {
+			continue
+		}
+
+		e := &fileEntry{mode: os.FileMode(hdr.Mode).Perm(), mtime: hdr.ModTime, added: true}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			e.mode |= os.ModeDir
+		case tar.TypeSymlink:
+			e.mode |= os.ModeSymlink
+			e.linkTarget = hdr.Linkname
+		case tar.TypeReg, tar.TypeRegA:
+			if e.content, err = ioutil.ReadAll(tr); err != nil {
+				return errBadRequest("invalid archive: %v", err)
+			}
+		default:
+			continue
+		}
+
+		if existing, ok := fs[p]; ok {
+			if noOverwriteDirNonDir && existing.mode.IsDir() && !e.mode.IsDir() {
+				return errBadRequest("cannot overwrite directory %q with non-directory %q", p, hdr.Name)
+			}
+			if noOverwriteDirNonDir && !existing.mode.IsDir() && e.mode.IsDir() {
+				return errBadRequest("cannot overwrite non-directory %q with directory %q", p, hdr.Name)
+			}
+			if existing.mode.IsDir() && e.mode.IsDir() {
+				// Directories are merged.
+				e.added = existing.added
+			}
+		}
+		for dir := path.Dir(p); dir != "/"; dir = path.Dir(dir) {
+			if _, ok := fs[dir]; !ok {
+				fs[dir] = &fileEntry{mode: os.ModeDir | 0755, mtime: now(), added: true}
+			}
+		}
+		fs[p] = e
+	}
+}
+
+// archivePath returns the container and the information about the path
+// requested by the archive endpoints.
+// The caller must hold mu.
+func (d *Daemon) archivePath(name, p string) (*containerRecord, types.ContainerPathStat, error) {
+	c, err := d.lookupContainer(name)
+	if err != nil {
+		return nil, types.ContainerPathStat{}, err
+	}
+	if p == "" {
+		return nil, types.ContainerPathStat{}, errBadRequest("path is required")
+	}
+	stat, ok := c.fs.stat(p)
+	if !ok {
+		return nil, types.ContainerPathStat{}, errNotFound("Could not find the file %s in container %s", p, name)
+	}
+	return c, stat, nil
+}
+
+// setPathStatHeader sets the header with the information about the path.
+func setPathStatHeader(w http.ResponseWriter, stat types.ContainerPathStat) error {
+	b, err := json.Marshal(stat)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(b))
+	return nil
+}
+
+func (d *Daemon) headContainerArchive(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	_, stat, err := d.archivePath(vars[0], r.URL.Query().Get("path"))
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := setPathStatHeader(w, stat); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (d *Daemon) getContainerArchive(w http.ResponseWriter, r *http.Request, vars []string) error {
+	p := r.URL.Query().Get("path")
+	d.mu.Lock()
+	c, stat, err := d.archivePath(vars[0], p)
+	var fs filesystem
+	if err == nil {
+		fs = c.fs.clone()
+		d.logContainerEvent(c, "archive-path", nil)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := setPathStatHeader(w, stat); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	fs.writeTar(w, path.Clean("/"+p), stat.Name)
+	return nil
+}
+
+func (d *Daemon) putContainerArchive(w http.ResponseWriter, r *http.Request, vars []string) error {
+	p := r.URL.Query().Get("path")
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, stat, err := d.archivePath(vars[0], p)
+	if err != nil {
+		return err
+	}
+	if !stat.Mode.IsDir() {
+		return errBadRequest("extraction point is not a directory")
+	}
+	// The archive is extracted in a copy of the filesystem,
+	// so the container is unchanged if it's invalid.
+	fs := c.fs.clone()
+	if err := fs.extract(bytes.NewReader(content), path.Clean("/"+p), boolValue(r, "noOverwriteDirNonDir")); err != nil {
+		return err
+	}
+	c.fs = fs
+	d.logContainerEvent(c, "extract-to-dir", nil)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
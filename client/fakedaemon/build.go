@@ -0,0 +1,253 @@
+package fakedaemon
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/strslice"
+	"github.com/docker/go-connections/nat"
+)
+
+// instruction is an instruction of a Dockerfile.
+type instruction struct {
+	command string
+	args    string
+}
+
+// String returns the instruction as it's printed in the steps of the build.
+func (i instruction) String() string {
+	return strings.ToUpper(i.command) + " " + i.args
+}
+
+// parseDockerfile returns the instructions of the Dockerfile,
+// joining the lines continued with a backslash.
+func parseDockerfile(content string) []instruction {
+	var instructions []instruction
+	var current string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if current == "" && (line == "" || strings.HasPrefix(line, "#")) {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			current += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		current += line
+		fields := strings.SplitN(strings.TrimSpace(current), " ", 2)
+		current = ""
+		i := instruction{command: strings.ToLower(fields[0])}
+		if len(fields) == 2 {
+			i.args = strings.TrimSpace(fields[1])
+		}
+		instructions = append(instructions, i)
+	}
+	return instructions
+}
+
+// parseCommand parses the arguments of CMD and ENTRYPOINT,
+// in the JSON or in the shell form.
+func parseCommand(args string) strslice.StrSlice {
+	var command []string
+	if err := json.Unmarshal([]byte(args), &command); err == nil {
+		return command
+	}
+	return strslice.StrSlice{"/bin/sh", "-c", args}
+}
+
+// parsePairs parses the key=value pairs of ENV and LABEL,
+// or their legacy form "key value".
+func parsePairs(args string) [][2]string {
+	fields := strings.Fields(args)
+	if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+		return [][2]string{{fields[0], strings.TrimSpace(strings.TrimPrefix(args, fields[0]))}}
+	}
+	var pairs [][2]string
+	for _, f := range fields {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) == 2 {
+			pairs = append(pairs, [2]string{strings.Trim(kv[0], `"`), strings.Trim(kv[1], `"`)})
+		}
+	}
+	return pairs
+}
+
+// copyConfig returns a copy of the config that doesn't share its maps
+// and slices with it.
+func copyConfig(config *container.Config) *container.Config {
+	c := *config
+	c.Env = append([]string(nil), config.Env...)
+	c.Labels = make(map[string]string)
+	for k, v := range config.Labels {
+		c.Labels[k] = v
+	}
+	c.ExposedPorts = make(map[nat.Port]struct{})
+	for p := range config.ExposedPorts {
+		c.ExposedPorts[p] = struct{}{}
+	}
+	c.Volumes = make(map[string]struct{})
+	for v := range config.Volumes {
+		c.Volumes[v] = struct{}{}
+	}
+	return &c
+}
+
+// applyInstruction applies the instruction to the config of the image.
+func applyInstruction(config *container.Config, i instruction) {
+	switch i.command {
+	case "cmd":
+		config.Cmd = parseCommand(i.args)
+	case "entrypoint":
+		config.Entrypoint = parseCommand(i.args)
+	case "workdir":
+		config.WorkingDir = path.Join(config.WorkingDir, i.args)
+		if !path.IsAbs(config.WorkingDir) {
+			config.WorkingDir = "/" + config.WorkingDir
+		}
+	case "user":
+		config.User = i.args
+	case "env":
+		for _, kv := range parsePairs(i.args) {
+			config.Env = append(config.Env, kv[0]+"="+kv[1])
+		}
+	case "label":
+		for _, kv := range parsePairs(i.args) {
+			config.Labels[kv[0]] = kv[1]
+		}
+	case "expose":
+		for _, p := range strings.Fields(i.args) {
+			if !strings.Contains(p, "/") {
+				p += "/tcp"
+			}
+			config.ExposedPorts[nat.Port(p)] = struct{}{}
+		}
+	case "volume":
+		var volumes []string
+		if err := json.Unmarshal([]byte(i.args), &volumes); err != nil {
+			volumes = strings.Fields(i.args)
+		}
+		for _, v := range volumes {
+			config.Volumes[v] = struct{}{}
+		}
+	}
+}
+
+// readBuildContext reads the files of the build context.
+func readBuildContext(r io.Reader) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, errBadRequest("invalid build context: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errBadRequest("invalid build context: %v", err)
+		}
+		files[path.Clean(hdr.Name)] = b
+	}
+}
+
+// postBuild builds images from the Dockerfile of the context. The
+// instructions change the configuration of the images, but they don't run
+// anything, so RUN, COPY and ADD only add a layer.
+func (d *Daemon) postBuild(w http.ResponseWriter, r *http.Request, vars []string) error {
+	query := r.URL.Query()
+	if query.Get("remote") != "" {
+		return errBadRequest("remote build contexts are not supported")
+	}
+	files, err := readBuildContext(r.Body)
+	if err != nil {
+		return err
+	}
+	dockerfile := query.Get("dockerfile")
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	content, ok := files[path.Clean(dockerfile)]
+	if !ok {
+		return fmt.Errorf("Cannot locate specified Dockerfile: %s", dockerfile)
+	}
+	instructions := parseDockerfile(string(content))
+	if len(instructions) == 0 {
+		return fmt.Errorf("The Dockerfile (%s) cannot be empty", dockerfile)
+	}
+	labels := make(map[string]string)
+	if l := query.Get("labels"); l != "" {
+		if err := json.Unmarshal([]byte(l), &labels); err != nil {
+			return errBadRequest("invalid labels: %v", err)
+		}
+	}
+	quiet := boolValue(r, "q")
+
+	s := newJSONStream(w)
+	say := func(format string, args ...interface{}) {
+		if !quiet {
+			s.send(types.JSONMessage{Stream: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var img *imageRecord
+	for n, i := range instructions {
+		say("Step %d : %s\n", n+1, i)
+		if n == 0 {
+			if i.command != "from" {
+				s.sendError("Please provide a source image with `from` prior to commit")
+				return nil
+			}
+			if i.args == "scratch" {
+				img = d.addImage("", &container.Config{}, "", "")
+			} else {
+				if img, err = d.lookupImage(i.args); err != nil {
+					s.sendError("Error: image %s not found", repositoryName(normalizeReference(i.args)))
+					return nil
+				}
+			}
+			say(" ---> %s\n", shortID(img.ID))
+			continue
+		}
+		config := copyConfig(img.Config)
+		applyInstruction(config, i)
+		createdBy := "/bin/sh -c #(nop) " + i.String()
+		if i.command == "run" {
+			createdBy = "/bin/sh -c " + i.args
+			say(" ---> Running in %s\n", shortID(generateID()))
+		}
+		img = d.addImage(img.ID, config, "", createdBy)
+		say(" ---> %s\n", shortID(img.ID))
+	}
+
+	if len(labels) > 0 {
+		config := copyConfig(img.Config)
+		for k, v := range labels {
+			config.Labels[k] = v
+		}
+		img = d.addImage(img.ID, config, "", "/bin/sh -c #(nop) LABEL")
+	}
+	for _, t := range query["t"] {
+		d.tagImage(img, normalizeReference(t))
+	}
+	if quiet {
+		s.send(types.JSONMessage{Stream: img.ID + "\n"})
+		return nil
+	}
+	say("Successfully built %s\n", shortID(img.ID))
+	return nil
+}
@@ -0,0 +1,1018 @@
+package fakedaemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"github.com/docker/engine-api/types/network"
+	"github.com/docker/engine-api/types/strslice"
+	"github.com/docker/go-units"
+)
+
+// zeroTime is the time reported by the daemon for the events that didn't happen yet.
+const zeroTime = "0001-01-01T00:00:00Z"
+
+// stopSignals are the signals that make the containers exit, with their numbers.
+var stopSignals = map[string]int{"INT": 2, "QUIT": 3, "KILL": 9, "TERM": 15}
+
+// otherSignals are the signals accepted by kill that don't make the containers exit.
+var otherSignals = map[string]int{"HUP": 1, "USR1": 10, "USR2": 12, "WINCH": 28}
+
+// containerRecord holds the state of a container.
+type containerRecord struct {
+	types.ContainerJSON
+	// seq orders the containers by creation.
+	seq int
+	// fs is the filesystem of the container, used by the archive endpoints.
+	fs filesystem
+	// stopped is closed when the container isn't running.
+	stopped chan struct{}
+	// anonymousVolumes are the volumes created for the container,
+	// removed with it when they are requested to.
+	anonymousVolumes []string
+}
+
+// name returns the name of the container, without the leading slash.
+func (c *containerRecord) name() string {
+	return strings.TrimPrefix(c.Name, "/")
+}
+
+// ExitContainer makes a running container exit with the code, as if its
+// process ended. It returns an error if the container doesn't exist or
+// it's not running.
+func (d *Daemon) ExitContainer(id string, code int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(id)
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		return fmt.Errorf("Container %s is not running", id)
+	}
+	d.exitContainer(c, code)
+	d.autoRemove(c)
+	return nil
+}
+
+// lookupContainer finds a container by ID, name or ID prefix.
+// The caller must hold mu.
+func (d *Daemon) lookupContainer(name string) (*containerRecord, error) {
+	if name == "" {
+		return nil, errNotFound("No such container: %s", name)
+	}
+	if c, ok := d.containers[name]; ok {
+		return c, nil
+	}
+	for _, c := range d.containers {
+		if c.name() == strings.TrimPrefix(name, "/") {
+			return c, nil
+		}
+	}
+	var found *containerRecord
+	for id, c := range d.containers {
+		if strings.HasPrefix(id, name) {
+			if found != nil {
+				return nil, errBadRequest("Multiple IDs found with provided prefix: %s", name)
+			}
+			found = c
+		}
+	}
+	if found == nil {
+		return nil, errNotFound("No such container: %s", name)
+	}
+	return found, nil
+}
+
+// containerAttributes returns the attributes of the events of the container.
+func (d *Daemon) containerAttributes(c *containerRecord) map[string]string {
+	attributes := map[string]string{
+		"image": c.Config.Image,
+		"name":  c.name(),
+	}
+	for k, v := range c.Config.Labels {
+		attributes[k] = v
+	}
+	return attributes
+}
+
+// logContainerEvent records an event of the container with extra attributes.
+// The caller must hold mu.
+func (d *Daemon) logContainerEvent(c *containerRecord, action string, extra map[string]string) {
+	attributes := d.containerAttributes(c)
+	for k, v := range extra {
+		attributes[k] = v
+	}
+	d.logEvent(events.ContainerEventType, action, c.ID, attributes)
+}
+
+// createConfig is the body of the requests to create containers.
+type createConfig struct {
+	*container.Config
+	HostConfig       *container.HostConfig
+	NetworkingConfig *network.NetworkingConfig
+}
+
+func (d *Daemon) postContainersCreate(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var body createConfig
+	if err := decodeBody(r, &body); err != nil {
+		return err
+	}
+	if body.Config == nil || body.Config.Image == "" {
+		return errBadRequest("Config cannot be empty in order to create a container")
+	}
+	hostConfig := body.HostConfig
+	if hostConfig == nil {
+		hostConfig = &container.HostConfig{}
+	}
+	if hostConfig.NetworkMode == "" {
+		hostConfig.NetworkMode = "default"
+	}
+	var endpoints map[string]*network.EndpointSettings
+	if body.NetworkingConfig != nil {
+		endpoints = body.NetworkingConfig.EndpointsConfig
+	}
+	if len(endpoints) > 1 {
+		var names []string
+		for name := range endpoints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return errBadRequest("Container cannot be connected to network endpoints: %s", strings.Join(names, ", "))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	img, err := d.lookupImage(body.Config.Image)
+	if err != nil {
+		return err
+	}
+
+	id := generateID()
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "fake_" + id[:8]
+	}
+	name = strings.TrimPrefix(name, "/")
+	if other, err := d.lookupContainer("/" + name); err == nil && other.name() == name {
+		return errConflict("Conflict. The name \"/%s\" is already in use by container %s. You have to remove (or rename) that container to be able to reuse that name.", name, other.ID)
+	}
+
+	config := mergeImageConfig(*body.Config, img.Config)
+	command := append(strslice.StrSlice{}, config.Entrypoint...)
+	command = append(command, config.Cmd...)
+	if len(command) == 0 {
+		return errBadRequest("No command specified")
+	}
+
+	d.counter++
+	c := &containerRecord{
+		ContainerJSON: types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:      id,
+				Created: now().Format(time.RFC3339Nano),
+				Path:    command[0],
+				Args:    append([]string{}, command[1:]...),
+				State: &types.ContainerState{
+					Status:     "created",
+					StartedAt:  zeroTime,
+					FinishedAt: zeroTime,
+				},
+				Image:       img.ID,
+				Name:        "/" + name,
+				Driver:      "fake",
+				ExecIDs:     nil,
+				HostConfig:  hostConfig,
+				GraphDriver: types.GraphDriverData{Name: "fake"},
+			},
+			Config: &config,
+			NetworkSettings: &types.NetworkSettings{
+				Networks: make(map[string]*network.EndpointSettings),
+			},
+		},
+		seq:     d.counter,
+		fs:      newFilesystem(),
+		stopped: make(chan struct{}),
+	}
+	close(c.stopped)
+
+	if err := d.mountVolumes(c); err != nil {
+		return err
+	}
+
+	mode := string(hostConfig.NetworkMode)
+	if mode == "default" {
+		mode = "bridge"
+	}
+	if !strings.HasPrefix(mode, "container:") {
+		n, err := d.lookupNetwork(mode)
+		if err != nil {
+			return errNotFound("network %s not found", mode)
+		}
+		d.connectContainer(n, c, endpoints[mode])
+	}
+
+	d.containers[id] = c
+	d.logContainerEvent(c, "create", nil)
+	return writeJSON(w, http.StatusCreated, types.ContainerCreateResponse{ID: id, Warnings: []string{}})
+}
+
+// mergeImageConfig completes the configuration of a container with
+// the configuration of its image.
+func mergeImageConfig(config container.Config, image *container.Config) container.Config {
+	if image == nil {
+		return config
+	}
+	if len(config.Entrypoint) == 0 {
+		config.Entrypoint = image.Entrypoint
+		if len(config.Cmd) == 0 {
+			config.Cmd = image.Cmd
+		}
+	}
+	if config.WorkingDir == "" {
+		config.WorkingDir = image.WorkingDir
+	}
+	if config.User == "" {
+		config.User = image.User
+	}
+	config.Env = append(append([]string{}, image.Env...), config.Env...)
+	if len(image.Labels) > 0 {
+		labels := make(map[string]string)
+		for k, v := range image.Labels {
+			labels[k] = v
+		}
+		for k, v := range config.Labels {
+			labels[k] = v
+		}
+		config.Labels = labels
+	}
+	if len(image.ExposedPorts) > 0 && config.ExposedPorts == nil {
+		config.ExposedPorts = image.ExposedPorts
+	}
+	return config
+}
+
+// mountVolumes mounts the binds and the volumes of the container,
+// creating the volumes that don't exist. The caller must hold mu.
+func (d *Daemon) mountVolumes(c *containerRecord) error {
+	driver := c.HostConfig.VolumeDriver
+	if driver == "" {
+		driver = "local"
+	}
+	mounted := make(map[string]bool)
+	for _, bind := range c.HostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return errBadRequest("Invalid volume specification: '%s'", bind)
+		}
+		mode := "rw"
+		if len(parts) == 3 {
+			mode = parts[2]
+		}
+		m := types.MountPoint{
+			Source:      parts[0],
+			Destination: parts[1],
+			Mode:        mode,
+			RW:          !strings.Contains(mode, "ro"),
+			Propagation: "rprivate",
+		}
+		if !path.IsAbs(parts[0]) {
+			v := d.createVolume(parts[0], driver, nil)
+			m.Name = v.Name
+			m.Source = v.Mountpoint
+			m.Driver = v.Driver
+			m.Propagation = ""
+		}
+		c.Mounts = append(c.Mounts, m)
+		mounted[m.Destination] = true
+	}
+	var destinations []string
+	for dst := range c.Config.Volumes {
+		if !mounted[dst] {
+			destinations = append(destinations, dst)
+		}
+	}
+	sort.Strings(destinations)
+	for _, dst := range destinations {
+		v := d.createVolume(generateID(), driver, nil)
+		c.anonymousVolumes = append(c.anonymousVolumes, v.Name)
+		c.Mounts = append(c.Mounts, types.MountPoint{
+			Name:        v.Name,
+			Source:      v.Mountpoint,
+			Destination: dst,
+			Driver:      v.Driver,
+			RW:          true,
+		})
+	}
+	sort.Sort(mountsByDestination(c.Mounts))
+	return nil
+}
+
+// mountsByDestination sorts mount points by destination.
+type mountsByDestination []types.MountPoint
+
+func (s mountsByDestination) Len() int           { return len(s) }
+func (s mountsByDestination) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s mountsByDestination) Less(i, j int) bool { return s[i].Destination < s[j].Destination }
+
+// startContainer makes the container run. The caller must hold mu.
+func (d *Daemon) startContainer(c *containerRecord) {
+	d.counter++
+	c.State.Running = true
+	c.State.Paused = false
+	c.State.Status = "running"
+	c.State.Pid = 1000 + d.counter
+	c.State.ExitCode = 0
+	c.State.StartedAt = now().Format(time.RFC3339Nano)
+	c.stopped = make(chan struct{})
+	d.logContainerEvent(c, "start", nil)
+}
+
+// exitContainer makes the container exit with the code.
+// The caller must hold mu.
+func (d *Daemon) exitContainer(c *containerRecord, code int) {
+	c.State.Running = false
+	c.State.Paused = false
+	c.State.Status = "exited"
+	c.State.Pid = 0
+	c.State.ExitCode = code
+	c.State.FinishedAt = now().Format(time.RFC3339Nano)
+	close(c.stopped)
+	d.logContainerEvent(c, "die", map[string]string{"exitCode": strconv.Itoa(code)})
+}
+
+// autoRemove removes the container if it exited and it was created with
+// AutoRemove. The caller must hold mu.
+func (d *Daemon) autoRemove(c *containerRecord) {
+	if !c.State.Running && c.HostConfig.AutoRemove {
+		d.removeContainer(c, true)
+	}
+}
+
+// removeContainer removes the container, and its anonymous volumes if
+// removeVolumes is true. The caller must hold mu.
+func (d *Daemon) removeContainer(c *containerRecord, removeVolumes bool) {
+	for _, n := range d.networks {
+		delete(n.Containers, c.ID)
+	}
+	for id, e := range d.execs {
+		if e.ContainerID == c.ID {
+			delete(d.execs, id)
+		}
+	}
+	delete(d.containers, c.ID)
+	if removeVolumes {
+		for _, name := range c.anonymousVolumes {
+			if v, ok := d.volumes[name]; ok && !d.volumeInUse(name) {
+				delete(d.volumes, name)
+				d.logEvent(events.VolumeEventType, "destroy", name, map[string]string{"driver": v.Driver})
+			}
+		}
+	}
+	d.logContainerEvent(c, "destroy", nil)
+}
+
+func (d *Daemon) postContainerStart(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if c.State.Running {
+		return errNotModified()
+	}
+	d.startContainer(c)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (d *Daemon) postContainerStop(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		return errNotModified()
+	}
+	d.exitContainer(c, 0)
+	d.logContainerEvent(c, "stop", nil)
+	d.autoRemove(c)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (d *Daemon) postContainerRestart(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if c.State.Running {
+		d.exitContainer(c, 0)
+	}
+	d.startContainer(c)
+	c.RestartCount++
+	d.logContainerEvent(c, "restart", nil)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// parseSignal returns the number of a signal, and true if it makes the
+// containers exit. It accepts the names of the signals, with or without
+// the SIG prefix, and their numbers.
+func parseSignal(s string) (int, bool, error) {
+	if s == "" {
+		s = "KILL"
+	}
+	name := strings.TrimPrefix(strings.ToUpper(s), "SIG")
+	if n, ok := stopSignals[name]; ok {
+		return n, true, nil
+	}
+	if n, ok := otherSignals[name]; ok {
+		return n, false, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, false, errBadRequest("Invalid signal: %s", s)
+	}
+	for _, stop := range stopSignals {
+		if stop == n {
+			return n, true, nil
+		}
+	}
+	return n, false, nil
+}
+
+func (d *Daemon) postContainerKill(w http.ResponseWriter, r *http.Request, vars []string) error {
+	signal, stop, err := parseSignal(r.URL.Query().Get("signal"))
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		return errConflict("Container %s is not running", vars[0])
+	}
+	d.logContainerEvent(c, "kill", map[string]string{"signal": strconv.Itoa(signal)})
+	if stop {
+		d.exitContainer(c, 128+signal)
+		d.autoRemove(c)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (d *Daemon) postContainerPause(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		return errConflict("Container %s is not running", vars[0])
+	}
+	if c.State.Paused {
+		return errConflict("Container %s is already paused", vars[0])
+	}
+	c.State.Paused = true
+	c.State.Status = "paused"
+	d.logContainerEvent(c, "pause", nil)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (d *Daemon) postContainerUnpause(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if !c.State.Paused {
+		return errConflict("Container %s is not paused", vars[0])
+	}
+	c.State.Paused = false
+	c.State.Status = "running"
+	d.logContainerEvent(c, "unpause", nil)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (d *Daemon) deleteContainer(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if c.State.Running {
+		if !boolValue(r, "force") {
+			return errConflict("You cannot remove a running container %s. Stop the container before attempting removal or use -f", c.ID)
+		}
+		d.logContainerEvent(c, "kill", map[string]string{"signal": "9"})
+		d.exitContainer(c, 137)
+	}
+	d.removeContainer(c, boolValue(r, "v"))
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (d *Daemon) postContainerRename(w http.ResponseWriter, r *http.Request, vars []string) error {
+	name := strings.TrimPrefix(r.URL.Query().Get("name"), "/")
+	if name == "" {
+		return errBadRequest("Neither old nor new names may be empty")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if other, err := d.lookupContainer("/" + name); err == nil && other.name() == name {
+		return errConflict("Conflict. The name \"/%s\" is already in use by container %s. You have to remove (or rename) that container to be able to reuse that name.", name, other.ID)
+	}
+	oldName := c.Name
+	c.Name = "/" + name
+	d.logContainerEvent(c, "rename", map[string]string{"oldName": oldName})
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (d *Daemon) postContainerUpdate(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var update container.UpdateConfig
+	if err := decodeBody(r, &update); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	resources := &c.HostConfig.Resources
+	if update.BlkioWeight != 0 {
+		resources.BlkioWeight = update.BlkioWeight
+	}
+	if update.CPUShares != 0 {
+		resources.CPUShares = update.CPUShares
+	}
+	if update.CPUPeriod != 0 {
+		resources.CPUPeriod = update.CPUPeriod
+	}
+	if update.CPUQuota != 0 {
+		resources.CPUQuota = update.CPUQuota
+	}
+	if update.CpusetCpus != "" {
+		resources.CpusetCpus = update.CpusetCpus
+	}
+	if update.CpusetMems != "" {
+		resources.CpusetMems = update.CpusetMems
+	}
+	if update.Memory != 0 {
+		resources.Memory = update.Memory
+	}
+	if update.MemorySwap != 0 {
+		resources.MemorySwap = update.MemorySwap
+	}
+	if update.MemoryReservation != 0 {
+		resources.MemoryReservation = update.MemoryReservation
+	}
+	if update.KernelMemory != 0 {
+		resources.KernelMemory = update.KernelMemory
+	}
+	if update.RestartPolicy.Name != "" {
+		c.HostConfig.RestartPolicy = update.RestartPolicy
+	}
+	d.logContainerEvent(c, "update", nil)
+	return writeJSON(w, http.StatusOK, types.ContainerUpdateResponse{Warnings: []string{}})
+}
+
+// waitStopped waits until the container stops, the client closes
+// the connection or the daemon is closed. It returns false if the
+// container didn't stop.
+func (d *Daemon) waitStopped(w http.ResponseWriter, stopped chan struct{}) bool {
+	select {
+	case <-stopped:
+		return true
+	case <-closeNotify(w):
+		return false
+	case <-d.closed:
+		return false
+	}
+}
+
+func (d *Daemon) postContainerWait(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	c, err := d.lookupContainer(vars[0])
+	var stopped chan struct{}
+	if err == nil {
+		stopped = c.stopped
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if !d.waitStopped(w, stopped) {
+		return nil
+	}
+	d.mu.Lock()
+	code := c.State.ExitCode
+	d.mu.Unlock()
+	return writeJSON(w, http.StatusOK, types.ContainerWaitResponse{StatusCode: code})
+}
+
+// containerSummary returns the container as it's listed.
+// The caller must hold mu.
+func (d *Daemon) containerSummary(c *containerRecord) types.Container {
+	created, _ := time.Parse(time.RFC3339Nano, c.Created)
+	summary := types.Container{
+		ID:      c.ID,
+		Names:   []string{c.Name},
+		Image:   c.Config.Image,
+		ImageID: c.Image,
+		Command: strings.Join(append([]string{c.Path}, c.Args...), " "),
+		Created: created.Unix(),
+		Ports:   []types.Port{},
+		Labels:  c.Config.Labels,
+		State:   c.State.Status,
+		Status:  containerStatus(c.State),
+		NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: c.NetworkSettings.Networks,
+		},
+		Mounts: c.Mounts,
+	}
+	summary.HostConfig.NetworkMode = string(c.HostConfig.NetworkMode)
+	for p := range c.Config.ExposedPorts {
+		summary.Ports = append(summary.Ports, types.Port{PrivatePort: p.Int(), Type: p.Proto()})
+	}
+	return summary
+}
+
+// containerStatus returns the status of the container, in the format
+// used by the daemon to list them.
+func containerStatus(state *types.ContainerState) string {
+	switch state.Status {
+	case "running", "paused":
+		startedAt, _ := time.Parse(time.RFC3339Nano, state.StartedAt)
+		status := "Up " + units.HumanDuration(time.Since(startedAt))
+		if state.Paused {
+			status += " (Paused)"
+		}
+		return status
+	case "exited":
+		finishedAt, _ := time.Parse(time.RFC3339Nano, state.FinishedAt)
+		return fmt.Sprintf("Exited (%d) %s ago", state.ExitCode, units.HumanDuration(time.Since(finishedAt)))
+	}
+	return "Created"
+}
+
+// containersBySeq sorts containers from the newest to the oldest.
+type containersBySeq []*containerRecord
+
+func (s containersBySeq) Len() int           { return len(s) }
+func (s containersBySeq) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s containersBySeq) Less(i, j int) bool { return s[i].seq > s[j].seq }
+
+// sortedContainers returns the containers from the newest to the oldest.
+// The caller must hold mu.
+func (d *Daemon) sortedContainers() []*containerRecord {
+	containers := make([]*containerRecord, 0, len(d.containers))
+	for _, c := range d.containers {
+		containers = append(containers, c)
+	}
+	sort.Sort(containersBySeq(containers))
+	return containers
+}
+
+// listContainers returns the summaries of all the containers, from the
+// newest to the oldest. The caller must hold mu.
+func (d *Daemon) listContainers() []types.Container {
+	var list []types.Container
+	for _, c := range d.sortedContainers() {
+		list = append(list, d.containerSummary(c))
+	}
+	return list
+}
+
+func (d *Daemon) getContainers(w http.ResponseWriter, r *http.Request, vars []string) error {
+	query := r.URL.Query()
+	args, err := filters.FromParam(query.Get("filters"))
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+	limit, err := intValue(r, "limit", 0)
+	if err != nil {
+		return err
+	}
+	// The legacy since and before parameters are like the filters,
+	// and like the limit they list the containers in all states.
+	all := boolValue(r, "all") || limit > 0 || args.Include("status")
+	for _, name := range []string{"since", "before"} {
+		if v := query.Get(name); v != "" {
+			args.Add(name, v)
+			all = true
+		}
+	}
+
+	d.mu.Lock()
+	list := d.listContainers()
+	d.mu.Unlock()
+
+	list, err = client.FilterContainers(args, list)
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+	containers := []types.Container{}
+	for _, c := range list {
+		if !all && c.State != "running" && c.State != "paused" {
+			continue
+		}
+		if limit > 0 && len(containers) == limit {
+			break
+		}
+		containers = append(containers, c)
+	}
+	return writeJSON(w, http.StatusOK, containers)
+}
+
+func (d *Daemon) getContainer(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	c, err := d.lookupContainer(vars[0])
+	var inspect types.ContainerJSON
+	if err == nil {
+		base := *c.ContainerJSONBase
+		state := *c.State
+		base.State = &state
+		if boolValue(r, "size") {
+			var sizeRw, sizeRootFs int64 = c.fs.size(), defaultImageSize
+			base.SizeRw = &sizeRw
+			base.SizeRootFs = &sizeRootFs
+		}
+		inspect = c.ContainerJSON
+		inspect.ContainerJSONBase = &base
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, inspect)
+}
+
+func (d *Daemon) getContainerTop(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		return errConflict("Container %s is not running", vars[0])
+	}
+	return writeJSON(w, http.StatusOK, types.ContainerProcessList{
+		Titles: []string{"UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"},
+		Processes: [][]string{{
+			"root", strconv.Itoa(c.State.Pid), "1", "0", "00:00", "?", "00:00:00",
+			strings.Join(append([]string{c.Path}, c.Args...), " "),
+		}},
+	})
+}
+
+func (d *Daemon) getContainerChanges(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	c, err := d.lookupContainer(vars[0])
+	var changes []types.ContainerChange
+	if err == nil {
+		changes = c.fs.changes()
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, changes)
+}
+
+// getContainerLogs sends no logs, the containers don't run any process.
+// With follow, the stream ends when the container stops.
+func (d *Daemon) getContainerLogs(w http.ResponseWriter, r *http.Request, vars []string) error {
+	if !boolValue(r, "stdout") && !boolValue(r, "stderr") {
+		return errBadRequest("Bad parameters: you must choose at least one stream")
+	}
+	d.mu.Lock()
+	c, err := d.lookupContainer(vars[0])
+	var stopped chan struct{}
+	if err == nil {
+		stopped = c.stopped
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+	if boolValue(r, "follow") {
+		d.waitStopped(w, stopped)
+	}
+	return nil
+}
+
+// containerStats returns a sample of the statistics of the container.
+// The caller must hold mu.
+func containerStats(c *containerRecord, previous *types.StatsJSON) types.StatsJSON {
+	var stats types.StatsJSON
+	stats.Read = now()
+	if previous != nil {
+		stats.PreCPUStats = previous.CPUStats
+	}
+	if c.State.Running {
+		elapsed := uint64(stats.Read.UnixNano())
+		stats.CPUStats = types.CPUStats{
+			CPUUsage:    types.CPUUsage{TotalUsage: elapsed / 1000, PercpuUsage: []uint64{elapsed / 1000}},
+			SystemUsage: elapsed,
+		}
+		stats.MemoryStats = types.MemoryStats{
+			Usage:    defaultImageSize,
+			MaxUsage: defaultImageSize,
+			Limit:    uint64(c.HostConfig.Memory),
+		}
+		stats.PidsStats = types.PidsStats{Current: 1}
+		stats.Networks = map[string]types.NetworkStats{"eth0": {}}
+	}
+	return stats
+}
+
+func (d *Daemon) getContainerStats(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	c, err := d.lookupContainer(vars[0])
+	var stats types.StatsJSON
+	var stopped chan struct{}
+	if err == nil {
+		stats = containerStats(c, nil)
+		stopped = c.stopped
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	stream := r.URL.Query().Get("stream") == "" || boolValue(r, "stream")
+	if err := writeJSON(w, http.StatusOK, stats); err != nil || !stream {
+		return nil
+	}
+	flush(w)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	closing := closeNotify(w)
+	for {
+		select {
+		case <-ticker.C:
+			d.mu.Lock()
+			stats = containerStats(c, &stats)
+			d.mu.Unlock()
+			if err := json.NewEncoder(w).Encode(stats); err != nil {
+				return nil
+			}
+			flush(w)
+		case <-stopped:
+			return nil
+		case <-closing:
+			return nil
+		case <-d.closed:
+			return nil
+		}
+	}
+}
+
+func (d *Daemon) getContainerExport(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	c, err := d.lookupContainer(vars[0])
+	var fs filesystem
+	if err == nil {
+		fs = c.fs.clone()
+		d.logContainerEvent(c, "export", nil)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	fs.writeTar(w, "/", "")
+	return nil
+}
+
+func (d *Daemon) postContainerResize(w http.ResponseWriter, r *http.Request, vars []string) error {
+	height, err := intValue(r, "h", 0)
+	if err != nil {
+		return err
+	}
+	width, err := intValue(r, "w", 0)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		return errConflict("Container %s is not running", vars[0])
+	}
+	d.logContainerEvent(c, "resize", map[string]string{
+		"height": strconv.Itoa(height),
+		"width":  strconv.Itoa(width),
+	})
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// postContainerAttach hijacks the connection and keeps it open until the
+// container stops. The input sent by the client is discarded and the
+// containers don't write any output.
+func (d *Daemon) postContainerAttach(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	c, err := d.lookupContainer(vars[0])
+	var stopped chan struct{}
+	if err == nil {
+		stopped = c.stopped
+		d.logContainerEvent(c, "attach", nil)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	conn, rw, err := hijack(w, r)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if !boolValue(r, "stream") {
+		return nil
+	}
+
+	go io.Copy(ioutil.Discard, rw)
+	select {
+	case <-stopped:
+	case <-d.closed:
+	}
+	return nil
+}
+
+// hijack takes over the connection of the request, and writes the header
+// of the response that upgrades it to a raw stream, like the daemon does
+// for attach and exec.
+func hijack(w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the connection can't be hijacked")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	status := "HTTP/1.1 200 OK"
+	if r.Header.Get("Upgrade") == "tcp" {
+		status = "HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp"
+	}
+	fmt.Fprintf(rw, "%s\r\nContent-Type: application/vnd.docker.raw-stream\r\n\r\n", status)
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, rw, nil
+}
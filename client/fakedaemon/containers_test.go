@@ -0,0 +1,287 @@
+package fakedaemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+func TestContainerLifecycle(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	imageID := d.AddImage("busybox", &container.Config{Cmd: []string{"top"}})
+	ctx := context.Background()
+
+	c, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspect, err := cli.ContainerInspect(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspect.ID != c.ID || inspect.Image != imageID || inspect.Path != "top" || inspect.State.Status != "created" {
+		t.Fatalf("unexpected container %+v with state %+v", inspect.ContainerJSONBase, inspect.State)
+	}
+	if ip := inspect.NetworkSettings.Networks["bridge"].IPAddress; ip != "172.17.0.2" {
+		t.Fatalf("expected the container on the bridge network with address 172.17.0.2, got %q", ip)
+	}
+
+	if err := cli.ContainerStart(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.ContainerPause(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.ContainerPause(ctx, c.ID); err == nil {
+		t.Fatal("expected an error pausing a paused container")
+	}
+	if err := cli.ContainerUnpause(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := cli.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != c.ID || list[0].State != "running" || list[0].Names[0] != "/test" {
+		t.Fatalf("expected the running container in the list, got %+v", list)
+	}
+
+	if err := cli.ContainerStop(ctx, c.ID, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.ContainerKill(ctx, c.ID, "KILL"); err == nil {
+		t.Fatal("expected an error killing a stopped container")
+	}
+	list, err = cli.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("expected no running containers, got %+v", list)
+	}
+	list, err = cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].State != "exited" {
+		t.Fatalf("expected the exited container in the list, got %+v", list)
+	}
+
+	if err := cli.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.ContainerInspect(ctx, c.ID); !client.IsErrContainerNotFound(err) {
+		t.Fatalf("expected a container not found error, got %v", err)
+	}
+}
+
+func TestContainerCreateErrors(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	ctx := context.Background()
+
+	_, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "")
+	if !client.IsErrImageNotFound(err) {
+		t.Fatalf("expected an image not found error, got %v", err)
+	}
+
+	d.AddImage("busybox", nil)
+	if _, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "test"); err == nil {
+		t.Fatal("expected an error creating a container with a name in use")
+	}
+}
+
+func TestContainerWait(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	c, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.ContainerStart(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	codes := make(chan int, 1)
+	go func() {
+		code, err := cli.ContainerWait(ctx, c.ID)
+		if err != nil {
+			code = -1
+		}
+		codes <- code
+	}()
+
+	select {
+	case code := <-codes:
+		t.Fatalf("expected wait to block while the container runs, got %d", code)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := d.ExitContainer(c.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case code := <-codes:
+		if code != 3 {
+			t.Fatalf("expected exit code 3, got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the container")
+	}
+}
+
+func TestContainerAutoRemove(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	c, err := cli.ContainerCreate(ctx,
+		&container.Config{Image: "busybox", Volumes: map[string]struct{}{"/data": {}}},
+		&container.HostConfig{AutoRemove: true}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.ContainerStart(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.ContainerKill(ctx, c.ID, "SIGTERM"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.ContainerInspect(ctx, c.ID); !client.IsErrContainerNotFound(err) {
+		t.Fatalf("expected the container to be removed, got %v", err)
+	}
+	volumes, err := cli.VolumeList(ctx, filters.NewArgs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes.Volumes) != 0 {
+		t.Fatalf("expected the anonymous volume to be removed, got %+v", volumes.Volumes)
+	}
+}
+
+func TestCopyToAndFromContainer(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	c, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	content := []byte("hello")
+	tw.WriteHeader(&tar.Header{Name: "dir/", Mode: 0755, Typeflag: tar.TypeDir})
+	tw.WriteHeader(&tar.Header{Name: "dir/file", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+
+	if err := cli.CopyToContainer(ctx, c.ID, "/tmp", bytes.NewReader(buf.Bytes()), types.CopyToContainerOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := cli.ContainerStatPath(ctx, c.ID, "/tmp/dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Name != "file" || stat.Size != int64(len(content)) {
+		t.Fatalf("unexpected stat %+v", stat)
+	}
+	if _, err := cli.ContainerStatPath(ctx, c.ID, "/missing"); !client.IsErrNotFound(err) {
+		t.Fatalf("expected a path not found error, got %v", err)
+	}
+
+	r, stat, err := cli.CopyFromContainer(ctx, c.ID, "/tmp/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if !stat.Mode.IsDir() {
+		t.Fatalf("expected a directory, got %+v", stat)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(tr)
+		files[hdr.Name] = string(b)
+	}
+	if len(files) != 2 || files["dir/file"] != "hello" {
+		t.Fatalf("unexpected archive content %v", files)
+	}
+}
+
+func TestContainerExec(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	d.HandleExec(func(containerID string, cmd []string, stdout, stderr io.Writer) int {
+		fmt.Fprintf(stdout, "%v", cmd)
+		fmt.Fprint(stderr, "warning")
+		return 2
+	})
+	ctx := context.Background()
+
+	c, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := types.ExecConfig{Cmd: []string{"echo", "hello"}, AttachStdout: true, AttachStderr: true}
+	if _, err := cli.ContainerExecCreate(ctx, c.ID, config); err == nil {
+		t.Fatal("expected an error creating an exec in a container that isn't running")
+	}
+	if err := cli.ContainerStart(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	exec, err := cli.ContainerExecCreate(ctx, c.ID, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cli.ContainerExecAttach(ctx, exec.ID, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	_, err = client.StdCopy(&stdout, &stderr, resp.Reader)
+	resp.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "[echo hello]" || stderr.String() != "warning" {
+		t.Fatalf("unexpected output %q and %q", stdout.String(), stderr.String())
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspect.Running || inspect.ExitCode != 2 {
+		t.Fatalf("expected the exec to exit with 2, got %+v", inspect)
+	}
+}
@@ -0,0 +1,203 @@
+// Package fakedaemon provides an in-process fake Docker daemon to test code
+// that uses the API client without a real daemon.
+//
+// The daemon keeps containers, images, networks, volumes, execs and events
+// in memory, and it serves the endpoints called by the client over TCP or
+// unix sockets:
+//
+//	d := fakedaemon.New()
+//	defer d.Close()
+//	d.AddImage("busybox:latest", nil)
+//	cli, err := client.NewClientWithOpts(client.WithHost(d.Host()))
+//
+// Containers don't run any process. They stay running until they are
+// stopped, killed, or until the test calls ExitContainer. Failures can be
+// injected in any endpoint with InjectFailure.
+package fakedaemon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/versions"
+)
+
+// DefaultAPIVersion is the API version that the daemon reports by default.
+const DefaultAPIVersion = "1.25"
+
+// versionPrefix matches the version prefix of the request paths.
+var versionPrefix = regexp.MustCompile(`^/v([0-9.]+)(/.*)$`)
+
+// Daemon is a fake Docker daemon that keeps its state in memory.
+// It's safe to use it from several goroutines.
+type Daemon struct {
+	server *httptest.Server
+	host   string
+	routes []route
+
+	mu          sync.Mutex
+	apiVersion  string
+	containers  map[string]*containerRecord
+	images      map[string]*imageRecord
+	networks    map[string]*networkRecord
+	volumes     map[string]*types.Volume
+	execs       map[string]*execRecord
+	execFunc    ExecFunc
+	counter     int
+	subnets     int
+	failures    []*Failure
+	events      []events.Message
+	subscribers map[chan events.Message]bool
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// New starts a fake daemon listening on a random TCP port of the loopback interface.
+func New() *Daemon {
+	d := newDaemon()
+	d.server = httptest.NewServer(d)
+	d.host = "tcp://" + d.server.Listener.Addr().String()
+	return d
+}
+
+// NewUnix starts a fake daemon listening on the unix socket at socketPath.
+func NewUnix(socketPath string) (*Daemon, error) {
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	d := newDaemon()
+	d.server = &httptest.Server{
+		Listener: l,
+		Config:   &http.Server{Handler: d},
+	}
+	d.server.Start()
+	d.host = "unix://" + socketPath
+	return d, nil
+}
+
+// newDaemon creates the state of a daemon without starting its server.
+func newDaemon() *Daemon {
+	d := &Daemon{
+		apiVersion:  DefaultAPIVersion,
+		containers:  make(map[string]*containerRecord),
+		images:      make(map[string]*imageRecord),
+		networks:    make(map[string]*networkRecord),
+		volumes:     make(map[string]*types.Volume),
+		execs:       make(map[string]*execRecord),
+		subscribers: make(map[chan events.Message]bool),
+		closed:      make(chan struct{}),
+	}
+	d.routes = d.newRoutes()
+	for _, name := range []string{"bridge", "host", "none"} {
+		d.addNetwork(generateID(), name, types.NetworkCreate{}, true)
+	}
+	return d
+}
+
+// Host returns the address of the daemon, to configure the client with it.
+// It's like tcp://127.0.0.1:port or unix:///path/to/socket.
+func (d *Daemon) Host() string {
+	return d.host
+}
+
+// Close stops the daemon. It ends the streams that are still open, like
+// the events or the stats, and the calls that are still waiting for
+// containers to exit.
+func (d *Daemon) Close() {
+	d.closeOnce.Do(func() {
+		close(d.closed)
+		d.server.CloseClientConnections()
+		d.server.Close()
+	})
+}
+
+// SetAPIVersion sets the API version reported by the daemon. Requests for
+// newer API versions are rejected, like real daemons do.
+func (d *Daemon) SetAPIVersion(version string) {
+	d.mu.Lock()
+	d.apiVersion = version
+	d.mu.Unlock()
+}
+
+// ServeHTTP serves the requests sent to the daemon.
+// It makes possible to use the daemon as the handler of other servers.
+func (d *Daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.Path
+	if m := versionPrefix.FindStringSubmatch(p); m != nil {
+		d.mu.Lock()
+		apiVersion := d.apiVersion
+		d.mu.Unlock()
+		if versions.GreaterThan(m[1], apiVersion) {
+			writeError(w, errBadRequest("client is newer than server (client API version: %s, server API version: %s)", m[1], apiVersion))
+			return
+		}
+		p = m[2]
+	}
+
+	if f := d.failure(r.Method, p); f != nil {
+		f.write(w)
+		return
+	}
+
+	for _, rt := range d.routes {
+		if rt.method != r.Method {
+			continue
+		}
+		if vars, ok := matchRoute(rt.parts, splitPath(p)); ok {
+			if err := rt.handler(w, r, vars); err != nil {
+				writeError(w, err)
+			}
+			return
+		}
+	}
+	writeError(w, errNotFound("page not found"))
+}
+
+// generateID returns a random identifier like the ones generated by the daemon.
+func generateID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("fakedaemon: unable to generate an id: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// shortID returns the short form of an identifier.
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// now returns the current time, in UTC like the daemon reports it.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// version returns the version information of the daemon.
+// The caller must hold mu.
+func (d *Daemon) version() types.Version {
+	return types.Version{
+		Version:       "1.12.0-fake",
+		APIVersion:    d.apiVersion,
+		GitCommit:     "fake",
+		GoVersion:     runtime.Version(),
+		Os:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		KernelVersion: "fake",
+	}
+}
@@ -0,0 +1,130 @@
+package fakedaemon
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/client"
+	"golang.org/x/net/context"
+)
+
+// newTestDaemon starts a daemon and returns a client configured for it.
+func newTestDaemon(t *testing.T, opts ...client.Opt) (*Daemon, *client.Client) {
+	d := New()
+	cli, err := client.NewClientWithOpts(append([]client.Opt{client.WithHost(d.Host())}, opts...)...)
+	if err != nil {
+		d.Close()
+		t.Fatal(err)
+	}
+	return d, cli
+}
+
+func TestDaemonTCP(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+
+	if !strings.HasPrefix(d.Host(), "tcp://127.0.0.1:") {
+		t.Fatalf("expected a TCP host on the loopback interface, got %s", d.Host())
+	}
+	v, err := cli.ServerVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.APIVersion != DefaultAPIVersion {
+		t.Fatalf("expected API version %s, got %s", DefaultAPIVersion, v.APIVersion)
+	}
+}
+
+func TestDaemonUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fakedaemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := NewUnix(filepath.Join(dir, "docker.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	d.AddImage("busybox", nil)
+
+	cli, err := client.NewClientWithOpts(client.WithHost(d.Host()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := cli.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Images != 1 || info.Containers != 0 {
+		t.Fatalf("expected 1 image and 0 containers, got %d and %d", info.Images, info.Containers)
+	}
+}
+
+func TestDaemonRejectsNewerClients(t *testing.T) {
+	d, cli := newTestDaemon(t, client.WithVersion("1.24"))
+	defer d.Close()
+	d.SetAPIVersion("1.22")
+
+	_, err := cli.ServerVersion(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "client is newer than server (client API version: 1.24, server API version: 1.22)") {
+		t.Fatalf("expected an error about the API version, got %v", err)
+	}
+}
+
+func TestDaemonVersionNegotiation(t *testing.T) {
+	d, cli := newTestDaemon(t, client.WithAPIVersionNegotiation())
+	defer d.Close()
+	d.SetAPIVersion("1.22")
+
+	if _, err := cli.Info(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v := cli.ClientVersion(); v != "1.22" {
+		t.Fatalf("expected the client to negotiate API version 1.22, got %s", v)
+	}
+}
+
+func TestDaemonPageNotFound(t *testing.T) {
+	d := New()
+	defer d.Close()
+
+	resp, err := http.Get("http://" + strings.TrimPrefix(d.Host(), "tcp://") + "/v1.24/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status code 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	cases := []struct {
+		route string
+		path  string
+		vars  []string
+		ok    bool
+	}{
+		{"/containers/json", "/containers/json", nil, true},
+		{"/containers/*/json", "/containers/abc/json", []string{"abc"}, true},
+		{"/containers/*/json", "/containers/abc/top", nil, false},
+		{"/containers/*", "/containers/abc/json", nil, false},
+		{"/images/**/json", "/images/busybox/json", []string{"busybox"}, true},
+		{"/images/**/json", "/images/example.com/user/image:tag/json", []string{"example.com/user/image:tag"}, true},
+		{"/images/**", "/images/user/image", []string{"user/image"}, true},
+		{"/images/**", "/images", nil, false},
+	}
+	for _, c := range cases {
+		vars, ok := matchRoute(splitPath(c.route), splitPath(c.path))
+		if ok != c.ok || !reflect.DeepEqual(vars, c.vars) {
+			t.Errorf("%s with %s: expected %v %v, got %v %v", c.route, c.path, c.vars, c.ok, vars, ok)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package fakedaemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	timetypes "github.com/docker/engine-api/types/time"
+)
+
+// eventsBuffer is the number of events buffered for each subscriber,
+// the events are dropped for subscribers that don't keep up.
+const eventsBuffer = 256
+
+// logEvent records an event and sends it to the subscribers.
+// The caller must hold mu.
+func (d *Daemon) logEvent(eventType, action, id string, attributes map[string]string) {
+	t := now()
+	m := events.Message{
+		Type:   eventType,
+		Action: action,
+		Actor: events.Actor{
+			ID:         id,
+			Attributes: attributes,
+		},
+		Time:     t.Unix(),
+		TimeNano: t.UnixNano(),
+	}
+	if eventType == events.ContainerEventType {
+		m.Status = action
+		m.ID = id
+		m.From = attributes["image"]
+	}
+	d.events = append(d.events, m)
+	for ch := range d.subscribers {
+		select {
+		case ch <- m:
+		default:
+		}
+	}
+}
+
+// Events returns the events recorded by the daemon.
+func (d *Daemon) Events() []events.Message {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]events.Message(nil), d.events...)
+}
+
+// getEvents streams the events that happened since the since parameter,
+// and the new ones until the until parameter, if any.
+func (d *Daemon) getEvents(w http.ResponseWriter, r *http.Request, vars []string) error {
+	args, err := filters.FromParam(r.URL.Query().Get("filters"))
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+	if _, err := client.MatchEvent(args, events.Message{}); err != nil {
+		return errBadRequest("%v", err)
+	}
+	since, err := parseEventsTime(r.URL.Query().Get("since"))
+	if err != nil {
+		return err
+	}
+	until, err := parseEventsTime(r.URL.Query().Get("until"))
+	if err != nil {
+		return err
+	}
+
+	var untilTimer <-chan time.Time
+	if !until.IsZero() {
+		wait := until.Sub(time.Now())
+		if wait < 0 {
+			wait = 0
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		untilTimer = timer.C
+	}
+
+	ch := make(chan events.Message, eventsBuffer)
+	d.mu.Lock()
+	var past []events.Message
+	if !since.IsZero() {
+		for _, m := range d.events {
+			if m.TimeNano >= since.UnixNano() && (until.IsZero() || m.TimeNano <= until.UnixNano()) {
+				past = append(past, m)
+			}
+		}
+	}
+	d.subscribers[ch] = true
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.subscribers, ch)
+		d.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+
+	enc := json.NewEncoder(w)
+	send := func(m events.Message) error {
+		if ok, err := client.MatchEvent(args, m); err != nil || !ok {
+			return err
+		}
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+		flush(w)
+		return nil
+	}
+
+	for _, m := range past {
+		if err := send(m); err != nil {
+			return nil
+		}
+	}
+
+	closing := closeNotify(w)
+	for {
+		select {
+		case m := <-ch:
+			if !until.IsZero() && m.TimeNano > until.UnixNano() {
+				return nil
+			}
+			if err := send(m); err != nil {
+				return nil
+			}
+		case <-untilTimer:
+			return nil
+		case <-closing:
+			return nil
+		case <-d.closed:
+			return nil
+		}
+	}
+}
+
+// parseEventsTime parses the since and until parameters of the events,
+// in the format seconds.nanoseconds sent by the client.
+func parseEventsTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	sec, nsec, err := timetypes.ParseTimestamps(value, 0)
+	if err != nil {
+		return time.Time{}, errBadRequest("%v", err)
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// flush sends the data written to the response so far to the client.
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// closeNotify returns a channel that receives a value when the client
+// closes the connection. It never receives a value if the response
+// writer doesn't support it.
+func closeNotify(w http.ResponseWriter) <-chan bool {
+	if cn, ok := w.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
+}
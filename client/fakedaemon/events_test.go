@@ -0,0 +1,101 @@
+package fakedaemon
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+func TestEvents(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	args := filters.NewArgs()
+	args.Add("type", "container")
+	body, err := cli.Events(ctx, types.EventsOptions{Filters: args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+
+	c, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.ContainerStart(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(body)
+	for _, action := range []string{"create", "start"} {
+		var m events.Message
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Type != events.ContainerEventType || m.Action != action || m.Actor.ID != c.ID || m.Actor.Attributes["name"] != "test" {
+			t.Fatalf("expected the %s event of the container, got %+v", action, m)
+		}
+	}
+}
+
+func TestEventsSinceUntil(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	since := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	if _, err := cli.VolumeCreate(ctx, types.VolumeCreateRequest{Name: "data"}); err != nil {
+		t.Fatal(err)
+	}
+	until := strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10)
+
+	body, err := cli.Events(ctx, types.EventsOptions{Since: since, Until: until})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+
+	var messages []events.Message
+	dec := json.NewDecoder(body)
+	for {
+		var m events.Message
+		if err := dec.Decode(&m); err != nil {
+			break
+		}
+		messages = append(messages, m)
+	}
+	var found bool
+	for _, m := range messages {
+		if m.Type == events.VolumeEventType && m.Action == "create" && m.Actor.ID == "data" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the past events until the end of the stream, got %+v", messages)
+	}
+	if recorded := d.Events(); len(recorded) != len(messages) {
+		t.Fatalf("expected %d recorded events, got %d", len(messages), len(recorded))
+	}
+}
+
+func TestEventsInvalidFilter(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+
+	args := filters.NewArgs()
+	args.Add("unknown", "value")
+	if _, err := cli.Events(context.Background(), types.EventsOptions{Filters: args}); err == nil {
+		t.Fatal("expected an error with an invalid filter")
+	}
+}
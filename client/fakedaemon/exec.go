@@ -0,0 +1,210 @@
+package fakedaemon
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+)
+
+// ExecFunc runs the command of an exec in a container. It writes the output
+// of the command to stdout and stderr, and it returns its exit code.
+type ExecFunc func(containerID string, cmd []string, stdout, stderr io.Writer) int
+
+// execProcessConfig is the process of an exec, as the daemon reports it.
+type execProcessConfig struct {
+	Tty        bool     `json:"tty"`
+	Entrypoint string   `json:"entrypoint"`
+	Arguments  []string `json:"arguments"`
+	Privileged bool     `json:"privileged"`
+	User       string   `json:"user"`
+}
+
+// execRecord holds the state of an exec, in the format of the daemon
+// to inspect it.
+type execRecord struct {
+	ID            string
+	Running       bool
+	ExitCode      *int
+	ProcessConfig execProcessConfig
+	OpenStdin     bool
+	OpenStderr    bool
+	OpenStdout    bool
+	ContainerID   string
+	DetachKeys    string
+
+	// started is true once the exec is started, it can't be started again.
+	started bool
+	cmd     []string
+}
+
+// HandleExec sets the function that runs the commands of the execs.
+// By default the commands don't write any output and exit with 0.
+func (d *Daemon) HandleExec(fn ExecFunc) {
+	d.mu.Lock()
+	d.execFunc = fn
+	d.mu.Unlock()
+}
+
+func (d *Daemon) postContainerExec(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var config types.ExecConfig
+	if err := decodeBody(r, &config); err != nil {
+		return err
+	}
+	if len(config.Cmd) == 0 {
+		return errBadRequest("No exec command specified")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(vars[0])
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		return errConflict("Container %s is not running", vars[0])
+	}
+	if c.State.Paused {
+		return errConflict("Container %s is paused, unpause the container before exec", vars[0])
+	}
+
+	e := &execRecord{
+		ID: generateID(),
+		ProcessConfig: execProcessConfig{
+			Tty:        config.Tty,
+			Entrypoint: config.Cmd[0],
+			Arguments:  config.Cmd[1:],
+			Privileged: config.Privileged,
+			User:       config.User,
+		},
+		OpenStdin:   config.AttachStdin,
+		OpenStderr:  config.AttachStderr,
+		OpenStdout:  config.AttachStdout,
+		ContainerID: c.ID,
+		DetachKeys:  config.DetachKeys,
+		cmd:         config.Cmd,
+	}
+	d.execs[e.ID] = e
+	c.ExecIDs = append(c.ExecIDs, e.ID)
+	d.logContainerEvent(c, "exec_create: "+strings.Join(config.Cmd, " "), nil)
+	return writeJSON(w, http.StatusCreated, types.ContainerExecCreateResponse{ID: e.ID})
+}
+
+// startExec marks the exec as started, and returns the function that runs it.
+// The caller must hold mu.
+func (d *Daemon) startExec(id string) (*execRecord, ExecFunc, error) {
+	e, ok := d.execs[id]
+	if !ok {
+		return nil, nil, errNotFound("No such exec instance '%s' found in daemon", id)
+	}
+	if e.started {
+		return nil, nil, errConflict("Error: Exec command %s has already run", id)
+	}
+	c, ok := d.containers[e.ContainerID]
+	if !ok || !c.State.Running {
+		return nil, nil, errConflict("Container %s is not running", e.ContainerID)
+	}
+	e.started = true
+	e.Running = true
+	d.logContainerEvent(c, "exec_start: "+strings.Join(e.cmd, " "), nil)
+
+	fn := d.execFunc
+	if fn == nil {
+		fn = func(string, []string, io.Writer, io.Writer) int { return 0 }
+	}
+	return e, fn, nil
+}
+
+// finishExec records the exit code of the exec.
+func (d *Daemon) finishExec(e *execRecord, code int) {
+	d.mu.Lock()
+	e.Running = false
+	e.ExitCode = &code
+	d.mu.Unlock()
+}
+
+// postExecStart runs the exec. Detached execs run before the response is
+// sent, so their exit code can be inspected when the call returns. The
+// connections of the other ones are hijacked to send the output, which is
+// multiplexed unless the exec has a TTY.
+func (d *Daemon) postExecStart(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var check types.ExecStartCheck
+	if err := decodeBody(r, &check); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	e, fn, err := d.startExec(vars[0])
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if check.Detach {
+		d.finishExec(e, fn(e.ContainerID, e.cmd, ioutil.Discard, ioutil.Discard))
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	conn, rw, err := hijack(w, r)
+	if err != nil {
+		d.finishExec(e, -1)
+		return err
+	}
+	defer conn.Close()
+
+	if e.OpenStdin {
+		go io.Copy(ioutil.Discard, rw)
+	}
+	var stdout, stderr io.Writer = ioutil.Discard, ioutil.Discard
+	if e.OpenStdout {
+		stdout = conn
+	}
+	if e.OpenStderr {
+		stderr = conn
+	}
+	if !check.Tty && !e.ProcessConfig.Tty {
+		if e.OpenStdout {
+			stdout = client.NewStdWriter(conn, client.Stdout)
+		}
+		if e.OpenStderr {
+			stderr = client.NewStdWriter(conn, client.Stderr)
+		}
+	}
+	d.finishExec(e, fn(e.ContainerID, e.cmd, stdout, stderr))
+	return nil
+}
+
+func (d *Daemon) getExec(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.execs[vars[0]]
+	if !ok {
+		return errNotFound("No such exec instance '%s' found in daemon", vars[0])
+	}
+	return writeJSON(w, http.StatusOK, e)
+}
+
+func (d *Daemon) postExecResize(w http.ResponseWriter, r *http.Request, vars []string) error {
+	if _, err := intValue(r, "h", 0); err != nil {
+		return err
+	}
+	if _, err := intValue(r, "w", 0); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.execs[vars[0]]
+	if !ok {
+		return errNotFound("No such exec instance '%s' found in daemon", vars[0])
+	}
+	if !e.Running {
+		return errConflict("Exec %s is not running", e.ID)
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
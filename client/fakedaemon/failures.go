@@ -0,0 +1,79 @@
+package fakedaemon
+
+import (
+	"net/http"
+	"path"
+)
+
+// Failure describes the requests that the daemon fails instead of handling them.
+type Failure struct {
+	// Method is the HTTP method of the requests, empty matches every method.
+	Method string
+	// Path is a pattern for the path of the requests, without the version
+	// prefix, in the syntax of path.Match, like /containers/*/start.
+	// Empty matches every path.
+	Path string
+	// StatusCode is the HTTP status code of the responses.
+	// Zero closes the connection without sending any response,
+	// like when the connection with the daemon is lost.
+	StatusCode int
+	// Message is the error message of the responses.
+	Message string
+	// Times is the number of requests that fail, zero fails every request.
+	Times int
+}
+
+// InjectFailure makes the daemon fail the requests described by f.
+// Failures are matched in the order they are injected, and they are
+// removed when they have failed the number of requests in their Times.
+func (d *Daemon) InjectFailure(f Failure) {
+	d.mu.Lock()
+	d.failures = append(d.failures, &f)
+	d.mu.Unlock()
+}
+
+// ClearFailures removes all the failures injected in the daemon.
+func (d *Daemon) ClearFailures() {
+	d.mu.Lock()
+	d.failures = nil
+	d.mu.Unlock()
+}
+
+// failure returns the failure that matches the request, if any.
+func (d *Daemon) failure(method, p string) *Failure {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, f := range d.failures {
+		if f.Method != "" && f.Method != method {
+			continue
+		}
+		if f.Path != "" {
+			if ok, _ := path.Match(f.Path, p); !ok {
+				continue
+			}
+		}
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				d.failures = append(d.failures[:i], d.failures[i+1:]...)
+			}
+		}
+		return &matched
+	}
+	return nil
+}
+
+// write writes the failure as the response to a request.
+func (f *Failure) write(w http.ResponseWriter) {
+	if f.StatusCode == 0 {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		f.StatusCode = http.StatusInternalServerError
+	}
+	writeError(w, newAPIError(f.StatusCode, "%s", f.Message))
+}
@@ -0,0 +1,68 @@
+package fakedaemon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types/container"
+	"golang.org/x/net/context"
+)
+
+func TestInjectFailure(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	c, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.InjectFailure(Failure{
+		Method:     "POST",
+		Path:       "/containers/*/start",
+		StatusCode: 500,
+		Message:    "cannot start the container",
+		Times:      1,
+	})
+
+	err = cli.ContainerStart(ctx, c.ID)
+	if err == nil || err.Error() != "Error response from daemon: cannot start the container" {
+		t.Fatalf("expected the injected error, got %v", err)
+	}
+	if err := cli.ContainerStart(ctx, c.ID); err != nil {
+		t.Fatalf("expected the failure to be removed after one request, got %v", err)
+	}
+}
+
+func TestInjectFailureClosesConnection(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.InjectFailure(Failure{Path: "/info"})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := cli.Info(ctx); err == nil {
+			t.Fatal("expected an error when the connection is closed")
+		}
+	}
+	if _, err := cli.ServerVersion(ctx); err != nil {
+		t.Fatalf("expected other paths to succeed, got %v", err)
+	}
+
+	d.ClearFailures()
+	if _, err := cli.Info(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInjectFailureStatusCode(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.InjectFailure(Failure{Method: "GET", Path: "/containers/*/json", StatusCode: 404, Message: "No such container: x"})
+
+	_, err := cli.ContainerInspect(context.Background(), "x")
+	if err == nil || !strings.Contains(err.Error(), "No such container: x") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,764 @@
+package fakedaemon
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"github.com/docker/engine-api/types/registry"
+	"github.com/docker/engine-api/types/strslice"
+)
+
+// defaultImageSize is the size reported for the images, they have no content.
+const defaultImageSize = 1024
+
+// imageRecord holds the state of an image.
+type imageRecord struct {
+	types.ImageInspect
+	// createdBy is the instruction that created the image, for its history.
+	createdBy string
+}
+
+// normalizeReference returns the reference of an image as the daemon stores it,
+// without the default registry and with the latest tag if it has no tag.
+func normalizeReference(ref string) string {
+	ref = strings.TrimPrefix(ref, "docker.io/")
+	ref = strings.TrimPrefix(ref, "library/")
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+	if i := strings.LastIndex(ref, ":"); i < 0 || strings.Contains(ref[i:], "/") {
+		ref += ":latest"
+	}
+	return ref
+}
+
+// repositoryName returns the repository of a normalized reference.
+func repositoryName(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i >= 0 && !strings.Contains(ref[i:], "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
+// AddImage adds an image to the daemon with the given reference and config,
+// as if it had been pulled. It returns the ID of the image. The config can
+// be nil, the containers of the image run sh then.
+func (d *Daemon) AddImage(ref string, config *container.Config) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	img := d.addImage("", config, "", "")
+	if ref != "" {
+		d.tagImage(img, normalizeReference(ref))
+	}
+	return img.ID
+}
+
+// addImage adds a new image without tags. Images without config run a
+// shell, like most base images. The caller must hold mu.
+func (d *Daemon) addImage(parent string, config *container.Config, comment, createdBy string) *imageRecord {
+	if config == nil {
+		config = &container.Config{Cmd: strslice.StrSlice{"sh"}}
+	}
+	img := &imageRecord{
+		ImageInspect: types.ImageInspect{
+			ID:            "sha256:" + generateID(),
+			RepoTags:      []string{},
+			RepoDigests:   []string{},
+			Parent:        parent,
+			Comment:       comment,
+			Created:       now().Format(time.RFC3339Nano),
+			DockerVersion: d.version().Version,
+			Config:        config,
+			Architecture:  "amd64",
+			Os:            "linux",
+			Size:          defaultImageSize,
+			VirtualSize:   defaultImageSize,
+			GraphDriver:   types.GraphDriverData{Name: "fake"},
+			RootFS:        types.RootFS{Type: "layers"},
+		},
+		createdBy: createdBy,
+	}
+	d.images[img.ID] = img
+	return img
+}
+
+// tagImage adds the tag to the image, removing it from the image that had it.
+// The caller must hold mu.
+func (d *Daemon) tagImage(img *imageRecord, ref string) {
+	for _, other := range d.images {
+		other.RepoTags = removeString(other.RepoTags, ref)
+	}
+	img.RepoTags = append(img.RepoTags, ref)
+	sort.Strings(img.RepoTags)
+	d.logEvent(events.ImageEventType, "tag", img.ID, map[string]string{"name": ref})
+}
+
+// lookupImage finds an image by reference, ID or ID prefix.
+// The caller must hold mu.
+func (d *Daemon) lookupImage(ref string) (*imageRecord, error) {
+	if img, ok := d.images[ref]; ok {
+		return img, nil
+	}
+	if img, ok := d.images["sha256:"+ref]; ok {
+		return img, nil
+	}
+
+	normalized := normalizeReference(ref)
+	for _, img := range d.images {
+		for _, tag := range img.RepoTags {
+			if tag == normalized {
+				return img, nil
+			}
+		}
+		for _, digest := range img.RepoDigests {
+			if digest == normalized {
+				return img, nil
+			}
+		}
+	}
+
+	var found *imageRecord
+	id := strings.TrimPrefix(ref, "sha256:")
+	if len(id) > 0 && isHex(id) {
+		for key, img := range d.images {
+			if strings.HasPrefix(strings.TrimPrefix(key, "sha256:"), id) {
+				if found != nil {
+					return nil, errBadRequest("multiple IDs found with provided prefix: %s", ref)
+				}
+				found = img
+			}
+		}
+	}
+	if found == nil {
+		return nil, errNotFound("No such image: %s", ref)
+	}
+	return found, nil
+}
+
+// imageSummary returns the image as it's listed.
+func imageSummary(img *imageRecord) types.Image {
+	created, _ := time.Parse(time.RFC3339Nano, img.Created)
+	var labels map[string]string
+	if img.Config != nil {
+		labels = img.Config.Labels
+	}
+	repoTags := img.RepoTags
+	if len(repoTags) == 0 {
+		repoTags = []string{"<none>:<none>"}
+	}
+	repoDigests := img.RepoDigests
+	if len(repoDigests) == 0 {
+		repoDigests = []string{"<none>@<none>"}
+	}
+	return types.Image{
+		ID:          img.ID,
+		ParentID:    img.Parent,
+		RepoTags:    repoTags,
+		RepoDigests: repoDigests,
+		Created:     created.Unix(),
+		Size:        img.Size,
+		VirtualSize: img.VirtualSize,
+		Labels:      labels,
+	}
+}
+
+// sortedImages returns the images from the newest to the oldest.
+// The caller must hold mu.
+func (d *Daemon) sortedImages() []*imageRecord {
+	images := make([]*imageRecord, 0, len(d.images))
+	for _, img := range d.images {
+		images = append(images, img)
+	}
+	sort.Sort(imagesByCreated(images))
+	return images
+}
+
+// imagesByCreated sorts images from the newest to the oldest.
+type imagesByCreated []*imageRecord
+
+func (s imagesByCreated) Len() int           { return len(s) }
+func (s imagesByCreated) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s imagesByCreated) Less(i, j int) bool { return s[i].Created > s[j].Created }
+
+func (d *Daemon) getImages(w http.ResponseWriter, r *http.Request, vars []string) error {
+	args, err := filters.FromParam(r.URL.Query().Get("filters"))
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+	all := boolValue(r, "all")
+	matchName := r.URL.Query().Get("filter")
+
+	d.mu.Lock()
+	parents := make(map[string]bool)
+	for _, img := range d.images {
+		parents[img.Parent] = true
+	}
+	var images []types.Image
+	for _, img := range d.sortedImages() {
+		if !all && len(img.RepoTags) == 0 && parents[img.ID] {
+			// Intermediate images are only listed with all.
+			continue
+		}
+		if matchName != "" && !matchRepository(img.RepoTags, matchName) {
+			continue
+		}
+		images = append(images, imageSummary(img))
+	}
+	d.mu.Unlock()
+
+	images, err = client.FilterImages(args, images)
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+	if images == nil {
+		images = []types.Image{}
+	}
+	return writeJSON(w, http.StatusOK, images)
+}
+
+// matchRepository returns true if a repository of the tags matches the pattern.
+func matchRepository(tags []string, pattern string) bool {
+	for _, tag := range tags {
+		if ok, _ := path.Match(pattern, repositoryName(tag)); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Daemon) getImage(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	img, err := d.lookupImage(vars[0])
+	var inspect types.ImageInspect
+	if err == nil {
+		inspect = img.ImageInspect
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, inspect)
+}
+
+func (d *Daemon) getImageHistory(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	img, err := d.lookupImage(vars[0])
+	if err != nil {
+		return err
+	}
+	var history []types.ImageHistory
+	for img != nil {
+		created, _ := time.Parse(time.RFC3339Nano, img.Created)
+		history = append(history, types.ImageHistory{
+			ID:        img.ID,
+			Created:   created.Unix(),
+			CreatedBy: img.createdBy,
+			Tags:      img.RepoTags,
+			Size:      img.Size,
+			Comment:   img.Comment,
+		})
+		img = d.images[img.Parent]
+	}
+	return writeJSON(w, http.StatusOK, history)
+}
+
+func (d *Daemon) postImageTag(w http.ResponseWriter, r *http.Request, vars []string) error {
+	repo := r.URL.Query().Get("repo")
+	tag := r.URL.Query().Get("tag")
+	if repo == "" {
+		return errBadRequest("repository name must have at least one component")
+	}
+	ref := repo
+	if tag != "" {
+		ref += ":" + tag
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	img, err := d.lookupImage(vars[0])
+	if err != nil {
+		return err
+	}
+	d.tagImage(img, normalizeReference(ref))
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+func (d *Daemon) deleteImage(w http.ResponseWriter, r *http.Request, vars []string) error {
+	name := vars[0]
+	force := boolValue(r, "force")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	img, err := d.lookupImage(name)
+	if err != nil {
+		return err
+	}
+
+	normalized := normalizeReference(name)
+	byTag := false
+	for _, tag := range img.RepoTags {
+		if tag == normalized {
+			byTag = true
+		}
+	}
+
+	var deleted []types.ImageDelete
+	if byTag && len(img.RepoTags) > 1 {
+		// The image has other tags, only this one is removed.
+		img.RepoTags = removeString(img.RepoTags, normalized)
+		d.logEvent(events.ImageEventType, "untag", img.ID, map[string]string{"name": normalized})
+		return writeJSON(w, http.StatusOK, []types.ImageDelete{{Untagged: normalized}})
+	}
+	if !byTag && len(img.RepoTags) > 1 && !force {
+		return errConflict("conflict: unable to delete %s (must be forced) - image is referenced in multiple repositories", shortID(img.ID))
+	}
+	for _, c := range d.containers {
+		if c.Image == img.ID && !force {
+			return errConflict("conflict: unable to delete %s (must be forced) - image is being used by stopped container %s", shortID(img.ID), shortID(c.ID))
+		}
+		if c.Image == img.ID && c.State.Running {
+			return errConflict("conflict: unable to delete %s (cannot be forced) - image is being used by running container %s", shortID(img.ID), shortID(c.ID))
+		}
+	}
+	for _, other := range d.images {
+		if other.Parent == img.ID && !force {
+			return errConflict("conflict: unable to delete %s (cannot be forced) - image has dependent child images", shortID(img.ID))
+		}
+	}
+
+	for _, tag := range img.RepoTags {
+		deleted = append(deleted, types.ImageDelete{Untagged: tag})
+		d.logEvent(events.ImageEventType, "untag", img.ID, map[string]string{"name": tag})
+	}
+	deleted = append(deleted, d.deleteUntaggedImages(img)...)
+	return writeJSON(w, http.StatusOK, deleted)
+}
+
+// deleteUntaggedImages deletes the image and its parents that have no tags
+// and that aren't used by other images or containers.
+// The caller must hold mu.
+func (d *Daemon) deleteUntaggedImages(img *imageRecord) []types.ImageDelete {
+	var deleted []types.ImageDelete
+	for img != nil {
+		delete(d.images, img.ID)
+		d.logEvent(events.ImageEventType, "delete", img.ID, map[string]string{"name": img.ID})
+		deleted = append(deleted, types.ImageDelete{Deleted: img.ID})
+
+		parent, ok := d.images[img.Parent]
+		if !ok || len(parent.RepoTags) > 0 || d.imageInUse(parent.ID) {
+			break
+		}
+		img = parent
+	}
+	return deleted
+}
+
+// imageInUse returns true if a container or another image is based on the image.
+// The caller must hold mu.
+func (d *Daemon) imageInUse(id string) bool {
+	for _, c := range d.containers {
+		if c.Image == id {
+			return true
+		}
+	}
+	for _, other := range d.images {
+		if other.Parent == id {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonStream writes the JSON messages streamed to pull, push, build, load and import images.
+type jsonStream struct {
+	w   http.ResponseWriter
+	enc *json.Encoder
+}
+
+// newJSONStream writes the header of the response and returns the stream.
+func newJSONStream(w http.ResponseWriter) *jsonStream {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return &jsonStream{w: w, enc: json.NewEncoder(w)}
+}
+
+// send writes a message to the stream.
+func (s *jsonStream) send(msg types.JSONMessage) {
+	s.enc.Encode(msg)
+	flush(s.w)
+}
+
+// sendAux writes a message with additional data, like the result of a build.
+func (s *jsonStream) sendAux(v interface{}) {
+	b, _ := json.Marshal(v)
+	raw := json.RawMessage(b)
+	s.send(types.JSONMessage{Aux: &raw})
+}
+
+// sendError writes a message that reports that the operation failed.
+func (s *jsonStream) sendError(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	s.send(types.JSONMessage{Error: &types.JSONError{Message: message}, ErrorMessage: message})
+}
+
+// postImagesCreate pulls images, when fromImage is set,
+// or imports them, when fromSrc is set.
+func (d *Daemon) postImagesCreate(w http.ResponseWriter, r *http.Request, vars []string) error {
+	query := r.URL.Query()
+	if src := query.Get("fromSrc"); src != "" {
+		return d.importImage(w, r)
+	}
+
+	image := query.Get("fromImage")
+	if image == "" {
+		return errBadRequest("fromImage or fromSrc must be set")
+	}
+	ref := image
+	if tag := query.Get("tag"); tag != "" {
+		if strings.HasPrefix(tag, "sha256:") {
+			ref += "@" + tag
+		} else {
+			ref += ":" + tag
+		}
+	}
+	ref = normalizeReference(ref)
+	layer := shortID(generateID())
+
+	d.mu.Lock()
+	img, err := d.lookupImage(ref)
+	upToDate := err == nil
+	if !upToDate {
+		img = d.addImage("", nil, "", "")
+		if strings.Contains(ref, "@") {
+			img.RepoDigests = append(img.RepoDigests, ref)
+		} else {
+			d.tagImage(img, ref)
+		}
+	}
+	d.logEvent(events.ImageEventType, "pull", ref, map[string]string{"name": ref})
+	d.mu.Unlock()
+
+	s := newJSONStream(w)
+	repo := repositoryName(ref)
+	s.send(types.JSONMessage{Status: "Pulling from " + repo, ID: strings.TrimPrefix(ref, repo+":")})
+	if upToDate {
+		s.send(types.JSONMessage{Status: "Status: Image is up to date for " + ref})
+		return nil
+	}
+	s.send(types.JSONMessage{Status: "Pulling fs layer", ID: layer})
+	s.send(types.JSONMessage{
+		Status:   "Downloading",
+		ID:       layer,
+		Progress: &types.JSONProgress{Current: defaultImageSize, Total: defaultImageSize},
+	})
+	s.send(types.JSONMessage{Status: "Pull complete", ID: layer})
+	s.send(types.JSONMessage{Status: "Digest: " + img.ID})
+	s.send(types.JSONMessage{Status: "Status: Downloaded newer image for " + ref})
+	return nil
+}
+
+// importImage creates an image from the content sent in the request body,
+// or from an URL.
+func (d *Daemon) importImage(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	if query.Get("fromSrc") == "-" {
+		if _, err := io.Copy(ioutil.Discard, r.Body); err != nil {
+			return err
+		}
+	}
+
+	d.mu.Lock()
+	img := d.addImage("", nil, query.Get("message"), "import "+query.Get("fromSrc"))
+	if repo := query.Get("repo"); repo != "" {
+		ref := repo
+		if tag := query.Get("tag"); tag != "" {
+			ref += ":" + tag
+		}
+		d.tagImage(img, normalizeReference(ref))
+	}
+	d.logEvent(events.ImageEventType, "import", img.ID, map[string]string{"name": img.ID})
+	d.mu.Unlock()
+
+	newJSONStream(w).send(types.JSONMessage{Status: img.ID})
+	return nil
+}
+
+func (d *Daemon) postImagePush(w http.ResponseWriter, r *http.Request, vars []string) error {
+	repo := normalizeReference(vars[0])
+	repo = repositoryName(repo)
+	tag := r.URL.Query().Get("tag")
+
+	d.mu.Lock()
+	var refs []string
+	images := make(map[string]*imageRecord)
+	for _, img := range d.images {
+		for _, ref := range img.RepoTags {
+			if repositoryName(ref) == repo && (tag == "" || ref == repo+":"+tag) {
+				refs = append(refs, ref)
+				images[ref] = img
+			}
+		}
+	}
+	for _, ref := range refs {
+		d.logEvent(events.ImageEventType, "push", ref, map[string]string{"name": ref})
+	}
+	d.mu.Unlock()
+
+	if len(refs) == 0 {
+		if tag != "" {
+			repo += ":" + tag
+		}
+		return errNotFound("An image does not exist locally with the tag: %s", repo)
+	}
+	sort.Strings(refs)
+
+	s := newJSONStream(w)
+	s.send(types.JSONMessage{Status: "The push refers to a repository [" + repo + "]"})
+	for _, ref := range refs {
+		img := images[ref]
+		layer := shortID(img.ID)
+		t := strings.TrimPrefix(ref, repo+":")
+		s.send(types.JSONMessage{Status: "Preparing", ID: layer})
+		s.send(types.JSONMessage{Status: "Pushed", ID: layer})
+		s.send(types.JSONMessage{Status: fmt.Sprintf("%s: digest: %s size: %d", t, img.ID, img.Size)})
+		s.sendAux(types.PushResult{Tag: t, Digest: img.ID, Size: int(img.Size)})
+	}
+	return nil
+}
+
+func (d *Daemon) getImagesSearch(w http.ResponseWriter, r *http.Request, vars []string) error {
+	term := r.URL.Query().Get("term")
+	d.mu.Lock()
+	repos := make(map[string]bool)
+	for _, img := range d.images {
+		for _, ref := range img.RepoTags {
+			if name := repositoryName(ref); strings.Contains(name, term) {
+				repos[name] = true
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	results := []registry.SearchResult{}
+	for name := range repos {
+		results = append(results, registry.SearchResult{
+			Name:       name,
+			IsOfficial: !strings.Contains(name, "/"),
+		})
+	}
+	sort.Sort(searchResultsByName(results))
+	return writeJSON(w, http.StatusOK, results)
+}
+
+// searchResultsByName sorts search results by name.
+type searchResultsByName []registry.SearchResult
+
+func (s searchResultsByName) Len() int           { return len(s) }
+func (s searchResultsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s searchResultsByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// savedManifest is an entry of the manifest of the saved images.
+type savedManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// getImagesGet saves the images in a tar archive, with a manifest.json file
+// that lists them and a configuration file for each one of them.
+func (d *Daemon) getImagesGet(w http.ResponseWriter, r *http.Request, vars []string) error {
+	names := r.URL.Query()["names"]
+
+	d.mu.Lock()
+	var saved []imageRecord
+	for _, name := range names {
+		img, err := d.lookupImage(name)
+		if err != nil {
+			d.mu.Unlock()
+			return err
+		}
+		saved = append(saved, *img)
+	}
+	d.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.WriteHeader(http.StatusOK)
+	tw := tar.NewWriter(w)
+
+	var manifest []savedManifest
+	for _, img := range saved {
+		config, err := json.Marshal(img.ImageInspect)
+		if err != nil {
+			return nil
+		}
+		name := strings.TrimPrefix(img.ID, "sha256:") + ".json"
+		if err := writeTarFile(tw, name, config); err != nil {
+			return nil
+		}
+		manifest = append(manifest, savedManifest{Config: name, RepoTags: img.RepoTags, Layers: []string{}})
+	}
+	b, _ := json.Marshal(manifest)
+	writeTarFile(tw, "manifest.json", b)
+	tw.Close()
+	return nil
+}
+
+// writeTarFile writes a regular file to the tar archive.
+func writeTarFile(tw *tar.Writer, name string, content []byte) error {
+	hdr := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		ModTime:  now(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+// postImagesLoad loads the images saved with getImagesGet.
+func (d *Daemon) postImagesLoad(w http.ResponseWriter, r *http.Request, vars []string) error {
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errBadRequest("invalid archive: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errBadRequest("invalid archive: %v", err)
+		}
+		files[path.Clean(hdr.Name)] = b
+	}
+
+	var manifest []savedManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		return errBadRequest("invalid manifest.json: %v", err)
+	}
+
+	var loaded []string
+	d.mu.Lock()
+	for _, m := range manifest {
+		var inspect types.ImageInspect
+		if err := json.Unmarshal(files[path.Clean(m.Config)], &inspect); err != nil || inspect.ID == "" {
+			d.mu.Unlock()
+			return errBadRequest("invalid image configuration %s", m.Config)
+		}
+		img, ok := d.images[inspect.ID]
+		if !ok {
+			inspect.RepoTags = []string{}
+			img = &imageRecord{ImageInspect: inspect}
+			d.images[img.ID] = img
+		}
+		for _, ref := range m.RepoTags {
+			d.tagImage(img, ref)
+			loaded = append(loaded, "Loaded image: "+ref)
+		}
+		if len(m.RepoTags) == 0 {
+			loaded = append(loaded, "Loaded image ID: "+img.ID)
+		}
+		d.logEvent(events.ImageEventType, "load", img.ID, map[string]string{"name": img.ID})
+	}
+	d.mu.Unlock()
+
+	s := newJSONStream(w)
+	for _, l := range loaded {
+		s.send(types.JSONMessage{Stream: l + "\n"})
+	}
+	return nil
+}
+
+// postCommit creates an image from a container.
+func (d *Daemon) postCommit(w http.ResponseWriter, r *http.Request, vars []string) error {
+	query := r.URL.Query()
+	var config container.Config
+	if err := decodeBody(r, &config); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, err := d.lookupContainer(query.Get("container"))
+	if err != nil {
+		return err
+	}
+	merged := *c.Config
+	if config.Cmd != nil {
+		merged.Cmd = config.Cmd
+	}
+	if config.Labels != nil {
+		merged.Labels = config.Labels
+	}
+	if config.Env != nil {
+		merged.Env = config.Env
+	}
+
+	img := d.addImage(c.Image, &merged, query.Get("comment"), "commit "+c.ID)
+	img.Author = query.Get("author")
+	img.Container = c.ID
+	img.ContainerConfig = c.Config
+	if repo := query.Get("repo"); repo != "" {
+		ref := repo
+		if tag := query.Get("tag"); tag != "" {
+			ref += ":" + tag
+		}
+		d.tagImage(img, normalizeReference(ref))
+	}
+	d.logEvent(events.ContainerEventType, "commit", c.ID, d.containerAttributes(c))
+	return writeJSON(w, http.StatusCreated, types.ContainerCommitResponse{ID: img.ID})
+}
+
+// removeString returns the list without the value.
+func removeString(list []string, value string) []string {
+	var result []string
+	for _, v := range list {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	if result == nil {
+		return []string{}
+	}
+	return result
+}
+
+// isHex returns true if the string only has hexadecimal digits.
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
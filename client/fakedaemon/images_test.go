@@ -0,0 +1,158 @@
+package fakedaemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"golang.org/x/net/context"
+)
+
+func TestImagePullAndList(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	ctx := context.Background()
+
+	r, err := cli.ImagePull(ctx, "docker.io/library/busybox:latest", types.ImagePullOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Downloaded newer image for busybox:latest") {
+		t.Fatalf("unexpected pull output %s", b)
+	}
+
+	images, err := cli.ImageList(ctx, types.ImageListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 || images[0].RepoTags[0] != "busybox:latest" {
+		t.Fatalf("expected the pulled image, got %+v", images)
+	}
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, "busybox", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspect.ID != images[0].ID {
+		t.Fatalf("expected image %s, got %s", images[0].ID, inspect.ID)
+	}
+	if _, _, err := cli.ImageInspectWithRaw(ctx, "missing", false); !client.IsErrImageNotFound(err) {
+		t.Fatalf("expected an image not found error, got %v", err)
+	}
+}
+
+func TestImageRemove(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	id := d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	if _, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.ImageRemove(ctx, "busybox", types.ImageRemoveOptions{}); err == nil {
+		t.Fatal("expected an error removing an image used by a container")
+	}
+	if err := cli.ContainerRemove(ctx, "test", types.ContainerRemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := cli.ImageRemove(ctx, "busybox", types.ImageRemoveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 2 || deleted[0].Untagged != "busybox:latest" || deleted[1].Deleted != id {
+		t.Fatalf("unexpected deleted images %+v", deleted)
+	}
+}
+
+func TestImageBuild(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	dockerfile := []byte("FROM busybox\nENV A=1\nLABEL a=b\nRUN echo hello\nCMD [\"top\"]\n")
+	tw.WriteHeader(&tar.Header{Name: "Dockerfile", Mode: 0644, Size: int64(len(dockerfile))})
+	tw.Write(dockerfile)
+	tw.Close()
+
+	resp, err := cli.ImageBuild(ctx, &buf, types.ImageBuildOptions{
+		Tags:   []string{"built:1"},
+		Labels: map[string]string{"c": "d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Step 5 : CMD [\\\"top\\\"]") || !strings.Contains(string(b), "Successfully built") {
+		t.Fatalf("unexpected build output %s", b)
+	}
+
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, "built:1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := inspect.Config
+	if len(config.Cmd) != 1 || config.Cmd[0] != "top" || config.Labels["a"] != "b" || config.Labels["c"] != "d" || config.Env[0] != "A=1" {
+		t.Fatalf("unexpected config of the built image %+v", config)
+	}
+
+	history, err := cli.ImageHistory(ctx, "built:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 6 || history[2].CreatedBy != "/bin/sh -c echo hello" {
+		t.Fatalf("unexpected history %+v", history)
+	}
+}
+
+func TestImageSaveAndLoad(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	id := d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	r, err := cli.ImageSave(ctx, []string{"busybox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, otherCli := newTestDaemon(t)
+	defer other.Close()
+	resp, err := otherCli.ImageLoad(ctx, bytes.NewReader(saved), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(b), "Loaded image: busybox:latest") {
+		t.Fatalf("unexpected load output %s", b)
+	}
+	inspect, _, err := otherCli.ImageInspectWithRaw(ctx, "busybox", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspect.ID != id {
+		t.Fatalf("expected image %s, got %s", id, inspect.ID)
+	}
+}
@@ -0,0 +1,315 @@
+package fakedaemon
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"github.com/docker/engine-api/types/network"
+)
+
+// predefinedDrivers are the drivers of the networks created by the daemon.
+var predefinedDrivers = map[string]string{"bridge": "bridge", "host": "host", "none": "null"}
+
+// networkRecord holds the state of a network.
+type networkRecord struct {
+	types.NetworkResource
+	// predefined is true for the networks created by the daemon,
+	// which can't be removed.
+	predefined bool
+	// subnet is the second byte of the addresses of the network,
+	// zero if the network doesn't allocate addresses.
+	subnet int
+	// lastHost is the last byte of the last address allocated.
+	lastHost int
+}
+
+// addNetwork adds a network with the options. The caller must hold mu.
+func (d *Daemon) addNetwork(id, name string, options types.NetworkCreate, predefined bool) *networkRecord {
+	driver := options.Driver
+	if predefined {
+		driver = predefinedDrivers[name]
+	}
+	if driver == "" {
+		driver = "bridge"
+	}
+	n := &networkRecord{
+		NetworkResource: types.NetworkResource{
+			Name:       name,
+			ID:         id,
+			Scope:      "local",
+			Driver:     driver,
+			EnableIPv6: options.EnableIPv6,
+			IPAM:       options.IPAM,
+			Internal:   options.Internal,
+			Containers: make(map[string]types.EndpointResource),
+			Options:    options.Options,
+			Labels:     options.Labels,
+		},
+		predefined: predefined,
+	}
+	if n.IPAM.Driver == "" {
+		n.IPAM.Driver = "default"
+	}
+	if n.Options == nil {
+		n.Options = map[string]string{}
+	}
+	if driver == "bridge" && len(n.IPAM.Config) == 0 {
+		d.subnets++
+		n.subnet = 16 + d.subnets
+		n.IPAM.Config = []network.IPAMConfig{{
+			Subnet:  fmt.Sprintf("172.%d.0.0/16", n.subnet),
+			Gateway: fmt.Sprintf("172.%d.0.1", n.subnet),
+		}}
+	}
+	d.networks[id] = n
+	return n
+}
+
+// lookupNetwork finds a network by ID, name or ID prefix.
+// The caller must hold mu.
+func (d *Daemon) lookupNetwork(name string) (*networkRecord, error) {
+	if n, ok := d.networks[name]; ok {
+		return n, nil
+	}
+	for _, n := range d.networks {
+		if n.Name == name {
+			return n, nil
+		}
+	}
+	var found *networkRecord
+	for id, n := range d.networks {
+		if name != "" && strings.HasPrefix(id, name) {
+			if found != nil {
+				return nil, errBadRequest("network %s is ambiguous", name)
+			}
+			found = n
+		}
+	}
+	if found == nil {
+		return nil, errNotFound("network %s not found", name)
+	}
+	return found, nil
+}
+
+// connectContainer connects the container to the network, allocating
+// an address for it in the networks that have a subnet.
+// The caller must hold mu.
+func (d *Daemon) connectContainer(n *networkRecord, c *containerRecord, config *network.EndpointSettings) {
+	endpoint := &network.EndpointSettings{
+		NetworkID:  n.ID,
+		EndpointID: generateID(),
+	}
+	if config != nil {
+		endpoint.IPAMConfig = config.IPAMConfig
+		endpoint.Links = config.Links
+		endpoint.Aliases = config.Aliases
+	}
+	if n.subnet > 0 {
+		n.lastHost++
+		host := n.lastHost + 1
+		endpoint.IPAddress = fmt.Sprintf("172.%d.0.%d", n.subnet, host)
+		endpoint.IPPrefixLen = 16
+		endpoint.Gateway = fmt.Sprintf("172.%d.0.1", n.subnet)
+		endpoint.MacAddress = fmt.Sprintf("02:42:ac:%02x:00:%02x", n.subnet, host)
+	}
+	if endpoint.IPAMConfig != nil && endpoint.IPAMConfig.IPv4Address != "" {
+		endpoint.IPAddress = endpoint.IPAMConfig.IPv4Address
+	}
+
+	c.NetworkSettings.Networks[n.Name] = endpoint
+	if n.Name == "bridge" {
+		c.NetworkSettings.Bridge = "docker0"
+		c.NetworkSettings.DefaultNetworkSettings = types.DefaultNetworkSettings{
+			EndpointID:  endpoint.EndpointID,
+			Gateway:     endpoint.Gateway,
+			IPAddress:   endpoint.IPAddress,
+			IPPrefixLen: endpoint.IPPrefixLen,
+			MacAddress:  endpoint.MacAddress,
+		}
+	}
+
+	resource := types.EndpointResource{
+		Name:       c.name(),
+		EndpointID: endpoint.EndpointID,
+		MacAddress: endpoint.MacAddress,
+	}
+	if endpoint.IPAddress != "" {
+		resource.IPv4Address = fmt.Sprintf("%s/%d", endpoint.IPAddress, endpoint.IPPrefixLen)
+	}
+	n.Containers[c.ID] = resource
+	d.logEvent(events.NetworkEventType, "connect", n.ID, map[string]string{
+		"name":      n.Name,
+		"type":      n.Driver,
+		"container": c.ID,
+	})
+}
+
+// networkResource returns a copy of the network that can be sent
+// without holding mu.
+func networkResource(n *networkRecord) types.NetworkResource {
+	resource := n.NetworkResource
+	resource.Containers = make(map[string]types.EndpointResource, len(n.Containers))
+	for id, e := range n.Containers {
+		resource.Containers[id] = e
+	}
+	return resource
+}
+
+// networksByName sorts networks by name.
+type networksByName []types.NetworkResource
+
+func (s networksByName) Len() int           { return len(s) }
+func (s networksByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s networksByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+func (d *Daemon) getNetworks(w http.ResponseWriter, r *http.Request, vars []string) error {
+	args, err := filters.FromParam(r.URL.Query().Get("filters"))
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+
+	d.mu.Lock()
+	var list []types.NetworkResource
+	for _, n := range d.networks {
+		list = append(list, networkResource(n))
+	}
+	d.mu.Unlock()
+	sort.Sort(networksByName(list))
+
+	list, err = client.FilterNetworks(args, list)
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+	if list == nil {
+		list = []types.NetworkResource{}
+	}
+	return writeJSON(w, http.StatusOK, list)
+}
+
+func (d *Daemon) postNetworksCreate(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var create types.NetworkCreateRequest
+	if err := decodeBody(r, &create); err != nil {
+		return err
+	}
+	if create.Name == "" {
+		return errBadRequest("network name is required")
+	}
+	if _, ok := predefinedDrivers[create.Name]; ok {
+		return errForbidden("%s is a pre-defined network and cannot be created", create.Name)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var warning string
+	for _, n := range d.networks {
+		if n.Name != create.Name {
+			continue
+		}
+		if create.CheckDuplicate {
+			return errConflict("network with name %s already exists", create.Name)
+		}
+		warning = fmt.Sprintf("Network with name %s (id : %s) already exists", n.Name, n.ID)
+	}
+	n := d.addNetwork(generateID(), create.Name, create.NetworkCreate, false)
+	d.logEvent(events.NetworkEventType, "create", n.ID, map[string]string{"name": n.Name, "type": n.Driver})
+	return writeJSON(w, http.StatusCreated, types.NetworkCreateResponse{ID: n.ID, Warning: warning})
+}
+
+func (d *Daemon) getNetwork(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	n, err := d.lookupNetwork(vars[0])
+	var resource types.NetworkResource
+	if err == nil {
+		resource = networkResource(n)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, resource)
+}
+
+func (d *Daemon) postNetworkConnect(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var connect types.NetworkConnect
+	if err := decodeBody(r, &connect); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, err := d.lookupNetwork(vars[0])
+	if err != nil {
+		return err
+	}
+	c, err := d.lookupContainer(connect.Container)
+	if err != nil {
+		return err
+	}
+	if _, ok := n.Containers[c.ID]; ok {
+		return errForbidden("endpoint with name %s already exists in network %s", c.name(), n.Name)
+	}
+	if n.predefined && n.Name != "bridge" {
+		return errForbidden("container cannot be disconnected from host network or connected to host network")
+	}
+	d.connectContainer(n, c, connect.EndpointConfig)
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (d *Daemon) postNetworkDisconnect(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var disconnect types.NetworkDisconnect
+	if err := decodeBody(r, &disconnect); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, err := d.lookupNetwork(vars[0])
+	if err != nil {
+		return err
+	}
+	c, err := d.lookupContainer(disconnect.Container)
+	if err != nil {
+		return err
+	}
+	if _, ok := n.Containers[c.ID]; !ok {
+		return errForbidden("container %s is not connected to the network %s", c.ID, n.Name)
+	}
+	delete(n.Containers, c.ID)
+	delete(c.NetworkSettings.Networks, n.Name)
+	if n.Name == "bridge" {
+		c.NetworkSettings.DefaultNetworkSettings = types.DefaultNetworkSettings{}
+	}
+	d.logEvent(events.NetworkEventType, "disconnect", n.ID, map[string]string{
+		"name":      n.Name,
+		"type":      n.Driver,
+		"container": c.ID,
+	})
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (d *Daemon) deleteNetwork(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, err := d.lookupNetwork(vars[0])
+	if err != nil {
+		return err
+	}
+	if n.predefined {
+		return errForbidden("%s is a pre-defined network and cannot be removed", n.Name)
+	}
+	if len(n.Containers) > 0 {
+		return errForbidden("error while removing network: network %s id %s has active endpoints", n.Name, n.ID)
+	}
+	delete(d.networks, n.ID)
+	d.logEvent(events.NetworkEventType, "destroy", n.ID, map[string]string{"name": n.Name, "type": n.Driver})
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
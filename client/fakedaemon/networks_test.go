@@ -0,0 +1,66 @@
+package fakedaemon
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+func TestNetworks(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	n, err := cli.NetworkCreate(ctx, "test", types.NetworkCreate{CheckDuplicate: true, Labels: map[string]string{"a": "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.NetworkCreate(ctx, "test", types.NetworkCreate{CheckDuplicate: true}); !client.IsErrConflict(err) {
+		t.Fatalf("expected a conflict creating a duplicate network, got %v", err)
+	}
+
+	args := filters.NewArgs()
+	args.Add("label", "a=b")
+	list, err := cli.NetworkList(ctx, types.NetworkListOptions{Filters: args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != n.ID {
+		t.Fatalf("expected the network with the label, got %+v", list)
+	}
+
+	c, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.NetworkConnect(ctx, "test", c.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	resource, err := cli.NetworkInspect(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := resource.Containers[c.ID]; !ok || e.IPv4Address != "172.18.0.2/16" {
+		t.Fatalf("expected the container in the network, got %+v", resource.Containers)
+	}
+	if err := cli.NetworkRemove(ctx, "test"); !client.IsErrForbidden(err) {
+		t.Fatalf("expected an error removing a network with endpoints, got %v", err)
+	}
+	if err := cli.NetworkDisconnect(ctx, "test", c.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.NetworkRemove(ctx, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.NetworkInspect(ctx, "test"); !client.IsErrNetworkNotFound(err) {
+		t.Fatalf("expected a network not found error, got %v", err)
+	}
+	if err := cli.NetworkRemove(ctx, "bridge"); !client.IsErrForbidden(err) {
+		t.Fatalf("expected an error removing a predefined network, got %v", err)
+	}
+}
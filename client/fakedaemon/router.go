@@ -0,0 +1,226 @@
+package fakedaemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// handlerFunc handles a request. The vars hold the values of the
+// variable parts of the route, in order.
+type handlerFunc func(w http.ResponseWriter, r *http.Request, vars []string) error
+
+// route associates a method and a path with a handler.
+type route struct {
+	method  string
+	parts   []string
+	handler handlerFunc
+}
+
+// newRoute creates a route for the path. A `*` part of the path matches
+// one part of the request path, a `**` part matches one or more parts,
+// like the references of the images with slashes in them.
+func newRoute(method, path string, handler handlerFunc) route {
+	return route{method: method, parts: splitPath(path), handler: handler}
+}
+
+// newRoutes returns the endpoints served by the daemon.
+func (d *Daemon) newRoutes() []route {
+	return []route{
+		newRoute("GET", "/_ping", d.getPing),
+		newRoute("GET", "/version", d.getVersion),
+		newRoute("GET", "/info", d.getInfo),
+		newRoute("POST", "/auth", d.postAuth),
+		newRoute("GET", "/events", d.getEvents),
+
+		newRoute("GET", "/containers/json", d.getContainers),
+		newRoute("POST", "/containers/create", d.postContainersCreate),
+		newRoute("GET", "/containers/*/json", d.getContainer),
+		newRoute("GET", "/containers/*/top", d.getContainerTop),
+		newRoute("GET", "/containers/*/changes", d.getContainerChanges),
+		newRoute("GET", "/containers/*/logs", d.getContainerLogs),
+		newRoute("GET", "/containers/*/stats", d.getContainerStats),
+		newRoute("GET", "/containers/*/export", d.getContainerExport),
+		newRoute("HEAD", "/containers/*/archive", d.headContainerArchive),
+		newRoute("GET", "/containers/*/archive", d.getContainerArchive),
+		newRoute("PUT", "/containers/*/archive", d.putContainerArchive),
+		newRoute("POST", "/containers/*/start", d.postContainerStart),
+		newRoute("POST", "/containers/*/stop", d.postContainerStop),
+		newRoute("POST", "/containers/*/restart", d.postContainerRestart),
+		newRoute("POST", "/containers/*/kill", d.postContainerKill),
+		newRoute("POST", "/containers/*/pause", d.postContainerPause),
+		newRoute("POST", "/containers/*/unpause", d.postContainerUnpause),
+		newRoute("POST", "/containers/*/rename", d.postContainerRename),
+		newRoute("POST", "/containers/*/resize", d.postContainerResize),
+		newRoute("POST", "/containers/*/update", d.postContainerUpdate),
+		newRoute("POST", "/containers/*/wait", d.postContainerWait),
+		newRoute("POST", "/containers/*/attach", d.postContainerAttach),
+		newRoute("POST", "/containers/*/exec", d.postContainerExec),
+		newRoute("DELETE", "/containers/*", d.deleteContainer),
+		newRoute("POST", "/commit", d.postCommit),
+
+		newRoute("GET", "/exec/*/json", d.getExec),
+		newRoute("POST", "/exec/*/start", d.postExecStart),
+		newRoute("POST", "/exec/*/resize", d.postExecResize),
+
+		newRoute("GET", "/images/json", d.getImages),
+		newRoute("GET", "/images/search", d.getImagesSearch),
+		newRoute("GET", "/images/get", d.getImagesGet),
+		newRoute("POST", "/images/create", d.postImagesCreate),
+		newRoute("POST", "/images/load", d.postImagesLoad),
+		newRoute("POST", "/build", d.postBuild),
+		newRoute("GET", "/images/**/json", d.getImage),
+		newRoute("GET", "/images/**/history", d.getImageHistory),
+		newRoute("POST", "/images/**/tag", d.postImageTag),
+		newRoute("POST", "/images/**/push", d.postImagePush),
+		newRoute("DELETE", "/images/**", d.deleteImage),
+
+		newRoute("GET", "/networks", d.getNetworks),
+		newRoute("POST", "/networks/create", d.postNetworksCreate),
+		newRoute("GET", "/networks/*", d.getNetwork),
+		newRoute("POST", "/networks/*/connect", d.postNetworkConnect),
+		newRoute("POST", "/networks/*/disconnect", d.postNetworkDisconnect),
+		newRoute("DELETE", "/networks/*", d.deleteNetwork),
+
+		newRoute("GET", "/volumes", d.getVolumes),
+		newRoute("POST", "/volumes/create", d.postVolumesCreate),
+		newRoute("GET", "/volumes/*", d.getVolume),
+		newRoute("DELETE", "/volumes/*", d.deleteVolume),
+	}
+}
+
+// splitPath splits a path in its parts, ignoring the empty ones.
+func splitPath(p string) []string {
+	var parts []string
+	for _, part := range strings.Split(p, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// matchRoute matches the parts of a request path with the parts of a route.
+// It returns the values of the variable parts of the route.
+func matchRoute(route, parts []string) ([]string, bool) {
+	if len(route) == 0 {
+		return nil, len(parts) == 0
+	}
+	if len(parts) == 0 {
+		return nil, false
+	}
+	switch route[0] {
+	case "*":
+		vars, ok := matchRoute(route[1:], parts[1:])
+		if !ok {
+			return nil, false
+		}
+		return append([]string{parts[0]}, vars...), true
+	case "**":
+		// The variable takes as few parts as possible, so the fixed
+		// parts at the end of the route match the end of the path.
+		for i := 1; i <= len(parts); i++ {
+			if vars, ok := matchRoute(route[1:], parts[i:]); ok {
+				return append([]string{strings.Join(parts[:i], "/")}, vars...), true
+			}
+		}
+		return nil, false
+	}
+	if route[0] != parts[0] {
+		return nil, false
+	}
+	return matchRoute(route[1:], parts[1:])
+}
+
+// apiError is an error returned to the client with an HTTP status code.
+type apiError struct {
+	statusCode int
+	message    string
+}
+
+// Error returns the message of the error.
+func (e apiError) Error() string {
+	return e.message
+}
+
+func newAPIError(statusCode int, format string, args ...interface{}) apiError {
+	return apiError{statusCode: statusCode, message: fmt.Sprintf(format, args...)}
+}
+
+func errBadRequest(format string, args ...interface{}) error {
+	return newAPIError(http.StatusBadRequest, format, args...)
+}
+
+func errNotFound(format string, args ...interface{}) error {
+	return newAPIError(http.StatusNotFound, format, args...)
+}
+
+func errConflict(format string, args ...interface{}) error {
+	return newAPIError(http.StatusConflict, format, args...)
+}
+
+func errForbidden(format string, args ...interface{}) error {
+	return newAPIError(http.StatusForbidden, format, args...)
+}
+
+func errNotModified() error {
+	return newAPIError(http.StatusNotModified, "")
+}
+
+// writeError writes the error in the JSON format used by the daemon.
+// Errors without a status code are internal errors of the daemon.
+func writeError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	if e, ok := err.(apiError); ok {
+		statusCode = e.statusCode
+	}
+	if statusCode == http.StatusNotModified {
+		w.WriteHeader(statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+}
+
+// writeJSON writes the value as a JSON document with the status code.
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// decodeBody decodes the JSON body of a request. An empty body leaves v unchanged.
+func decodeBody(r *http.Request, v interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && err != io.EOF {
+		return errBadRequest("%v", err)
+	}
+	return nil
+}
+
+// boolValue returns the value of a boolean query parameter,
+// like the daemon it accepts 1, true and their variants.
+func boolValue(r *http.Request, name string) bool {
+	s := strings.ToLower(strings.TrimSpace(r.URL.Query().Get(name)))
+	return !(s == "" || s == "0" || s == "no" || s == "false" || s == "none")
+}
+
+// intValue returns the value of an integer query parameter, or def if it's not set.
+func intValue(r *http.Request, name string, def int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errBadRequest("invalid value for %s: %q", name, s)
+	}
+	return v, nil
+}
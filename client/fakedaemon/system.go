@@ -0,0 +1,85 @@
+package fakedaemon
+
+import (
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/docker/engine-api/types"
+)
+
+// daemonID is the identifier reported by the daemon in its information.
+const daemonID = "FAKE:DAEMON:0000:0000:0000:0000:0000:0000:0000:0000:0000:0000"
+
+func (d *Daemon) getPing(w http.ResponseWriter, r *http.Request, vars []string) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write([]byte("OK"))
+	return err
+}
+
+func (d *Daemon) getVersion(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	v := d.version()
+	d.mu.Unlock()
+	return writeJSON(w, http.StatusOK, v)
+}
+
+func (d *Daemon) getInfo(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	info := types.Info{
+		ID:              daemonID,
+		Containers:      len(d.containers),
+		Images:          len(d.images),
+		Driver:          "fake",
+		NGoroutines:     runtime.NumGoroutine(),
+		SystemTime:      now().Format(time.RFC3339Nano),
+		LoggingDriver:   "json-file",
+		NEventsListener: len(d.subscribers),
+		KernelVersion:   "fake",
+		OperatingSystem: "fakedaemon",
+		OSType:          runtime.GOOS,
+		Architecture:    runtime.GOARCH,
+		NCPU:            runtime.NumCPU(),
+		DockerRootDir:   "/var/lib/docker",
+		Name:            "fakedaemon",
+		ServerVersion:   d.version().Version,
+	}
+	for _, c := range d.containers {
+		switch {
+		case c.State.Paused:
+			info.ContainersPaused++
+		case c.State.Running:
+			info.ContainersRunning++
+		default:
+			info.ContainersStopped++
+		}
+	}
+	for _, n := range d.networks {
+		info.Plugins.Network = appendUnique(info.Plugins.Network, n.Driver)
+	}
+	sort.Strings(info.Plugins.Network)
+	info.Plugins.Volume = []string{"local"}
+	d.mu.Unlock()
+	return writeJSON(w, http.StatusOK, info)
+}
+
+// postAuth accepts any credentials.
+func (d *Daemon) postAuth(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var config types.AuthConfig
+	if err := decodeBody(r, &config); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, types.AuthResponse{Status: "Login Succeeded"})
+}
+
+// appendUnique appends the value to the list if it's not in it yet.
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
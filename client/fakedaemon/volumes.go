@@ -0,0 +1,125 @@
+package fakedaemon
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+// createVolume creates a volume, or returns the volume with the
+// name if it exists. The caller must hold mu.
+func (d *Daemon) createVolume(name, driver string, labels map[string]string) *types.Volume {
+	if v, ok := d.volumes[name]; ok {
+		return v
+	}
+	if driver == "" {
+		driver = "local"
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	v := &types.Volume{
+		Name:       name,
+		Driver:     driver,
+		Mountpoint: "/var/lib/docker/volumes/" + name + "/_data",
+		Labels:     labels,
+	}
+	d.volumes[name] = v
+	d.logEvent(events.VolumeEventType, "create", name, map[string]string{"driver": driver})
+	return v
+}
+
+// volumeInUse returns true if a container mounts the volume.
+// The caller must hold mu.
+func (d *Daemon) volumeInUse(name string) bool {
+	for _, c := range d.containers {
+		for _, m := range c.Mounts {
+			if m.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// volumesByName sorts volumes by name.
+type volumesByName []*types.Volume
+
+func (s volumesByName) Len() int           { return len(s) }
+func (s volumesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s volumesByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+func (d *Daemon) getVolumes(w http.ResponseWriter, r *http.Request, vars []string) error {
+	args, err := filters.FromParam(r.URL.Query().Get("filters"))
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+
+	d.mu.Lock()
+	var volumes []*types.Volume
+	for _, v := range d.volumes {
+		copied := *v
+		volumes = append(volumes, &copied)
+	}
+	containers := d.listContainers()
+	d.mu.Unlock()
+	sort.Sort(volumesByName(volumes))
+
+	volumes, err = client.FilterVolumes(args, volumes, containers)
+	if err != nil {
+		return errBadRequest("%v", err)
+	}
+	if volumes == nil {
+		volumes = []*types.Volume{}
+	}
+	return writeJSON(w, http.StatusOK, types.VolumesListResponse{Volumes: volumes})
+}
+
+func (d *Daemon) postVolumesCreate(w http.ResponseWriter, r *http.Request, vars []string) error {
+	var create types.VolumeCreateRequest
+	if err := decodeBody(r, &create); err != nil {
+		return err
+	}
+	name := create.Name
+	if name == "" {
+		name = generateID()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if v, ok := d.volumes[name]; ok && create.Driver != "" && v.Driver != create.Driver {
+		return errConflict("a volume with the name %s already exists with the driver %s", name, v.Driver)
+	}
+	v := d.createVolume(name, create.Driver, create.Labels)
+	return writeJSON(w, http.StatusCreated, v)
+}
+
+func (d *Daemon) getVolume(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	v, ok := d.volumes[vars[0]]
+	if !ok {
+		return errNotFound("no such volume: %s", vars[0])
+	}
+	return writeJSON(w, http.StatusOK, v)
+}
+
+func (d *Daemon) deleteVolume(w http.ResponseWriter, r *http.Request, vars []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	v, ok := d.volumes[vars[0]]
+	if !ok {
+		return errNotFound("no such volume: %s", vars[0])
+	}
+	if d.volumeInUse(v.Name) {
+		return errConflict("unable to remove volume: remove %s: volume is in use", v.Name)
+	}
+	delete(d.volumes, v.Name)
+	d.logEvent(events.VolumeEventType, "destroy", v.Name, map[string]string{"driver": v.Driver})
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,51 @@
+package fakedaemon
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/filters"
+	"golang.org/x/net/context"
+)
+
+func TestVolumes(t *testing.T) {
+	d, cli := newTestDaemon(t)
+	defer d.Close()
+	d.AddImage("busybox", nil)
+	ctx := context.Background()
+
+	v, err := cli.VolumeCreate(ctx, types.VolumeCreateRequest{Name: "data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Driver != "local" || v.Mountpoint != "/var/lib/docker/volumes/data/_data" {
+		t.Fatalf("unexpected volume %+v", v)
+	}
+	if _, err := cli.ContainerCreate(ctx, &container.Config{Image: "busybox"}, &container.HostConfig{Binds: []string{"data:/data"}}, nil, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	args := filters.NewArgs()
+	args.Add("dangling", "true")
+	list, err := cli.VolumeList(ctx, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Volumes) != 0 {
+		t.Fatalf("expected no dangling volumes, got %+v", list.Volumes)
+	}
+	if err := cli.VolumeRemove(ctx, "data"); !client.IsErrConflict(err) {
+		t.Fatalf("expected an error removing a volume in use, got %v", err)
+	}
+	if err := cli.ContainerRemove(ctx, "test", types.ContainerRemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.VolumeRemove(ctx, "data"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.VolumeInspect(ctx, "data"); !client.IsErrVolumeNotFound(err) {
+		t.Fatalf("expected a volume not found error, got %v", err)
+	}
+}
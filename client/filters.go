@@ -0,0 +1,508 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+)
+
+var (
+	exitCodeRegexp = regexp.MustCompile(`^Exited \((-?\d+)\)`)
+	// builtinNetworks are the networks created by the daemon.
+	builtinNetworks = map[string]bool{"bridge": true, "host": true, "none": true, "nat": true}
+)
+
+// FilterContainers returns the containers that match the filters, with the
+// same semantics as the daemon. Older daemons ignore the filters they don't
+// know, FilterContainers applies them to the containers they return.
+//
+// The ancestor filter matches the image of the containers, but not the images
+// it's based on, which are not known by the client. The before and since
+// filters refer to containers in the list. The isolation filter can't be
+// evaluated with the containers in the list.
+func FilterContainers(args filters.Args, containers []types.Container) ([]types.Container, error) {
+	if err := args.Validate(filters.AcceptedKeys(filters.ContainerResource, "")); err != nil {
+		return nil, err
+	}
+	if args.Include("isolation") {
+		return nil, fmt.Errorf("Invalid filter 'isolation': the isolation of the containers is not listed")
+	}
+	if err := validateValues(args, "status", "created", "restarting", "running", "paused", "exited", "dead", "removing"); err != nil {
+		return nil, err
+	}
+	if err := validateValues(args, "health", "starting", "healthy", "unhealthy", "none"); err != nil {
+		return nil, err
+	}
+
+	exitCodes := map[int]bool{}
+	err := args.WalkValues("exited", func(value string) error {
+		code, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Invalid filter 'exited=%s'", value)
+		}
+		exitCodes[code] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := containerCreatedBound(args, "before", containers)
+	if err != nil {
+		return nil, err
+	}
+	since, err := containerCreatedBound(args, "since", containers)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []types.Container
+	for _, c := range containers {
+		if !args.Match("id", c.ID) ||
+			!args.Match("name", containerName(c)) ||
+			!args.MatchKVList("label", c.Labels) ||
+			!args.ExactMatch("status", containerState(c)) ||
+			!args.ExactMatch("health", containerHealth(c)) {
+			continue
+		}
+		if len(exitCodes) > 0 {
+			code, ok := containerExitCode(c)
+			if !ok || !exitCodes[code] {
+				continue
+			}
+		}
+		if before != nil && c.Created >= *before {
+			continue
+		}
+		if since != nil && c.Created <= *since {
+			continue
+		}
+		if args.Include("ancestor") && !matchAny(args.Get("ancestor"), func(ref string) bool {
+			return matchContainerImage(c, ref)
+		}) {
+			continue
+		}
+		if args.Include("volume") && !matchContainerVolume(args, c) {
+			continue
+		}
+		if args.Include("network") && !matchContainerNetwork(args, c) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	return matched, nil
+}
+
+// FilterImages returns the images that match the filters,
+// with the same semantics as the daemon, like FilterContainers.
+// The before and since filters refer to images in the list.
+func FilterImages(args filters.Args, images []types.Image) ([]types.Image, error) {
+	if err := args.Validate(filters.AcceptedKeys(filters.ImageResource, "")); err != nil {
+		return nil, err
+	}
+	dangling, err := danglingFilter(args)
+	if err != nil {
+		return nil, err
+	}
+	before, err := imageCreatedBound(args, "before", images)
+	if err != nil {
+		return nil, err
+	}
+	since, err := imageCreatedBound(args, "since", images)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []types.Image
+	for _, img := range images {
+		if dangling != nil && isDanglingImage(img) != *dangling {
+			continue
+		}
+		if !args.MatchKVList("label", img.Labels) {
+			continue
+		}
+		if before != nil && img.Created >= *before {
+			continue
+		}
+		if since != nil && img.Created <= *since {
+			continue
+		}
+		matched = append(matched, img)
+	}
+	return matched, nil
+}
+
+// FilterVolumes returns the volumes that match the filters, with the same
+// semantics as the daemon, like FilterContainers. The dangling filter uses
+// the containers to know the volumes in use, the ones they mount.
+func FilterVolumes(args filters.Args, volumes []*types.Volume, containers []types.Container) ([]*types.Volume, error) {
+	if err := args.Validate(filters.AcceptedKeys(filters.VolumeResource, "")); err != nil {
+		return nil, err
+	}
+	dangling, err := danglingFilter(args)
+	if err != nil {
+		return nil, err
+	}
+	inUse := map[string]bool{}
+	for _, c := range containers {
+		for _, m := range c.Mounts {
+			if m.Name != "" {
+				inUse[m.Name] = true
+			}
+		}
+	}
+
+	var matched []*types.Volume
+	for _, v := range volumes {
+		if dangling != nil && inUse[v.Name] == *dangling {
+			continue
+		}
+		if !args.Match("name", v.Name) || !args.ExactMatch("driver", v.Driver) || !args.MatchKVList("label", v.Labels) {
+			continue
+		}
+		matched = append(matched, v)
+	}
+	return matched, nil
+}
+
+// FilterNetworks returns the networks that match the filters,
+// with the same semantics as the daemon, like FilterContainers.
+func FilterNetworks(args filters.Args, networks []types.NetworkResource) ([]types.NetworkResource, error) {
+	if err := args.Validate(filters.AcceptedKeys(filters.NetworkResource, "")); err != nil {
+		return nil, err
+	}
+	if err := validateValues(args, "type", "custom", "builtin"); err != nil {
+		return nil, err
+	}
+
+	var matched []types.NetworkResource
+	for _, n := range networks {
+		networkType := "custom"
+		if builtinNetworks[n.Name] {
+			networkType = "builtin"
+		}
+		if !args.Match("id", n.ID) ||
+			!args.Match("name", n.Name) ||
+			!args.ExactMatch("type", networkType) ||
+			!args.ExactMatch("driver", n.Driver) ||
+			!args.MatchKVList("label", n.Labels) {
+			continue
+		}
+		matched = append(matched, n)
+	}
+	return matched, nil
+}
+
+// MatchEvent returns true if the event matches the filters, with the same
+// semantics as the daemon. The events sent by daemons older than API 1.22,
+// which only have a status, an id and an image, are container events.
+func MatchEvent(args filters.Args, event events.Message) (bool, error) {
+	if err := args.Validate(filters.AcceptedKeys(filters.EventResource, "")); err != nil {
+		return false, err
+	}
+
+	if event.Type == "" {
+		event = legacyEventMessage(event)
+	}
+	return args.ExactMatch("event", event.Action) &&
+		args.ExactMatch("type", event.Type) &&
+		matchEventActor(args, "daemon", event) &&
+		matchEventActor(args, events.ContainerEventType, event) &&
+		matchEventActor(args, events.VolumeEventType, event) &&
+		matchEventActor(args, events.NetworkEventType, event) &&
+		matchEventImage(args, event) &&
+		(!args.Include("label") || args.MatchKVList("label", event.Actor.Attributes)), nil
+}
+
+// legacyEventMessage converts an event sent by a daemon older than API 1.22.
+func legacyEventMessage(event events.Message) events.Message {
+	event.Type = events.ContainerEventType
+	event.Action = event.Status
+	event.Actor.ID = event.ID
+	if event.From != "" {
+		attributes := map[string]string{"image": event.From}
+		for k, v := range event.Actor.Attributes {
+			attributes[k] = v
+		}
+		event.Actor.Attributes = attributes
+	} else {
+		// Image events have no image in them.
+		event.Type = events.ImageEventType
+	}
+	return event
+}
+
+// matchEventActor matches the filter of the type of object with the id
+// or the name of the object that generated the event.
+func matchEventActor(args filters.Args, field string, event events.Message) bool {
+	return args.FuzzyMatch(field, event.Actor.ID) || args.FuzzyMatch(field, event.Actor.Attributes["name"])
+}
+
+// matchEventImage matches the image filter with the image events, and with
+// the events of the containers created from the image.
+func matchEventImage(args filters.Args, event events.Message) bool {
+	nameAttr := "image"
+	if event.Type == events.ImageEventType {
+		nameAttr = "name"
+	}
+	imageName := event.Actor.Attributes[nameAttr]
+	return args.ExactMatch("image", event.Actor.ID) ||
+		args.ExactMatch("image", imageName) ||
+		args.ExactMatch("image", stripImageTag(event.Actor.ID)) ||
+		args.ExactMatch("image", stripImageTag(imageName))
+}
+
+// validateValues returns an error if one of the values of the filter is not valid.
+func validateValues(args filters.Args, field string, valid ...string) error {
+	return args.WalkValues(field, func(value string) error {
+		for _, v := range valid {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid filter '%s=%s'", field, value)
+	})
+}
+
+// danglingFilter returns the value of the dangling filter, nil if it's not set.
+func danglingFilter(args filters.Args) (*bool, error) {
+	if !args.Include("dangling") {
+		return nil, nil
+	}
+	var dangling bool
+	if args.ExactMatch("dangling", "true") || args.ExactMatch("dangling", "1") {
+		dangling = true
+	} else if !args.ExactMatch("dangling", "false") && !args.ExactMatch("dangling", "0") {
+		return nil, fmt.Errorf("Invalid filter 'dangling=%s'", strings.Join(args.Get("dangling"), ","))
+	}
+	return &dangling, nil
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDanglingImage returns true if the image is not tagged.
+func isDanglingImage(img types.Image) bool {
+	for _, tag := range img.RepoTags {
+		if tag != "<none>:<none>" {
+			return false
+		}
+	}
+	return true
+}
+
+// containerName returns the name of the container, the other names in the list are links.
+func containerName(c types.Container) string {
+	for _, name := range c.Names {
+		if strings.Count(name, "/") == 1 {
+			return name
+		}
+	}
+	if len(c.Names) > 0 {
+		return c.Names[0]
+	}
+	return ""
+}
+
+// containerState returns the state of the container. Daemons older than API 1.23
+// don't send it, it's in the status.
+func containerState(c types.Container) string {
+	if c.State != "" {
+		return c.State
+	}
+	switch {
+	case strings.HasPrefix(c.Status, "Up") && strings.Contains(c.Status, "(Paused)"):
+		return "paused"
+	case strings.HasPrefix(c.Status, "Up"):
+		return "running"
+	case strings.HasPrefix(c.Status, "Restarting"):
+		return "restarting"
+	case strings.HasPrefix(c.Status, "Exited"):
+		return "exited"
+	case strings.HasPrefix(c.Status, "Dead"):
+		return "dead"
+	case strings.HasPrefix(c.Status, "Removal In Progress"):
+		return "removing"
+	}
+	return "created"
+}
+
+// containerHealth returns the health status of the container, which is in its status.
+func containerHealth(c types.Container) string {
+	switch {
+	case strings.Contains(c.Status, "(healthy)"):
+		return "healthy"
+	case strings.Contains(c.Status, "(unhealthy)"):
+		return "unhealthy"
+	case strings.Contains(c.Status, "(health: starting)"):
+		return "starting"
+	}
+	return "none"
+}
+
+// containerExitCode returns the exit code of a container that exited, which is in its status.
+func containerExitCode(c types.Container) (int, bool) {
+	m := exitCodeRegexp.FindStringSubmatch(c.Status)
+	if m == nil {
+		return 0, false
+	}
+	code, err := strconv.Atoi(m[1])
+	return code, err == nil
+}
+
+// containerCreatedBound returns the creation time of the container referred by
+// the before or since filter, nil if it's not set.
+func containerCreatedBound(args filters.Args, field string, containers []types.Container) (*int64, error) {
+	values := args.Get(field)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if len(values) > 1 {
+		return nil, fmt.Errorf("Invalid filter '%s': only one value is allowed", field)
+	}
+	ref := values[0]
+	for _, c := range containers {
+		if (ref != "" && strings.HasPrefix(c.ID, ref)) || containerName(c) == "/"+strings.TrimPrefix(ref, "/") {
+			return &c.Created, nil
+		}
+	}
+	return nil, containerNotFoundError{ref}
+}
+
+// imageCreatedBound returns the creation time of the image referred by the
+// before or since filter, nil if it's not set.
+func imageCreatedBound(args filters.Args, field string, images []types.Image) (*int64, error) {
+	values := args.Get(field)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if len(values) > 1 {
+		return nil, fmt.Errorf("Invalid filter '%s': only one value is allowed", field)
+	}
+	ref := values[0]
+	for _, img := range images {
+		if matchImageReference(img.ID, append(img.RepoTags, img.RepoDigests...), ref) {
+			return &img.Created, nil
+		}
+	}
+	return nil, imageNotFoundError{ref}
+}
+
+// matchContainerImage returns true if the container was created from the image ref.
+func matchContainerImage(c types.Container, ref string) bool {
+	return matchImageReference(c.ImageID, []string{c.Image}, ref)
+}
+
+// matchImageReference returns true if ref refers to the image with the id or one of the references.
+// The id can be shortened, and the references can omit the latest tag.
+func matchImageReference(id string, refs []string, ref string) bool {
+	shortRef := strings.TrimPrefix(ref, "sha256:")
+	if id != "" && (id == ref || (shortRef != "" && strings.HasPrefix(strings.TrimPrefix(id, "sha256:"), shortRef))) {
+		return true
+	}
+	for _, r := range refs {
+		if r == ref || r == ref+":latest" || ref == r+":latest" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchContainerVolume returns true if the container mounts one of the volumes
+// of the filter, by name or by mount point.
+func matchContainerVolume(args filters.Args, c types.Container) bool {
+	for _, m := range c.Mounts {
+		if (m.Name != "" && args.ExactMatch("volume", m.Name)) || args.ExactMatch("volume", m.Destination) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchContainerNetwork returns true if the container is connected to one of
+// the networks of the filter, by name or by id.
+func matchContainerNetwork(args filters.Args, c types.Container) bool {
+	if c.NetworkSettings == nil {
+		return false
+	}
+	for name, endpoint := range c.NetworkSettings.Networks {
+		if args.ExactMatch("network", name) {
+			return true
+		}
+		if endpoint != nil && endpoint.NetworkID != "" && args.FuzzyMatch("network", endpoint.NetworkID) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripImageTag returns the name of an image reference, without its tag or digest.
+func stripImageTag(ref string) string {
+	if strings.HasPrefix(ref, "sha256:") {
+		return ref
+	}
+	if i := strings.Index(ref, "@"); i != -1 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i != -1 && !strings.Contains(ref[i:], "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// SelectContainers returns the containers whose labels match the selector.
+// Use it with the selector returned by filters.Selector.Compile, to evaluate
+// the requirements that the daemon can't.
+func SelectContainers(selector filters.Selector, containers []types.Container) []types.Container {
+	var matched []types.Container
+	for _, c := range containers {
+		if selector.Matches(c.Labels) {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// SelectImages returns the images whose labels match the selector, like SelectContainers.
+func SelectImages(selector filters.Selector, images []types.Image) []types.Image {
+	var matched []types.Image
+	for _, img := range images {
+		if selector.Matches(img.Labels) {
+			matched = append(matched, img)
+		}
+	}
+	return matched
+}
+
+// SelectVolumes returns the volumes whose labels match the selector, like SelectContainers.
+func SelectVolumes(selector filters.Selector, volumes []*types.Volume) []*types.Volume {
+	var matched []*types.Volume
+	for _, v := range volumes {
+		if selector.Matches(v.Labels) {
+			matched = append(matched, v)
+		}
+	}
+	return matched
+}
+
+// SelectNetworks returns the networks whose labels match the selector, like SelectContainers.
+func SelectNetworks(selector filters.Selector, networks []types.NetworkResource) []types.NetworkResource {
+	var matched []types.NetworkResource
+	for _, n := range networks {
+		if selector.Matches(n.Labels) {
+			matched = append(matched, n)
+		}
+	}
+	return matched
+}
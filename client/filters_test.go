@@ -0,0 +1,326 @@
+package client
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/events"
+	"github.com/docker/engine-api/types/filters"
+	"github.com/docker/engine-api/types/network"
+)
+
+func newTestArgs(kv ...string) filters.Args {
+	args := filters.NewArgs()
+	for _, f := range kv {
+		parts := strings.SplitN(f, "=", 2)
+		args.Add(parts[0], parts[1])
+	}
+	return args
+}
+
+func testContainers() []types.Container {
+	return []types.Container{
+		{
+			ID:      "aaaa1111",
+			Names:   []string{"/web", "/proxy/web"},
+			Image:   "nginx",
+			ImageID: "sha256:1111",
+			Created: 100,
+			Labels:  map[string]string{"env": "prod", "tier": "front"},
+			State:   "running",
+			Status:  "Up 2 minutes (healthy)",
+			Mounts:  []types.MountPoint{{Name: "data", Destination: "/data"}},
+			NetworkSettings: &types.SummaryNetworkSettings{
+				Networks: map[string]*network.EndpointSettings{"frontend": {NetworkID: "net1111"}},
+			},
+		},
+		{
+			ID:      "bbbb2222",
+			Names:   []string{"/db"},
+			Image:   "postgres:9.5",
+			ImageID: "sha256:2222",
+			Created: 200,
+			Labels:  map[string]string{"env": "staging"},
+			Status:  "Exited (1) 3 minutes ago",
+		},
+		{
+			ID:      "cccc3333",
+			Names:   []string{"/cache"},
+			Image:   "redis",
+			ImageID: "sha256:3333",
+			Created: 300,
+			Status:  "Up 1 second (Paused)",
+		},
+	}
+}
+
+func containerIDs(containers []types.Container) []string {
+	ids := []string{}
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestFilterContainers(t *testing.T) {
+	cases := []struct {
+		filters  []string
+		expected []string
+	}{
+		{nil, []string{"aaaa1111", "bbbb2222", "cccc3333"}},
+		{[]string{"label=env"}, []string{"aaaa1111", "bbbb2222"}},
+		{[]string{"label=env=prod", "label=tier"}, []string{"aaaa1111"}},
+		{[]string{"name=web"}, []string{"aaaa1111"}},
+		{[]string{"name=proxy"}, []string{}},
+		{[]string{"id=^bbbb"}, []string{"bbbb2222"}},
+		{[]string{"status=exited"}, []string{"bbbb2222"}},
+		{[]string{"status=paused", "status=running"}, []string{"aaaa1111", "cccc3333"}},
+		{[]string{"exited=1"}, []string{"bbbb2222"}},
+		{[]string{"exited=0"}, []string{}},
+		{[]string{"health=healthy"}, []string{"aaaa1111"}},
+		{[]string{"health=none"}, []string{"bbbb2222", "cccc3333"}},
+		{[]string{"ancestor=nginx:latest"}, []string{"aaaa1111"}},
+		{[]string{"ancestor=sha256:2222"}, []string{"bbbb2222"}},
+		{[]string{"ancestor=3333"}, []string{"cccc3333"}},
+		{[]string{"before=cache"}, []string{"aaaa1111", "bbbb2222"}},
+		{[]string{"since=aaaa"}, []string{"bbbb2222", "cccc3333"}},
+		{[]string{"volume=data"}, []string{"aaaa1111"}},
+		{[]string{"volume=/data"}, []string{"aaaa1111"}},
+		{[]string{"network=frontend"}, []string{"aaaa1111"}},
+		{[]string{"network=net1"}, []string{"aaaa1111"}},
+	}
+	for _, c := range cases {
+		matched, err := FilterContainers(newTestArgs(c.filters...), testContainers())
+		if err != nil {
+			t.Fatalf("%v: %v", c.filters, err)
+		}
+		if ids := containerIDs(matched); !reflect.DeepEqual(ids, c.expected) {
+			t.Fatalf("%v: expected %v, got %v", c.filters, c.expected, ids)
+		}
+	}
+}
+
+func TestFilterContainersErrors(t *testing.T) {
+	cases := []struct {
+		filters       []string
+		expectedError string
+	}{
+		{[]string{"lable=env"}, "Invalid filter 'lable'"},
+		{[]string{"status=runing"}, "Invalid filter 'status=runing'"},
+		{[]string{"exited=one"}, "Invalid filter 'exited=one'"},
+		{[]string{"before=missing"}, "No such container: missing"},
+		{[]string{"isolation=hyperv"}, "Invalid filter 'isolation'"},
+	}
+	for _, c := range cases {
+		_, err := FilterContainers(newTestArgs(c.filters...), testContainers())
+		if err == nil || !strings.Contains(err.Error(), c.expectedError) {
+			t.Fatalf("%v: expected an error containing %q, got %v", c.filters, c.expectedError, err)
+		}
+	}
+}
+
+func TestFilterImages(t *testing.T) {
+	images := []types.Image{
+		{ID: "sha256:1111", RepoTags: []string{"ubuntu:latest", "ubuntu:16.04"}, Created: 100, Labels: map[string]string{"maintainer": "me"}},
+		{ID: "sha256:2222", RepoTags: []string{"<none>:<none>"}, Created: 200},
+		{ID: "sha256:3333", RepoTags: []string{"busybox:latest"}, Created: 300},
+	}
+	cases := []struct {
+		filters  []string
+		expected []string
+	}{
+		{[]string{"dangling=true"}, []string{"sha256:2222"}},
+		{[]string{"dangling=false"}, []string{"sha256:1111", "sha256:3333"}},
+		{[]string{"label=maintainer=me"}, []string{"sha256:1111"}},
+		{[]string{"before=busybox"}, []string{"sha256:1111", "sha256:2222"}},
+		{[]string{"since=ubuntu:16.04"}, []string{"sha256:2222", "sha256:3333"}},
+		{[]string{"since=2222"}, []string{"sha256:3333"}},
+	}
+	for _, c := range cases {
+		matched, err := FilterImages(newTestArgs(c.filters...), images)
+		if err != nil {
+			t.Fatalf("%v: %v", c.filters, err)
+		}
+		ids := []string{}
+		for _, img := range matched {
+			ids = append(ids, img.ID)
+		}
+		if !reflect.DeepEqual(ids, c.expected) {
+			t.Fatalf("%v: expected %v, got %v", c.filters, c.expected, ids)
+		}
+	}
+
+	if _, err := FilterImages(newTestArgs("dangling=maybe"), images); err == nil || err.Error() != "Invalid filter 'dangling=maybe'" {
+		t.Fatalf("expected an invalid dangling filter error, got %v", err)
+	}
+	if _, err := FilterImages(newTestArgs("before=missing"), images); !IsErrImageNotFound(err) {
+		t.Fatalf("expected an image not found error, got %v", err)
+	}
+}
+
+func TestFilterVolumes(t *testing.T) {
+	volumes := []*types.Volume{
+		{Name: "data", Driver: "local", Labels: map[string]string{"backup": "daily"}},
+		{Name: "logs", Driver: "local"},
+		{Name: "shared-data", Driver: "nfs"},
+	}
+	cases := []struct {
+		filters  []string
+		expected []string
+	}{
+		{[]string{"dangling=true"}, []string{"logs", "shared-data"}},
+		{[]string{"dangling=false"}, []string{"data"}},
+		{[]string{"name=data"}, []string{"data", "shared-data"}},
+		{[]string{"name=^data$"}, []string{"data"}},
+		{[]string{"driver=nfs"}, []string{"shared-data"}},
+		{[]string{"label=backup"}, []string{"data"}},
+	}
+	for _, c := range cases {
+		matched, err := FilterVolumes(newTestArgs(c.filters...), volumes, testContainers())
+		if err != nil {
+			t.Fatalf("%v: %v", c.filters, err)
+		}
+		names := []string{}
+		for _, v := range matched {
+			names = append(names, v.Name)
+		}
+		if !reflect.DeepEqual(names, c.expected) {
+			t.Fatalf("%v: expected %v, got %v", c.filters, c.expected, names)
+		}
+	}
+}
+
+func TestFilterNetworks(t *testing.T) {
+	networks := []types.NetworkResource{
+		{Name: "bridge", ID: "1111", Driver: "bridge"},
+		{Name: "host", ID: "2222", Driver: "host"},
+		{Name: "frontend", ID: "3333", Driver: "overlay", Labels: map[string]string{"env": "prod"}},
+	}
+	cases := []struct {
+		filters  []string
+		expected []string
+	}{
+		{[]string{"type=builtin"}, []string{"bridge", "host"}},
+		{[]string{"type=custom"}, []string{"frontend"}},
+		{[]string{"driver=overlay"}, []string{"frontend"}},
+		{[]string{"name=front"}, []string{"frontend"}},
+		{[]string{"id=^2"}, []string{"host"}},
+		{[]string{"label=env=prod"}, []string{"frontend"}},
+	}
+	for _, c := range cases {
+		matched, err := FilterNetworks(newTestArgs(c.filters...), networks)
+		if err != nil {
+			t.Fatalf("%v: %v", c.filters, err)
+		}
+		names := []string{}
+		for _, n := range matched {
+			names = append(names, n.Name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, c.expected) {
+			t.Fatalf("%v: expected %v, got %v", c.filters, c.expected, names)
+		}
+	}
+
+	if _, err := FilterNetworks(newTestArgs("type=other"), networks); err == nil {
+		t.Fatal("expected an invalid type filter error")
+	}
+}
+
+func TestMatchEvent(t *testing.T) {
+	containerEvent := events.Message{
+		Type:   events.ContainerEventType,
+		Action: "start",
+		Actor: events.Actor{
+			ID:         "aaaa1111",
+			Attributes: map[string]string{"name": "web", "image": "nginx:1.11", "env": "prod"},
+		},
+	}
+	imageEvent := events.Message{
+		Type:   events.ImageEventType,
+		Action: "pull",
+		Actor: events.Actor{
+			ID:         "nginx:1.11",
+			Attributes: map[string]string{"name": "nginx"},
+		},
+	}
+	legacyEvent := events.Message{Status: "die", ID: "bbbb2222", From: "redis"}
+
+	cases := []struct {
+		filters  []string
+		event    events.Message
+		expected bool
+	}{
+		{nil, containerEvent, true},
+		{[]string{"type=container"}, containerEvent, true},
+		{[]string{"type=image"}, containerEvent, false},
+		{[]string{"event=start", "event=stop"}, containerEvent, true},
+		{[]string{"event=stop"}, containerEvent, false},
+		{[]string{"container=web"}, containerEvent, true},
+		{[]string{"container=aaaa"}, containerEvent, true},
+		{[]string{"container=db"}, containerEvent, false},
+		{[]string{"image=nginx"}, containerEvent, true},
+		{[]string{"image=nginx"}, imageEvent, true},
+		{[]string{"image=nginx:1.11"}, imageEvent, true},
+		{[]string{"image=redis"}, imageEvent, false},
+		{[]string{"label=env=prod"}, containerEvent, true},
+		{[]string{"label=env=staging"}, containerEvent, false},
+		{[]string{"network=frontend"}, containerEvent, false},
+		{[]string{"type=container", "event=die", "image=redis"}, legacyEvent, true},
+		{[]string{"container=bbbb"}, legacyEvent, true},
+	}
+	for _, c := range cases {
+		matched, err := MatchEvent(newTestArgs(c.filters...), c.event)
+		if err != nil {
+			t.Fatalf("%v: %v", c.filters, err)
+		}
+		if matched != c.expected {
+			t.Fatalf("%v: expected %v matching %+v, got %v", c.filters, c.expected, c.event, matched)
+		}
+	}
+
+	if _, err := MatchEvent(newTestArgs("service=web"), containerEvent); err == nil {
+		t.Fatal("expected an invalid filter error")
+	}
+}
+
+func TestSelectContainers(t *testing.T) {
+	selector, err := filters.ParseSelector("env in (prod,staging),tier!=front")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, rest := selector.Compile()
+	if args.Len() != 0 {
+		t.Fatalf("expected no daemon filters, got %v", args)
+	}
+
+	matched := SelectContainers(rest, testContainers())
+	if ids := containerIDs(matched); !reflect.DeepEqual(ids, []string{"bbbb2222"}) {
+		t.Fatalf("expected bbbb2222, got %v", ids)
+	}
+}
+
+func TestSelectLabels(t *testing.T) {
+	selector, err := filters.ParseSelector("!legacy,env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{"env": "prod"}
+	legacy := map[string]string{"env": "prod", "legacy": "true"}
+
+	images := SelectImages(selector, []types.Image{{ID: "1", Labels: labels}, {ID: "2", Labels: legacy}, {ID: "3"}})
+	if len(images) != 1 || images[0].ID != "1" {
+		t.Fatalf("unexpected images %v", images)
+	}
+	volumes := SelectVolumes(selector, []*types.Volume{{Name: "1", Labels: labels}, {Name: "2", Labels: legacy}})
+	if len(volumes) != 1 || volumes[0].Name != "1" {
+		t.Fatalf("unexpected volumes %v", volumes)
+	}
+	networks := SelectNetworks(selector, []types.NetworkResource{{Name: "1", Labels: labels}, {Name: "2"}})
+	if len(networks) != 1 || networks[0].Name != "1" {
+		t.Fatalf("unexpected networks %v", networks)
+	}
+}
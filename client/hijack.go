@@ -4,10 +4,12 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/engine-api/types"
@@ -30,28 +32,106 @@ func (c *tlsClientCon) CloseWrite() error {
 	return nil
 }
 
+// defaultHandshakeTimeout is the time limit to dial the server and
+// upgrade the connection before hijacking it, when it's not configured.
+const defaultHandshakeTimeout = 32 * time.Second
+
+// hijackedConn is a hijacked connection that closes when its context is done.
+type hijackedConn struct {
+	net.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newHijackedConn wraps the connection to close it when the context is done.
+func newHijackedConn(ctx context.Context, conn net.Conn) *hijackedConn {
+	c := &hijackedConn{
+		Conn:   conn,
+		closed: make(chan struct{}),
+	}
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				c.Close()
+			case <-c.closed:
+			}
+		}()
+	}
+	return c
+}
+
+// Close closes the connection.
+func (c *hijackedConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return err
+}
+
+// CloseWrite closes the connection for writing, if the connection supports it.
+func (c *hijackedConn) CloseWrite() error {
+	if conn, ok := c.Conn.(types.CloseWriter); ok {
+		return conn.CloseWrite()
+	}
+	return nil
+}
+
 // postHijacked sends a POST request and hijacks the connection.
+// The context limits the time to dial the server and upgrade the connection,
+// along with the client's handshake timeout. The hijacked connection is closed
+// when the context is done.
 func (cli *Client) postHijacked(ctx context.Context, path string, query url.Values, body interface{}, headers map[string][]string) (types.HijackedResponse, error) {
+	if err := cli.checkAPIVersion(ctx); err != nil {
+		return types.HijackedResponse{}, err
+	}
+
 	bodyEncoded, err := encodeData(body)
 	if err != nil {
 		return types.HijackedResponse{}, err
 	}
 
-	req, err := cli.newRequest("POST", path, query, bodyEncoded, headers)
-	if err != nil {
+	apiReq := &APIRequest{
+		Method: "POST",
+		Path:   path,
+		Query:  query,
+		Header: cli.requestHeaders(headers),
+		Body:   bodyEncoded,
+		Hijack: true,
+	}
+
+	resp, err := cli.intercept(ctx, apiReq, cli.hijackAPIRequest)
+	if resp == nil {
 		return types.HijackedResponse{}, err
 	}
+	return resp.Hijacked, err
+}
+
+// hijackAPIRequest sends a request to the docker API and hijacks the connection,
+// it's the last step in the chain of interceptors.
+func (cli *Client) hijackAPIRequest(ctx context.Context, apiReq *APIRequest) (*APIResponse, error) {
+	req, err := cli.newRequest(apiReq.Method, apiReq.Path, apiReq.Query, apiReq.Body, apiReq.Header)
+	if err != nil {
+		return nil, err
+	}
 	req.Host = cli.addr
 
 	req.Header.Set("Connection", "Upgrade")
 	req.Header.Set("Upgrade", "tcp")
 
-	conn, err := dial(cli.proto, cli.addr, cli.transport.TLSConfig())
+	handshakeCtx, cancel, timeout := cli.handshakeContext(ctx)
+	defer cancel()
+
+	conn, err := cli.dialHijack(handshakeCtx)
 	if err != nil {
+		if ctxErr := handshakeError(ctx, handshakeCtx, timeout); ctxErr != nil {
+			return nil, ctxErr
+		}
 		if strings.Contains(err.Error(), "connection refused") {
-			return types.HijackedResponse{}, fmt.Errorf("Cannot connect to the Docker daemon. Is 'docker daemon' running on this host?")
+			return nil, fmt.Errorf("Cannot connect to the Docker daemon. Is 'docker daemon' running on this host?")
 		}
-		return types.HijackedResponse{}, err
+		return nil, err
 	}
 
 	// When we set up a TCP connection for hijack, there could be long periods
@@ -68,15 +148,33 @@ func (cli *Client) postHijacked(ctx context.Context, path string, query url.Valu
 	defer clientconn.Close()
 
 	// Server hijacks the connection, error 'connection closed' expected
-	_, err = clientconn.Do(req)
+	handshakeDone := closeOnDone(handshakeCtx, conn)
+	serverResp, err := clientconn.Do(req)
+	handshakeDone()
+	if ctxErr := handshakeError(ctx, handshakeCtx, timeout); ctxErr != nil {
+		conn.Close()
+		return nil, ctxErr
+	}
 
-	rwc, br := clientconn.Hijack()
+	if serverResp != nil && serverResp.StatusCode >= 400 {
+		body, _ := ioutil.ReadAll(serverResp.Body)
+		conn.Close()
+		return &APIResponse{
+			StatusCode: serverResp.StatusCode,
+			Header:     serverResp.Header,
+		}, newServerError(serverResp.StatusCode, req.Method, apiReq.Path, cli.ClientVersion(), req.URL.String(), body)
+	}
 
-	return types.HijackedResponse{Conn: rwc, Reader: br}, err
-}
+	rwc, br := clientconn.Hijack()
 
-func tlsDial(network, addr string, config *tls.Config) (net.Conn, error) {
-	return tlsDialWithDialer(new(net.Dialer), network, addr, config)
+	apiResp := &APIResponse{
+		Hijacked: types.HijackedResponse{Conn: newHijackedConn(ctx, rwc), Reader: br},
+	}
+	if serverResp != nil {
+		apiResp.StatusCode = serverResp.StatusCode
+		apiResp.Header = serverResp.Header
+	}
+	return apiResp, err
 }
 
 // We need to copy Go's implementation of tls.Dial (pkg/cryptor/tls/tls.go) in
@@ -162,13 +260,99 @@ func tlsDialWithDialer(dialer *net.Dialer, network, addr string, config *tls.Con
 	return &tlsClientCon{conn, rawConn}, nil
 }
 
-func dial(proto, addr string, tlsConfig *tls.Config) (net.Conn, error) {
+// handshakeContext returns the context to dial the server and upgrade the connection,
+// it's done when the given context is done or when the handshake timeout expires.
+func (cli *Client) handshakeContext(ctx context.Context) (context.Context, context.CancelFunc, time.Duration) {
+	timeout := cli.handshakeTimeout
+	if timeout == 0 {
+		timeout = defaultHandshakeTimeout
+	}
+	handshakeCtx, cancel := context.WithTimeout(ctx, timeout)
+	return handshakeCtx, cancel, timeout
+}
+
+// closeOnDone closes the connection if the context is done before
+// the returned function is called. It's used to abort requests
+// that cannot be cancelled otherwise.
+func closeOnDone(ctx context.Context, conn net.Conn) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// handshakeError returns the error to report when the context is done before
+// the connection is hijacked. It returns nil if the context is not done.
+func handshakeError(ctx, handshakeCtx context.Context, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if handshakeCtx.Err() != nil {
+		return fmt.Errorf("Timed out connecting to the Docker daemon after %v", timeout)
+	}
+	return nil
+}
+
+// dialHijack opens a new connection to the server to hijack it.
+// It goes through the ssh tunnel when the server is an ssh host.
+// It gives up when the context is done, and the connection is closed
+// if the dialing finishes later.
+func (cli *Client) dialHijack(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	if cli.dialer != nil {
+		// Make a copy to avoid modifying the dialer provided by the user.
+		d := *cli.dialer
+		dialer = &d
+	}
+	if deadline, ok := ctx.Deadline(); ok && (dialer.Deadline.IsZero() || deadline.Before(dialer.Deadline)) {
+		dialer.Deadline = deadline
+	}
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	result := make(chan dialResult, 1)
+	go func() {
+		var r dialResult
+		if cli.sshTunnel != nil {
+			r.conn, r.err = cli.sshTunnel.Dial(cli.proto, cli.addr)
+		} else {
+			r.conn, r.err = dial(cli.proto, cli.addr, dialer, cli.transport.TLSConfig())
+		}
+		result <- r
+	}()
+
+	select {
+	case r := <-result:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-result; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+func dial(proto, addr string, dialer *net.Dialer, tlsConfig *tls.Config) (net.Conn, error) {
+	if dialer == nil {
+		dialer = new(net.Dialer)
+	}
 	if tlsConfig != nil && proto != "unix" && proto != "npipe" {
 		// Notice this isn't Go standard's tls.Dial function
-		return tlsDial(proto, addr, tlsConfig)
+		return tlsDialWithDialer(dialer, proto, addr, tlsConfig)
 	}
 	if proto == "npipe" {
 		return sockets.DialPipe(addr, 32*time.Second)
 	}
-	return net.Dial(proto, addr)
+	return dialer.Dial(proto, addr)
 }
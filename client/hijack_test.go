@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+// hijackServer accepts connections and answers upgrade requests with
+// the given response, it never answers them if the response is empty.
+// The connections are kept open until the server is closed.
+func hijackServer(t *testing.T, response string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+					return
+				}
+				if response != "" {
+					conn.Write([]byte(response))
+				}
+				// Block until the client closes the connection.
+				buf := make([]byte, 1)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func TestHijackContextDeadlineDuringHandshake(t *testing.T) {
+	l := hijackServer(t, "")
+	defer l.Close()
+
+	client, err := NewClientWithOpts(WithHost("tcp://" + l.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.ContainerAttach(ctx, "container_id", types.ContainerAttachOptions{})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("expected the handshake to be aborted when the context is done")
+	}
+}
+
+func TestHijackHandshakeTimeout(t *testing.T) {
+	l := hijackServer(t, "")
+	defer l.Close()
+
+	client, err := NewClientWithOpts(WithHost("tcp://"+l.Addr().String()), WithHandshakeTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.ContainerExecAttach(context.Background(), "exec_id", types.ExecConfig{})
+	if err == nil || !strings.Contains(err.Error(), "Timed out connecting to the Docker daemon") {
+		t.Fatalf("expected a handshake timeout error, got %v", err)
+	}
+}
+
+func TestHijackContextCancelClosesConnection(t *testing.T) {
+	l := hijackServer(t, "HTTP/1.1 101 UPGRADED\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	defer l.Close()
+
+	client, err := NewClientWithOpts(WithHost("tcp://" + l.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := client.ContainerAttach(ctx, "container_id", types.ContainerAttachOptions{Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := resp.Reader.ReadByte()
+		readErr <- err
+	}()
+
+	cancel()
+	select {
+	case err := <-readErr:
+		if err == nil {
+			t.Fatal("expected an error reading from a closed connection, got nil")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the hijacked connection to be closed when the context is done")
+	}
+}
+
+func TestHijackedConnClose(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn := newHijackedConn(ctx, client)
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Closing it twice, or when the context is done, must not panic.
+	conn.Close()
+	cancel()
+	if err := conn.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -19,16 +19,34 @@ import (
 var headerRegexp = regexp.MustCompile(`\ADocker/.+\s\((.+)\)\z`)
 
 // ImageBuild sends request to the daemon to build images.
+// Use CreateBuildContext to archive a local directory as the build context.
+// It sends the credentials for all the registries known to the client's
+// auth provider if the options don't include any.
+// It returns an ErrUnsupportedByVersion if the options include fields
+// that the API version of the client doesn't support.
 // The Body in the response implement an io.ReadCloser and it's up to the caller to
 // close it.
 func (cli *Client) ImageBuild(ctx context.Context, buildContext io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	if err := cli.checkOptionsVersion(ctx,
+		versionedOptions{"ImageBuildOptions", options, []map[string]string{imageBuildVersions}},
+	); err != nil {
+		return types.ImageBuildResponse{}, err
+	}
+
 	query, err := imageBuildOptionsToQuery(options)
 	if err != nil {
 		return types.ImageBuildResponse{}, err
 	}
 
+	authConfigs := options.AuthConfigs
+	if authConfigs == nil && cli.authProvider != nil {
+		if authConfigs, err = cli.authProvider.AllAuthConfigs(ctx); err != nil {
+			return types.ImageBuildResponse{}, err
+		}
+	}
+
 	headers := http.Header(make(map[string][]string))
-	buf, err := json.Marshal(options.AuthConfigs)
+	buf, err := json.Marshal(authConfigs)
 	if err != nil {
 		return types.ImageBuildResponse{}, err
 	}
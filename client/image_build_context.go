@@ -0,0 +1,132 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/engine-api/client/archive"
+)
+
+// DefaultDockerfileName is the name of the Dockerfile in the build context
+// when ImageBuildOptions.Dockerfile is empty.
+const DefaultDockerfileName = "Dockerfile"
+
+// CreateBuildContext archives the directory contextDir to send it as the
+// build context to ImageBuild. The paths that match the patterns in the
+// .dockerignore file of the directory are excluded, but the Dockerfile and
+// the .dockerignore file are always sent. The entries are normalized, so that
+// the archive only depends on the names and the content of the files.
+//
+// dockerfile is the path of the Dockerfile, relative to contextDir unless it's
+// absolute, and it defaults to DefaultDockerfileName. It must be inside the
+// context. CreateBuildContext returns its path in the archive, which is the
+// ImageBuildOptions.Dockerfile to use with the archive.
+//
+// It's up to the caller to close the io.ReadCloser returned.
+func CreateBuildContext(contextDir, dockerfile string, compression archive.Compression) (io.ReadCloser, string, error) {
+	absContextDir, relDockerfile, err := resolveDockerfile(contextDir, dockerfile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	excludes, err := readDockerignore(absContextDir)
+	if err != nil {
+		return nil, "", err
+	}
+	pm, err := archive.NewPatternMatcher(excludes)
+	if err != nil {
+		return nil, "", fmt.Errorf("Error checking context: %v", err)
+	}
+	// The daemon needs the .dockerignore file to apply the same exclusions,
+	// and the Dockerfile to build the image.
+	if pm.Matches(archive.DockerignoreFileName) {
+		excludes = append(excludes, "!"+archive.DockerignoreFileName)
+	}
+	if pm.Matches(relDockerfile) {
+		excludes = append(excludes, "!"+relDockerfile)
+	}
+
+	buildCtx, err := archive.Tar(absContextDir, &archive.TarOptions{
+		ExcludePatterns: excludes,
+		Compression:     compression,
+		Normalize:       true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return buildCtx, relDockerfile, nil
+}
+
+// resolveDockerfile returns the absolute path of the context directory, with
+// the symbolic links resolved, and the path of the Dockerfile in the context.
+func resolveDockerfile(contextDir, dockerfile string) (string, string, error) {
+	absContextDir, err := filepath.Abs(contextDir)
+	if err != nil {
+		return "", "", fmt.Errorf("Unable to get absolute context directory: %v", err)
+	}
+	absContextDir, err = filepath.EvalSymlinks(absContextDir)
+	if err != nil {
+		return "", "", fmt.Errorf("Error evaluating symlinks in context directory: %v", err)
+	}
+	stat, err := os.Lstat(absContextDir)
+	if err != nil {
+		return "", "", fmt.Errorf("Unable to stat context directory %s: %v", absContextDir, err)
+	}
+	if !stat.IsDir() {
+		return "", "", fmt.Errorf("Context must be a directory: %s", absContextDir)
+	}
+
+	if dockerfile == "" {
+		dockerfile = DefaultDockerfileName
+	}
+	absDockerfile := dockerfile
+	if !filepath.IsAbs(absDockerfile) {
+		absDockerfile = filepath.Join(absContextDir, absDockerfile)
+	}
+	absDockerfile, err = filepath.EvalSymlinks(absDockerfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", fmt.Errorf("Cannot locate Dockerfile: %s", dockerfile)
+		}
+		return "", "", fmt.Errorf("Error evaluating symlinks in Dockerfile path: %v", err)
+	}
+
+	relDockerfile, err := filepath.Rel(absContextDir, absDockerfile)
+	if err != nil {
+		return "", "", fmt.Errorf("Unable to get relative Dockerfile path: %v", err)
+	}
+	if relDockerfile == ".." || strings.HasPrefix(relDockerfile, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("The Dockerfile (%s) must be within the build context (%s)", dockerfile, contextDir)
+	}
+	stat, err = os.Stat(absDockerfile)
+	if err != nil {
+		return "", "", fmt.Errorf("Unable to stat Dockerfile %s: %v", dockerfile, err)
+	}
+	if !stat.Mode().IsRegular() {
+		return "", "", fmt.Errorf("The Dockerfile (%s) must be a file", dockerfile)
+	}
+
+	return absContextDir, filepath.ToSlash(relDockerfile), nil
+}
+
+// readDockerignore reads the exclusion patterns in the .dockerignore file
+// of the context directory, if there is one.
+func readDockerignore(contextDir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(contextDir, archive.DockerignoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	excludes, err := archive.ReadDockerignore(f)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading .dockerignore: %v", err)
+	}
+	return excludes, nil
+}
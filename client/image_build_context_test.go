@@ -0,0 +1,133 @@
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/client/archive"
+)
+
+func createBuildContextDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "build-context-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func buildContextNames(t *testing.T, r io.Reader) []string {
+	var names []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCreateBuildContext(t *testing.T) {
+	dir := createBuildContextDir(t, map[string]string{
+		"Dockerfile":    "FROM scratch",
+		".dockerignore": "*\n!app\n",
+		"app/main.go":   "package main",
+		"secret":        "secret",
+	})
+	defer os.RemoveAll(dir)
+
+	buildCtx, dockerfile, err := CreateBuildContext(dir, "", archive.Uncompressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buildCtx.Close()
+
+	if dockerfile != "Dockerfile" {
+		t.Fatalf("expected Dockerfile, got %s", dockerfile)
+	}
+	names := buildContextNames(t, buildCtx)
+	expected := []string{".dockerignore", "Dockerfile", "app/", "app/main.go"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestCreateBuildContextDockerfileInExcludedDir(t *testing.T) {
+	dir := createBuildContextDir(t, map[string]string{
+		"build/Dockerfile.prod": "FROM scratch",
+		"build/script.sh":       "#!/bin/sh",
+		".dockerignore":         "build\n.dockerignore\n",
+		"main.go":               "package main",
+	})
+	defer os.RemoveAll(dir)
+
+	buildCtx, dockerfile, err := CreateBuildContext(dir, filepath.Join(dir, "build", "Dockerfile.prod"), archive.Gzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buildCtx.Close()
+
+	if dockerfile != "build/Dockerfile.prod" {
+		t.Fatalf("expected build/Dockerfile.prod, got %s", dockerfile)
+	}
+	gz, err := gzip.NewReader(buildCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := buildContextNames(t, gz)
+	expected := []string{".dockerignore", "build/Dockerfile.prod", "main.go"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestCreateBuildContextErrors(t *testing.T) {
+	dir := createBuildContextDir(t, map[string]string{
+		"context/Dockerfile": "FROM scratch",
+		"context/subdir/a":   "a",
+		"Dockerfile.outside": "FROM scratch",
+	})
+	defer os.RemoveAll(dir)
+	contextDir := filepath.Join(dir, "context")
+
+	cases := []struct {
+		contextDir    string
+		dockerfile    string
+		expectedError string
+	}{
+		{filepath.Join(dir, "missing"), "", "Error evaluating symlinks in context directory"},
+		{filepath.Join(contextDir, "Dockerfile"), "", "Context must be a directory"},
+		{contextDir, "Dockerfile.missing", "Cannot locate Dockerfile: Dockerfile.missing"},
+		{contextDir, "../Dockerfile.outside", "must be within the build context"},
+		{contextDir, filepath.Join(dir, "Dockerfile.outside"), "must be within the build context"},
+		{contextDir, "subdir", "must be a file"},
+	}
+	for _, c := range cases {
+		_, _, err := CreateBuildContext(c.contextDir, c.dockerfile, archive.Uncompressed)
+		if err == nil || !strings.Contains(err.Error(), c.expectedError) {
+			t.Fatalf("expected an error containing %q for %s, got %v", c.expectedError, c.dockerfile, err)
+		}
+	}
+}
@@ -12,6 +12,8 @@ import (
 
 // ImageCreate creates a new image based in the parent options.
 // It returns the JSON content in the response body.
+// It uses the credentials from the client's auth provider if the options don't include them,
+// and it refreshes them with the auth provider if the operation is unauthorized.
 func (cli *Client) ImageCreate(ctx context.Context, parentReference string, options types.ImageCreateOptions) (io.ReadCloser, error) {
 	repository, tag, err := reference.Parse(parentReference)
 	if err != nil {
@@ -21,7 +23,13 @@ func (cli *Client) ImageCreate(ctx context.Context, parentReference string, opti
 	query := url.Values{}
 	query.Set("fromImage", repository)
 	query.Set("tag", tag)
-	resp, err := cli.tryImageCreate(ctx, query, options.RegistryAuth)
+	hostname, err := cli.registryHostname(parentReference)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cli.tryWithRegistryAuth(ctx, hostname, options.RegistryAuth, nil, func(registryAuth string) (*serverResponse, error) {
+		return cli.tryImageCreate(ctx, query, registryAuth)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -2,7 +2,6 @@ package client
 
 import (
 	"io"
-	"net/http"
 	"net/url"
 
 	"golang.org/x/net/context"
@@ -12,8 +11,9 @@ import (
 )
 
 // ImagePull requests the docker host to pull an image from a remote registry.
-// It executes the privileged function if the operation is unauthorized
-// and it tries one more time.
+// It uses the credentials from the client's auth provider if the options don't include them.
+// It executes the privileged function, or it refreshes the credentials with the auth provider,
+// if the operation is unauthorized and it tries one more time.
 // It's up to the caller to handle the io.ReadCloser and close it properly.
 //
 // FIXME(vdemeester): there is currently used in a few way in docker/docker
@@ -31,14 +31,13 @@ func (cli *Client) ImagePull(ctx context.Context, ref string, options types.Imag
 		query.Set("tag", tag)
 	}
 
-	resp, err := cli.tryImageCreate(ctx, query, options.RegistryAuth)
-	if resp.statusCode == http.StatusUnauthorized {
-		newAuthHeader, privilegeErr := options.PrivilegeFunc()
-		if privilegeErr != nil {
-			return nil, privilegeErr
-		}
-		resp, err = cli.tryImageCreate(ctx, query, newAuthHeader)
+	hostname, err := cli.registryHostname(ref)
+	if err != nil {
+		return nil, err
 	}
+	resp, err := cli.tryWithRegistryAuth(ctx, hostname, options.RegistryAuth, options.PrivilegeFunc, func(registryAuth string) (*serverResponse, error) {
+		return cli.tryImageCreate(ctx, query, registryAuth)
+	})
 	if err != nil {
 		return nil, err
 	}
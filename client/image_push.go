@@ -3,7 +3,6 @@ package client
 import (
 	"errors"
 	"io"
-	"net/http"
 	"net/url"
 
 	"golang.org/x/net/context"
@@ -14,8 +13,9 @@ import (
 )
 
 // ImagePush requests the docker host to push an image to a remote registry.
-// It executes the privileged function if the operation is unauthorized
-// and it tries one more time.
+// It uses the credentials from the client's auth provider if the options don't include them.
+// It executes the privileged function, or it refreshes the credentials with the auth provider,
+// if the operation is unauthorized and it tries one more time.
 // It's up to the caller to handle the io.ReadCloser and close it properly.
 func (cli *Client) ImagePush(ctx context.Context, ref string, options types.ImagePushOptions) (io.ReadCloser, error) {
 	distributionRef, err := distreference.ParseNamed(ref)
@@ -32,14 +32,13 @@ func (cli *Client) ImagePush(ctx context.Context, ref string, options types.Imag
 	query := url.Values{}
 	query.Set("tag", tag)
 
-	resp, err := cli.tryImagePush(ctx, distributionRef.Name(), query, options.RegistryAuth)
-	if resp.statusCode == http.StatusUnauthorized {
-		newAuthHeader, privilegeErr := options.PrivilegeFunc()
-		if privilegeErr != nil {
-			return nil, privilegeErr
-		}
-		resp, err = cli.tryImagePush(ctx, distributionRef.Name(), query, newAuthHeader)
+	hostname, err := cli.registryHostname(ref)
+	if err != nil {
+		return nil, err
 	}
+	resp, err := cli.tryWithRegistryAuth(ctx, hostname, options.RegistryAuth, options.PrivilegeFunc, func(registryAuth string) (*serverResponse, error) {
+		return cli.tryImagePush(ctx, distributionRef.Name(), query, registryAuth)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -9,12 +9,13 @@ import (
 
 // ImageSave retrieves one or more images from the docker host as an io.ReadCloser.
 // It's up to the caller to store the images and close the stream.
+// The images are in a tar archive, archive.Untar extracts it safely.
 func (cli *Client) ImageSave(ctx context.Context, imageIDs []string) (io.ReadCloser, error) {
 	query := url.Values{
 		"names": imageIDs,
 	}
 
-	resp, err := cli.get(ctx, "/images/get", query, nil)
+	resp, err := cli.getStream(ctx, "/images/get", query, nil)
 	if err != nil {
 		return nil, err
 	}
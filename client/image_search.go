@@ -2,9 +2,9 @@ package client
 
 import (
 	"encoding/json"
-	"net/http"
 	"net/url"
 
+	"github.com/docker/engine-api/client/auth"
 	"github.com/docker/engine-api/types"
 	"github.com/docker/engine-api/types/registry"
 	"golang.org/x/net/context"
@@ -12,19 +12,16 @@ import (
 
 // ImageSearch makes the docker host to search by a term in a remote registry.
 // The list of results is not sorted in any fashion.
+// It uses the credentials from the client's auth provider if the options don't include them.
 func (cli *Client) ImageSearch(ctx context.Context, term string, options types.ImageSearchOptions) ([]registry.SearchResult, error) {
 	var results []registry.SearchResult
 	query := url.Values{}
 	query.Set("term", term)
 
-	resp, err := cli.tryImageSearch(ctx, query, options.RegistryAuth)
-	if resp.statusCode == http.StatusUnauthorized {
-		newAuthHeader, privilegeErr := options.PrivilegeFunc()
-		if privilegeErr != nil {
-			return results, privilegeErr
-		}
-		resp, err = cli.tryImageSearch(ctx, query, newAuthHeader)
-	}
+	hostname := auth.ParseSearchTermHostname(term)
+	resp, err := cli.tryWithRegistryAuth(ctx, hostname, options.RegistryAuth, options.PrivilegeFunc, func(registryAuth string) (*serverResponse, error) {
+		return cli.tryImageSearch(ctx, query, registryAuth)
+	})
 	if err != nil {
 		return results, err
 	}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+// APIRequest holds the information about a request to the docker API
+// that interceptors can inspect and rewrite.
+type APIRequest struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// Path is the API path of the request, without the version prefix.
+	Path string
+	// Query holds the query parameters of the request.
+	Query url.Values
+	// Header holds the http headers of the request,
+	// including the custom http headers configured in the client.
+	Header http.Header
+	// Body is the payload of the request, it can be nil.
+	Body io.Reader
+	// Hijack indicates if the connection is hijacked after sending the request,
+	// like in ContainerAttach and ContainerExecAttach.
+	Hijack bool
+	// Stream indicates if the response body is streamed to the caller,
+	// like in ContainerLogs and Events.
+	Stream bool
+}
+
+// APIResponse holds the information about a response from the docker API
+// that interceptors can inspect and rewrite.
+type APIResponse struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Header holds the http headers of the response.
+	Header http.Header
+	// Body is the payload of the response.
+	// It's nil for hijacked requests and unsuccessful responses.
+	Body io.ReadCloser
+	// Hijacked holds the connection hijacked from the server
+	// when the request's Hijack field is true.
+	Hijacked types.HijackedResponse
+}
+
+// SendFunc sends a request to the docker API and returns its response.
+// The response can be non-nil when an error is returned,
+// to let interceptors know the status code of unsuccessful responses.
+type SendFunc func(ctx context.Context, req *APIRequest) (*APIResponse, error)
+
+// Interceptor is an interface that allows to intercept every request
+// that the client sends to the docker API.
+// Interceptors call next to continue with the request, they can modify
+// the request before calling it and the response after calling it.
+// They can also short-circuit the request returning their own response
+// without calling next.
+type Interceptor interface {
+	Intercept(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error)
+}
+
+// InterceptorFunc is an adapter to allow the use of ordinary
+// functions as interceptors.
+type InterceptorFunc func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error)
+
+// Intercept calls f(ctx, req, next).
+func (f InterceptorFunc) Intercept(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+	return f(ctx, req, next)
+}
+
+// WithInterceptors adds interceptors to the client.
+// The interceptors are called in the order they are added,
+// the first interceptor sees the request first and the response last.
+func WithInterceptors(interceptors ...Interceptor) Opt {
+	return func(c *Client) error {
+		c.interceptors = append(c.interceptors, interceptors...)
+		return nil
+	}
+}
+
+// intercept sends the request through the chain of interceptors,
+// calling send at the end of the chain.
+func (cli *Client) intercept(ctx context.Context, req *APIRequest, send SendFunc) (*APIResponse, error) {
+	next := send
+	for i := len(cli.interceptors) - 1; i >= 0; i-- {
+		interceptor, n := cli.interceptors[i], next
+		next = func(ctx context.Context, req *APIRequest) (*APIResponse, error) {
+			return interceptor.Intercept(ctx, req, n)
+		}
+	}
+
+	resp, err := next(ctx, req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	// Hijacked connections are upgraded by the server,
+	// they don't return a successful status code.
+	unsuccessful := isErrorStatus(resp.StatusCode)
+	if req.Hijack {
+		unsuccessful = resp.StatusCode >= 400
+	}
+	if unsuccessful {
+		// An interceptor returned an unsuccessful response without an error.
+		var body []byte
+		if resp.Body != nil {
+			body, _ = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = nil
+		}
+		if resp.Hijacked.Conn != nil {
+			resp.Hijacked.Close()
+		}
+		err = newServerError(resp.StatusCode, req.Method, req.Path, cli.ClientVersion(), req.Path, body)
+	}
+	return resp, err
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+func TestInterceptorsOrder(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) Interceptor {
+		return InterceptorFunc(func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+			calls = append(calls, name+" request")
+			resp, err := next(ctx, req)
+			calls = append(calls, name+" response")
+			return resp, err
+		})
+	}
+
+	client := &Client{
+		transport:    newMockClient(nil, errorMock(http.StatusOK, "")),
+		interceptors: []Interceptor{interceptor("first"), interceptor("second")},
+	}
+	if err := client.ContainerStart(context.Background(), "container_id"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "first request,second request,second response,first response"
+	if strings.Join(calls, ",") != expected {
+		t.Fatalf("expected calls %s, got %v", expected, calls)
+	}
+}
+
+func TestInterceptorSeesRequestAndResponse(t *testing.T) {
+	var (
+		req        *APIRequest
+		statusCode int
+	)
+	client, err := NewClientWithOpts(
+		WithVersion("1.22"),
+		WithHTTPHeaders(map[string]string{"User-Agent": "engine-api-test"}),
+		WithInterceptors(InterceptorFunc(func(ctx context.Context, r *APIRequest, next SendFunc) (*APIResponse, error) {
+			req = r
+			resp, err := next(ctx, r)
+			if resp != nil {
+				statusCode = resp.StatusCode
+			}
+			return resp, err
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.transport = newMockClient(nil, errorMock(http.StatusConflict, "container is running"))
+
+	err = client.ContainerRemove(context.Background(), "container_id", types.ContainerRemoveOptions{Force: true})
+	if !IsErrConflict(err) {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if req.Method != "DELETE" || req.Path != "/containers/container_id" {
+		t.Fatalf("unexpected request %s %s", req.Method, req.Path)
+	}
+	if req.Query.Get("force") != "1" {
+		t.Fatalf("expected force query parameter, got %v", req.Query)
+	}
+	if req.Header.Get("User-Agent") != "engine-api-test" {
+		t.Fatalf("expected custom headers, got %v", req.Header)
+	}
+	if statusCode != http.StatusConflict {
+		t.Fatalf("expected status code %d, got %d", http.StatusConflict, statusCode)
+	}
+}
+
+func TestInterceptorRewritesRequest(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer token" {
+				return errorMock(http.StatusForbidden, "missing token")(req)
+			}
+			if req.URL.Path != "/containers/other/start" {
+				return errorMock(http.StatusNotFound, "unexpected path")(req)
+			}
+			return errorMock(http.StatusNoContent, "")(req)
+		}),
+		interceptors: []Interceptor{
+			InterceptorFunc(func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+				req.Header.Set("Authorization", "Bearer token")
+				req.Path = strings.Replace(req.Path, "container_id", "other", 1)
+				return next(ctx, req)
+			}),
+		},
+	}
+	if err := client.ContainerStart(context.Background(), "container_id"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			t.Fatalf("unexpected request to the server: %s", req.URL)
+			return nil, nil
+		}),
+		interceptors: []Interceptor{
+			InterceptorFunc(func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+				if req.Method != "GET" {
+					return &APIResponse{
+						StatusCode: http.StatusForbidden,
+						Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"message":"read only client"}`))),
+					}, nil
+				}
+				b, err := json.Marshal(types.Version{APIVersion: "1.22"})
+				if err != nil {
+					return nil, err
+				}
+				return &APIResponse{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewReader(b)),
+				}, nil
+			}),
+		},
+	}
+
+	v, err := client.ServerVersion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.APIVersion != "1.22" {
+		t.Fatalf("expected API version 1.22, got %s", v.APIVersion)
+	}
+
+	err = client.ContainerKill(context.Background(), "container_id", "SIGKILL")
+	if !IsErrForbidden(err) || err.Error() != "Error response from daemon: read only client" {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+
+	_, err = client.ContainerAttach(context.Background(), "container_id", types.ContainerAttachOptions{})
+	if !IsErrForbidden(err) {
+		t.Fatalf("expected a forbidden error for a hijacked request, got %v", err)
+	}
+}
+
+func TestInterceptorHijackedRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "No such container: unknown", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var (
+		hijack     bool
+		statusCode int
+	)
+	client, err := NewClientWithOpts(
+		WithHost("tcp://"+server.Listener.Addr().String()),
+		WithInterceptors(InterceptorFunc(func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+			hijack = req.Hijack
+			resp, err := next(ctx, req)
+			if resp != nil {
+				statusCode = resp.StatusCode
+			}
+			return resp, err
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.ContainerAttach(context.Background(), "unknown", types.ContainerAttachOptions{Stream: true})
+	if !IsErrNotFound(err) || err.Error() != "Error response from daemon: No such container: unknown" {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if !hijack {
+		t.Fatalf("expected the request to be marked as hijacked")
+	}
+	if statusCode != http.StatusNotFound {
+		t.Fatalf("expected status code %d, got %d", http.StatusNotFound, statusCode)
+	}
+}
+
+func TestInterceptorSeesVersionNegotiation(t *testing.T) {
+	client := &Client{
+		negotiateVersion: true,
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Proxy-Authorization") != "token" {
+				return errorMock(http.StatusProxyAuthRequired, "proxy authentication required")(req)
+			}
+			body := "{}"
+			if req.URL.Path == "/version" {
+				body = `{"ApiVersion":"1.22"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		}),
+		interceptors: []Interceptor{InterceptorFunc(func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+			req.Header.Set("Proxy-Authorization", "token")
+			return next(ctx, req)
+		})},
+	}
+	if _, err := client.Info(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v := client.ClientVersion(); v != "1.22" {
+		t.Fatalf("expected version 1.22, got %s", v)
+	}
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+)
+
+// layerCompleteStatus holds the statuses that report that a layer
+// has been completely downloaded, uploaded or loaded.
+var layerCompleteStatus = map[string]bool{
+	"Download complete":    true,
+	"Pull complete":        true,
+	"Already exists":       true,
+	"Pushed":               true,
+	"Layer already exists": true,
+}
+
+// layerExtractStatus is the status of the messages that report the progress
+// of the extraction of a layer after downloading it. Its progress doesn't
+// count toward the overall progress, which is about the transferred bytes.
+const layerExtractStatus = "Extracting"
+
+// Progress is the overall progress of the layers reported in a stream of JSON messages.
+// It's the progress of the bytes downloaded, uploaded or loaded, the extraction of the
+// pulled layers is not included.
+type Progress struct {
+	// Current is the number of bytes processed so far, in all the layers.
+	Current int64
+	// Total is the total number of bytes to process, in the layers whose size is known.
+	Total int64
+	// Layers is the number of layers reported in the stream.
+	Layers int
+	// Completed is the number of layers that have been completely processed.
+	Completed int
+}
+
+// Percent returns the percentage of bytes processed so far,
+// it's zero if the total size is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	percent := float64(p.Current) * 100 / float64(p.Total)
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
+
+// layerProgress holds the progress of a layer.
+type layerProgress struct {
+	current   int64
+	total     int64
+	completed bool
+}
+
+// JSONMessageDecoder decodes the streams of JSON messages returned by
+// ImagePull, ImagePush, ImageCreate, ImageImport, ImageLoad and ImageBuild.
+// It keeps track of the progress of the layers, and of the results of
+// the operation reported in the messages.
+type JSONMessageDecoder struct {
+	dec     *json.Decoder
+	layers  map[string]*layerProgress
+	imageID string
+	push    types.PushResult
+}
+
+// NewJSONMessageDecoder creates a new decoder that reads the JSON messages from r.
+func NewJSONMessageDecoder(r io.Reader) *JSONMessageDecoder {
+	return &JSONMessageDecoder{
+		dec:    json.NewDecoder(r),
+		layers: make(map[string]*layerProgress),
+	}
+}
+
+// Decode reads the next message in the stream. It returns io.EOF when
+// the stream ends. When the message reports that the operation failed,
+// it returns the message along with its error, a *types.JSONError.
+func (d *JSONMessageDecoder) Decode() (types.JSONMessage, error) {
+	var msg types.JSONMessage
+	if err := d.dec.Decode(&msg); err != nil {
+		return msg, err
+	}
+
+	if msg.Error == nil && msg.ErrorMessage != "" {
+		msg.Error = &types.JSONError{Message: msg.ErrorMessage}
+	}
+	if msg.Error != nil {
+		return msg, msg.Error
+	}
+
+	d.trackProgress(msg)
+	if err := d.trackResult(msg); err != nil {
+		return msg, err
+	}
+	return msg, nil
+}
+
+// DecodeAll reads all the messages in the stream, and it calls fn with
+// each one of them if it's not nil. It returns nil when the stream ends,
+// or the error of the first message that reports that the operation failed.
+func (d *JSONMessageDecoder) DecodeAll(fn func(types.JSONMessage)) error {
+	for {
+		msg, err := d.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if fn != nil && (err == nil || msg.Error != nil) {
+			fn(msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Progress returns the overall progress of the layers reported so far.
+func (d *JSONMessageDecoder) Progress() Progress {
+	var p Progress
+	for _, l := range d.layers {
+		p.Layers++
+		p.Current += l.current
+		p.Total += l.total
+		if l.completed {
+			p.Completed++
+		}
+	}
+	return p
+}
+
+// ImageID returns the ID of the image built or loaded, if it's been reported so far.
+func (d *JSONMessageDecoder) ImageID() string {
+	return d.imageID
+}
+
+// PushResult returns the result of the image push, and whether it's been reported so far.
+func (d *JSONMessageDecoder) PushResult() (types.PushResult, bool) {
+	return d.push, d.push.Digest != ""
+}
+
+// trackProgress updates the progress of the layer the message is about.
+func (d *JSONMessageDecoder) trackProgress(msg types.JSONMessage) {
+	if msg.ID == "" || msg.Status == layerExtractStatus || (msg.Progress == nil && !layerCompleteStatus[msg.Status]) {
+		return
+	}
+
+	l, ok := d.layers[msg.ID]
+	if !ok {
+		l = &layerProgress{}
+		d.layers[msg.ID] = l
+	}
+	if msg.Progress != nil && msg.Progress.Total > 0 {
+		l.current = msg.Progress.Current
+		if msg.Progress.Total > l.total {
+			l.total = msg.Progress.Total
+		}
+	}
+	if layerCompleteStatus[msg.Status] {
+		l.completed = true
+		l.current = l.total
+	}
+}
+
+// trackResult records the results of the operation reported in the message.
+func (d *JSONMessageDecoder) trackResult(msg types.JSONMessage) error {
+	if msg.Aux != nil {
+		var aux struct {
+			types.BuildResult
+			types.PushResult
+		}
+		if err := json.Unmarshal(*msg.Aux, &aux); err != nil {
+			return err
+		}
+		if aux.ID != "" {
+			d.imageID = aux.ID
+		}
+		if aux.Digest != "" {
+			d.push = aux.PushResult
+		}
+		return nil
+	}
+
+	// Older daemons only report the image ID in the output,
+	// newer ones report the short ID after the full ID in the aux data.
+	if d.imageID != "" {
+		return nil
+	}
+	for _, prefix := range []string{"Successfully built ", "Loaded image ID: "} {
+		for _, s := range []string{msg.Stream, msg.Status} {
+			if strings.HasPrefix(s, prefix) {
+				d.imageID = strings.TrimSpace(strings.TrimPrefix(s, prefix))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+)
+
+const pullStream = `{"status":"Pulling from library/busybox","id":"latest"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"layer1"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"layer2"}
+{"status":"Downloading","progressDetail":{"current":50,"total":100},"progress":"[=====>     ]","id":"layer1"}
+{"status":"Downloading","progressDetail":{"current":100,"total":300},"id":"layer2"}
+{"status":"Download complete","progressDetail":{},"id":"layer1"}
+{"status":"Pull complete","progressDetail":{},"id":"layer1"}
+{"status":"Digest: sha256:abcdef"}
+`
+
+func TestJSONMessageDecoderProgress(t *testing.T) {
+	d := NewJSONMessageDecoder(strings.NewReader(pullStream))
+
+	var statuses []string
+	for {
+		msg, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		statuses = append(statuses, msg.Status)
+		if msg.Status == "Downloading" && msg.ID == "layer1" {
+			if msg.Progress == nil || msg.Progress.Current != 50 || msg.ProgressMessage != "[=====>     ]" {
+				t.Fatalf("expected the progress of the layer, got %+v", msg)
+			}
+			if p := d.Progress(); p.Current != 50 || p.Total != 100 || p.Percent() != 50 {
+				t.Fatalf("expected 50%% progress, got %+v", p)
+			}
+		}
+	}
+
+	if len(statuses) != 8 {
+		t.Fatalf("expected 8 messages, got %d", len(statuses))
+	}
+	p := d.Progress()
+	expected := Progress{Current: 200, Total: 400, Layers: 2, Completed: 1}
+	if p != expected {
+		t.Fatalf("expected %+v, got %+v", expected, p)
+	}
+	if p.Percent() != 50 {
+		t.Fatalf("expected 50%%, got %v", p.Percent())
+	}
+}
+
+func TestJSONMessageDecoderProgressWithExtraction(t *testing.T) {
+	stream := `{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"layer1"}
+{"status":"Downloading","progressDetail":{"current":100,"total":100},"id":"layer1"}
+{"status":"Download complete","progressDetail":{},"id":"layer1"}
+{"status":"Extracting","progressDetail":{"current":10,"total":100},"id":"layer1"}
+{"status":"Extracting","progressDetail":{"current":100,"total":100},"id":"layer1"}
+{"status":"Pull complete","progressDetail":{},"id":"layer1"}
+`
+	d := NewJSONMessageDecoder(strings.NewReader(stream))
+	var last int64
+	err := d.DecodeAll(func(msg types.JSONMessage) {
+		p := d.Progress()
+		if p.Current < last {
+			t.Fatalf("expected the progress not to decrease after %q, got %d after %d", msg.Status, p.Current, last)
+		}
+		last = p.Current
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := d.Progress(); p.Current != 100 || p.Total != 100 || p.Completed != 1 {
+		t.Fatalf("expected the layer to be complete, got %+v", p)
+	}
+}
+
+func TestJSONMessageDecoderErrors(t *testing.T) {
+	cases := map[string]string{
+		"error detail":   `{"errorDetail":{"code":1,"message":"manifest unknown"},"error":"manifest unknown"}`,
+		"error message":  `{"error":"manifest unknown"}`,
+		"after progress": `{"status":"Downloading","progressDetail":{"current":1,"total":2},"id":"layer"}` + "\n" + `{"errorDetail":{"message":"manifest unknown"}}`,
+	}
+
+	for name, stream := range cases {
+		var seen []types.JSONMessage
+		err := NewJSONMessageDecoder(strings.NewReader(stream)).DecodeAll(func(msg types.JSONMessage) {
+			seen = append(seen, msg)
+		})
+		jsonErr, ok := err.(*types.JSONError)
+		if !ok || jsonErr.Message != "manifest unknown" {
+			t.Fatalf("%s: expected a JSON error, got %v", name, err)
+		}
+		if last := seen[len(seen)-1]; last.Error == nil {
+			t.Fatalf("%s: expected the error message to be handled, got %+v", name, last)
+		}
+	}
+
+	err := NewJSONMessageDecoder(strings.NewReader(`{"status":`)).DecodeAll(nil)
+	if err == nil || err == io.EOF {
+		t.Fatalf("expected an error decoding a truncated message, got %v", err)
+	}
+}
+
+func TestJSONMessageDecoderBuildResult(t *testing.T) {
+	stream := `{"stream":"Step 1 : FROM busybox\n"}
+{"stream":" ---> 2b8fd9751c4c\n"}
+{"aux":{"ID":"sha256:2b8fd9751c4c0f5dd266fcae00707e67a2545ef34f9a29354585f93dac906749"}}
+{"stream":"Successfully built 2b8fd9751c4c\n"}
+`
+	d := NewJSONMessageDecoder(strings.NewReader(stream))
+	if err := d.DecodeAll(nil); err != nil {
+		t.Fatal(err)
+	}
+	if id := d.ImageID(); id != "sha256:2b8fd9751c4c0f5dd266fcae00707e67a2545ef34f9a29354585f93dac906749" {
+		t.Fatalf("expected the image ID from the aux data, got %s", id)
+	}
+
+	d = NewJSONMessageDecoder(strings.NewReader(`{"stream":"Successfully built 2b8fd9751c4c\n"}`))
+	if err := d.DecodeAll(nil); err != nil {
+		t.Fatal(err)
+	}
+	if id := d.ImageID(); id != "2b8fd9751c4c" {
+		t.Fatalf("expected the image ID from the output, got %s", id)
+	}
+}
+
+func TestJSONMessageDecoderLoadResult(t *testing.T) {
+	d := NewJSONMessageDecoder(strings.NewReader(`{"status":"Loading layer","progressDetail":{"current":10,"total":10},"id":"layer1"}
+{"stream":"Loaded image ID: sha256:abcdef\n"}`))
+	if err := d.DecodeAll(nil); err != nil {
+		t.Fatal(err)
+	}
+	if d.ImageID() != "sha256:abcdef" {
+		t.Fatalf("expected the loaded image ID, got %s", d.ImageID())
+	}
+	if p := d.Progress(); p.Percent() != 100 {
+		t.Fatalf("expected 100%% progress, got %+v", p)
+	}
+}
+
+func TestJSONMessageDecoderPushResult(t *testing.T) {
+	stream := `{"status":"The push refers to a repository [localhost:5000/app]"}
+{"status":"Pushing","progressDetail":{"current":512,"total":1024},"id":"layer1"}
+{"status":"Pushed","progressDetail":{},"id":"layer1"}
+{"status":"latest: digest: sha256:abcdef size: 527"}
+{"progressDetail":{},"aux":{"Tag":"latest","Digest":"sha256:abcdef","Size":527}}
+`
+	d := NewJSONMessageDecoder(strings.NewReader(stream))
+	if _, ok := d.PushResult(); ok {
+		t.Fatal("expected no push result before decoding the stream")
+	}
+	if err := d.DecodeAll(nil); err != nil {
+		t.Fatal(err)
+	}
+	result, ok := d.PushResult()
+	if !ok || result != (types.PushResult{Tag: "latest", Digest: "sha256:abcdef", Size: 527}) {
+		t.Fatalf("expected the push result, got %+v", result)
+	}
+	if p := d.Progress(); p.Current != 1024 || p.Completed != 1 {
+		t.Fatalf("expected the layer to be completed, got %+v", p)
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/network"
+	"github.com/docker/engine-api/types/versions"
+	"github.com/docker/engine-api/types/versions/v1p19"
+	"github.com/docker/engine-api/types/versions/v1p20"
+)
+
+// v1p19ConfigWrapper is the body of a container create request for APIs older than 1.20.
+type v1p19ConfigWrapper struct {
+	*v1p19.ContainerConfig
+	HostConfig *container.HostConfig
+}
+
+// v1p20ConfigWrapper is the body of a container create request for the API 1.20.
+type v1p20ConfigWrapper struct {
+	*v1p20.ContainerConfig
+	HostConfig *container.HostConfig
+}
+
+// MarshalContainerCreate encodes the body of a container create request for
+// the given API version. Daemons older than API 1.21 expect some options in
+// the config instead of the host config, they are moved to their legacy
+// location. Daemons older than API 1.22 don't support the networking config,
+// it's dropped and the returned warnings describe it. It returns an
+// ErrUnsupportedByVersion for the other options that the version doesn't
+// support, like ContainerCreate.
+// The current body is returned if the version is empty.
+func MarshalContainerCreate(version string, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig) ([]byte, []string, error) {
+	version = strings.TrimPrefix(version, "v")
+	if version != "" {
+		for _, o := range containerCreateOptions(config, hostConfig) {
+			if err := unsupportedOption(version, o); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if version == "" || versions.GreaterThanOrEqualTo(version, "1.22") {
+		b, err := json.Marshal(configWrapper{
+			Config:           config,
+			HostConfig:       hostConfig,
+			NetworkingConfig: networkingConfig,
+		})
+		return b, nil, err
+	}
+
+	var warnings []string
+	if networkingConfig != nil && len(networkingConfig.EndpointsConfig) > 0 {
+		warnings = append(warnings, droppedWarning("NetworkingConfig", version))
+	}
+
+	var body interface{}
+	switch {
+	case versions.LessThan(version, "1.20"):
+		legacyConfig := &v1p19.ContainerConfig{Config: config}
+		if config != nil {
+			legacyConfig.MacAddress = config.MacAddress
+			legacyConfig.NetworkDisabled = config.NetworkDisabled
+			legacyConfig.ExposedPorts = config.ExposedPorts
+		}
+		if hostConfig != nil {
+			legacyConfig.VolumeDriver = hostConfig.VolumeDriver
+			legacyConfig.Memory = hostConfig.Memory
+			legacyConfig.MemorySwap = hostConfig.MemorySwap
+			legacyConfig.CPUShares = hostConfig.CPUShares
+			legacyConfig.CPUSet = hostConfig.CpusetCpus
+		}
+		body = v1p19ConfigWrapper{
+			ContainerConfig: legacyConfig,
+			HostConfig:      hostConfig,
+		}
+	case versions.LessThan(version, "1.21"):
+		legacyConfig := &v1p20.ContainerConfig{Config: config}
+		if config != nil {
+			legacyConfig.MacAddress = config.MacAddress
+			legacyConfig.NetworkDisabled = config.NetworkDisabled
+			legacyConfig.ExposedPorts = config.ExposedPorts
+		}
+		if hostConfig != nil {
+			legacyConfig.VolumeDriver = hostConfig.VolumeDriver
+		}
+		body = v1p20ConfigWrapper{
+			ContainerConfig: legacyConfig,
+			HostConfig:      hostConfig,
+		}
+	default:
+		body = configWrapper{
+			Config:     config,
+			HostConfig: hostConfig,
+		}
+	}
+
+	b, err := json.Marshal(body)
+	return b, warnings, err
+}
+
+// MarshalNetworkConnect encodes the body of a network connect request for
+// the given API version. Daemons older than API 1.22 don't support endpoint
+// settings, they are dropped and the returned warnings describe them.
+// The current body is returned if the version is empty.
+func MarshalNetworkConnect(version, containerID string, config *network.EndpointSettings) ([]byte, []string, error) {
+	version = strings.TrimPrefix(version, "v")
+	nc := types.NetworkConnect{
+		Container:      containerID,
+		EndpointConfig: config,
+	}
+
+	var warnings []string
+	if version != "" && versions.LessThan(version, "1.22") && config != nil {
+		nc.EndpointConfig = nil
+		warnings = append(warnings, droppedWarning("EndpointConfig", version))
+	}
+
+	b, err := json.Marshal(nc)
+	return b, warnings, err
+}
+
+// droppedWarning returns the warning for an option dropped from a request.
+func droppedWarning(option, version string) string {
+	return fmt.Sprintf("%s is not supported by API version %s, it was dropped from the request", option, version)
+}
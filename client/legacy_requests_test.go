@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/network"
+	"github.com/docker/go-connections/nat"
+	"golang.org/x/net/context"
+)
+
+func legacyCreateOptions() (*container.Config, *container.HostConfig, *network.NetworkingConfig) {
+	config := &container.Config{
+		Image:        "busybox",
+		MacAddress:   "02:42:ac:11:00:02",
+		ExposedPorts: map[nat.Port]struct{}{"80/tcp": {}},
+	}
+	hostConfig := &container.HostConfig{
+		VolumeDriver: "flocker",
+	}
+	hostConfig.Memory = 1024
+	hostConfig.CPUShares = 512
+	hostConfig.CpusetCpus = "0,1"
+	networkingConfig := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			"custom": {Aliases: []string{"alias"}},
+		},
+	}
+	return config, hostConfig, networkingConfig
+}
+
+func decodeBody(t *testing.T, b []byte) map[string]interface{} {
+	var body map[string]interface{}
+	if err := json.Unmarshal(b, &body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestMarshalContainerCreateCurrent(t *testing.T) {
+	config, hostConfig, networkingConfig := legacyCreateOptions()
+	for _, version := range []string{"", "1.22", "v1.24"} {
+		b, warnings, err := MarshalContainerCreate(version, config, hostConfig, networkingConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) != 0 {
+			t.Fatalf("expected no warnings with version %q, got %v", version, warnings)
+		}
+		body := decodeBody(t, b)
+		if _, ok := body["NetworkingConfig"].(map[string]interface{}); !ok {
+			t.Fatalf("expected the networking config with version %q, got %v", version, body)
+		}
+		if _, ok := body["VolumeDriver"]; ok {
+			t.Fatalf("expected no volume driver in the config with version %q, got %v", version, body)
+		}
+	}
+}
+
+func TestMarshalContainerCreateV1p21(t *testing.T) {
+	config, hostConfig, networkingConfig := legacyCreateOptions()
+	b, warnings, err := MarshalContainerCreate("1.21", config, hostConfig, networkingConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "NetworkingConfig") {
+		t.Fatalf("expected a warning for the networking config, got %v", warnings)
+	}
+	body := decodeBody(t, b)
+	if body["NetworkingConfig"] != nil {
+		t.Fatalf("expected no networking config, got %v", body)
+	}
+	if body["HostConfig"].(map[string]interface{})["VolumeDriver"] != "flocker" {
+		t.Fatalf("expected the volume driver in the host config, got %v", body["HostConfig"])
+	}
+}
+
+func TestMarshalContainerCreateV1p20(t *testing.T) {
+	config, hostConfig, _ := legacyCreateOptions()
+	b, warnings, err := MarshalContainerCreate("1.20", config, hostConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %v", warnings)
+	}
+	body := decodeBody(t, b)
+	if body["Image"] != "busybox" || body["VolumeDriver"] != "flocker" || body["MacAddress"] != "02:42:ac:11:00:02" {
+		t.Fatalf("expected the legacy fields in the config, got %v", body)
+	}
+	if _, ok := body["ExposedPorts"].(map[string]interface{})["80/tcp"]; !ok {
+		t.Fatalf("expected the exposed ports in the config, got %v", body)
+	}
+	if _, ok := body["Memory"]; ok {
+		t.Fatalf("expected no memory in the config, got %v", body)
+	}
+}
+
+func TestMarshalContainerCreateV1p19(t *testing.T) {
+	config, hostConfig, _ := legacyCreateOptions()
+	b, _, err := MarshalContainerCreate("1.19", config, hostConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := decodeBody(t, b)
+	if body["VolumeDriver"] != "flocker" || body["Memory"] != float64(1024) || body["CpuShares"] != float64(512) || body["Cpuset"] != "0,1" {
+		t.Fatalf("expected the legacy fields in the config, got %v", body)
+	}
+
+	// The config is optional.
+	if _, _, err := MarshalContainerCreate("1.19", nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarshalNetworkConnect(t *testing.T) {
+	config := &network.EndpointSettings{Aliases: []string{"alias"}}
+
+	b, warnings, err := MarshalNetworkConnect("1.22", "container_id", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %v", warnings)
+	}
+	if _, ok := decodeBody(t, b)["EndpointConfig"]; !ok {
+		t.Fatalf("expected the endpoint config, got %s", b)
+	}
+
+	b, warnings, err = MarshalNetworkConnect("1.21", "container_id", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "EndpointConfig") {
+		t.Fatalf("expected a warning for the endpoint config, got %v", warnings)
+	}
+	body := decodeBody(t, b)
+	if _, ok := body["EndpointConfig"]; ok || body["Container"] != "container_id" {
+		t.Fatalf("expected only the container, got %s", b)
+	}
+}
+
+func TestContainerCreateLegacyVersion(t *testing.T) {
+	client := &Client{
+		version: "1.20",
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+			if body["VolumeDriver"] != "flocker" {
+				return nil, fmt.Errorf("expected the volume driver in the config, got %v", body)
+			}
+			if body["NetworkingConfig"] != nil {
+				return nil, fmt.Errorf("expected no networking config, got %v", body)
+			}
+			b, err := json.Marshal(types.ContainerCreateResponse{
+				ID:       "container_id",
+				Warnings: []string{"daemon warning"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	}
+
+	config, hostConfig, networkingConfig := legacyCreateOptions()
+	r, err := client.ContainerCreate(context.Background(), config, hostConfig, networkingConfig, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Warnings) != 2 || !strings.Contains(r.Warnings[0], "NetworkingConfig") || r.Warnings[1] != "daemon warning" {
+		t.Fatalf("unexpected warnings %v", r.Warnings)
+	}
+}
+
+func TestMarshalContainerCreateUnsupportedOption(t *testing.T) {
+	config, hostConfig, _ := legacyCreateOptions()
+	config.StopSignal = "SIGINT"
+	_, _, err := MarshalContainerCreate("1.20", config, hostConfig, nil)
+	if !IsErrUnsupportedByVersion(err) {
+		t.Fatalf("expected an ErrUnsupportedByVersion, got %v", err)
+	}
+}
+
+func TestContainerCreateLegacyVersionUnsupportedOption(t *testing.T) {
+	var requests int
+	client := newVersionedMockClient("1.19", &requests)
+
+	config, hostConfig, networkingConfig := legacyCreateOptions()
+	hostConfig.GroupAdd = []string{"staff"}
+	_, err := client.ContainerCreate(context.Background(), config, hostConfig, networkingConfig, "")
+	if !IsErrUnsupportedByVersion(err) {
+		t.Fatalf("expected an ErrUnsupportedByVersion, got %v", err)
+	}
+	if expected := "HostConfig.GroupAdd requires API version 1.20, but the Docker daemon API version is 1.19"; err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+	if requests != 0 {
+		t.Fatalf("expected no requests, got %d", requests)
+	}
+}
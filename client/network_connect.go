@@ -1,18 +1,24 @@
 package client
 
 import (
-	"github.com/docker/engine-api/types"
+	"encoding/json"
+
 	"github.com/docker/engine-api/types/network"
 	"golang.org/x/net/context"
 )
 
 // NetworkConnect connects a container to an existent network in the docker host.
+// The request is encoded with MarshalNetworkConnect, the endpoint settings
+// are not sent to daemons older than API 1.22.
 func (cli *Client) NetworkConnect(ctx context.Context, networkID, containerID string, config *network.EndpointSettings) error {
-	nc := types.NetworkConnect{
-		Container:      containerID,
-		EndpointConfig: config,
+	if err := cli.checkAPIVersion(ctx); err != nil {
+		return err
+	}
+	body, _, err := MarshalNetworkConnect(cli.ClientVersion(), containerID, config)
+	if err != nil {
+		return err
 	}
-	resp, err := cli.post(ctx, "/networks/"+networkID+"/connect", nil, nc, nil)
+	resp, err := cli.post(ctx, "/networks/"+networkID+"/connect", nil, json.RawMessage(body), nil)
 	ensureReaderClosed(resp)
 	return err
 }
@@ -8,12 +8,20 @@ import (
 )
 
 // NetworkCreate creates a new network in the docker host.
+// It returns an ErrUnsupportedByVersion if the options include fields
+// that the API version of the client doesn't support.
 func (cli *Client) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
 	networkCreateRequest := types.NetworkCreateRequest{
 		NetworkCreate: options,
 		Name:          name,
 	}
 	var response types.NetworkCreateResponse
+	if err := cli.checkOptionsVersion(ctx,
+		versionedOptions{"NetworkCreate", options, []map[string]string{networkCreateVersions}},
+	); err != nil {
+		return response, err
+	}
+
 	serverResp, err := cli.post(ctx, "/networks/create", nil, networkCreateRequest, nil)
 	if err != nil {
 		return response, err
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/go-connections/tlsconfig"
+	"golang.org/x/crypto/ssh"
+)
+
+// Opt is a configuration option to initialize a client.
+type Opt func(*Client) error
+
+// FromEnv configures the client with values from environment variables.
+// Use DOCKER_HOST to set the url to the docker server.
+// Use DOCKER_API_VERSION to set the version of the API to reach, leave empty for latest.
+// Use DOCKER_CERT_PATH to load the tls certificates from.
+// Use DOCKER_TLS_VERIFY to enable or disable TLS verification, off by default.
+func FromEnv(c *Client) error {
+	if dockerCertPath := os.Getenv("DOCKER_CERT_PATH"); dockerCertPath != "" {
+		options := tlsconfig.Options{
+			CAFile:             filepath.Join(dockerCertPath, "ca.pem"),
+			CertFile:           filepath.Join(dockerCertPath, "cert.pem"),
+			KeyFile:            filepath.Join(dockerCertPath, "key.pem"),
+			InsecureSkipVerify: os.Getenv("DOCKER_TLS_VERIFY") == "",
+		}
+		tlsc, err := tlsconfig.Client(options)
+		if err != nil {
+			return err
+		}
+		c.tlsConfig = tlsc
+	}
+
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		if err := WithHost(host)(c); err != nil {
+			return err
+		}
+	}
+
+	if version := os.Getenv("DOCKER_API_VERSION"); version != "" {
+		c.version = version
+	}
+	return nil
+}
+
+// WithHost sets the docker server address the client connects to.
+// Use ssh://user@host:port/path/to/docker.sock to connect to the docker socket
+// in a remote host over ssh, the port and the socket path are optional.
+func WithHost(host string) Opt {
+	return func(c *Client) error {
+		proto, addr, basePath, err := ParseHost(host)
+		if err != nil {
+			return err
+		}
+		c.host = host
+		c.proto = proto
+		c.addr = addr
+		c.basePath = basePath
+		return nil
+	}
+}
+
+// WithVersion sets the API version the client sends in every request.
+// It won't send any version information if the version is empty.
+// When the version is negotiated, it sets the highest version the client uses.
+func WithVersion(version string) Opt {
+	return func(c *Client) error {
+		c.version = version
+		return nil
+	}
+}
+
+// WithAPIVersionNegotiation enables the API version negotiation.
+// The client pings the server before sending its first request and
+// it uses the highest API version that both, client and server, support.
+// The ping goes through the interceptors, like the retry policy.
+func WithAPIVersionNegotiation() Opt {
+	return func(c *Client) error {
+		c.negotiateVersion = true
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http client used to send requests.
+// The client's transport is not modified.
+// A nil client keeps Docker's default http transport configuration.
+// It cannot be used with ssh hosts, their connections go through the ssh tunnel.
+func WithHTTPClient(client *http.Client) Opt {
+	return func(c *Client) error {
+		if client != nil {
+			c.client = client
+		}
+		return nil
+	}
+}
+
+// WithTLSClientConfig loads the TLS certificates from the given paths
+// and uses them to configure the default http transport.
+// When the client uses a custom http client, its transport is not modified,
+// the TLS configuration is only used to secure hijacked connections
+// and to send requests with the https scheme.
+func WithTLSClientConfig(cacertPath, certPath, keyPath string) Opt {
+	return func(c *Client) error {
+		tlsc, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:   cacertPath,
+			CertFile: certPath,
+			KeyFile:  keyPath,
+		})
+		if err != nil {
+			return err
+		}
+		c.tlsConfig = tlsc
+		return nil
+	}
+}
+
+// WithHTTPHeaders sets the custom http headers to add to each request.
+func WithHTTPHeaders(headers map[string]string) Opt {
+	return func(c *Client) error {
+		c.customHTTPHeaders = headers
+		return nil
+	}
+}
+
+// WithTimeout sets the time limit for requests made by the client.
+// The timeout includes reading the response body, so it also
+// interrupts streams like the ones returned by ContainerLogs or Events.
+func WithTimeout(timeout time.Duration) Opt {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithHandshakeTimeout sets the time limit to dial the server and upgrade the connection
+// when attaching to containers and exec processes. It's 32 seconds by default.
+// The hijacked connection is not limited by this timeout once it's established.
+func WithHandshakeTimeout(timeout time.Duration) Opt {
+	return func(c *Client) error {
+		c.handshakeTimeout = timeout
+		return nil
+	}
+}
+
+// WithDialer sets the dialer used to open connections to the server,
+// including hijacked connections.
+func WithDialer(dialer *net.Dialer) Opt {
+	return func(c *Client) error {
+		c.dialer = dialer
+		return nil
+	}
+}
+
+// WithSSHClientConfig sets the ssh configuration used to connect to ssh hosts.
+// The user in the host address takes precedence over the user in the configuration.
+// Use NewSSHClientConfig to create a configuration with key based authentication
+// and known hosts checking. The client uses the keys in the ssh agent and in ~/.ssh,
+// and it checks the host keys against ~/.ssh/known_hosts by default.
+func WithSSHClientConfig(config *ssh.ClientConfig) Opt {
+	return func(c *Client) error {
+		c.sshConfig = config
+		return nil
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewClientWithOptsDefaults(t *testing.T) {
+	client, err := NewClientWithOpts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.host != DefaultDockerHost {
+		t.Fatalf("expected host %s, got %s", DefaultDockerHost, client.host)
+	}
+	if client.version != "" {
+		t.Fatalf("expected an empty version, got %s", client.version)
+	}
+	if client.transport.Secure() {
+		t.Fatalf("expected an insecure transport")
+	}
+}
+
+func TestNewClientWithOpts(t *testing.T) {
+	headers := map[string]string{"User-Agent": "engine-api-test"}
+	client, err := NewClientWithOpts(
+		WithHost("tcp://localhost:2476/path"),
+		WithVersion("1.22"),
+		WithHTTPHeaders(headers),
+		WithAPIVersionNegotiation(),
+		WithDialer(&net.Dialer{Timeout: time.Second}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.proto != "tcp" || client.addr != "localhost:2476" || client.basePath != "/path" {
+		t.Fatalf("unexpected host configuration: %s %s %s", client.proto, client.addr, client.basePath)
+	}
+	if client.version != "1.22" {
+		t.Fatalf("expected version 1.22, got %s", client.version)
+	}
+	if client.customHTTPHeaders["User-Agent"] != "engine-api-test" {
+		t.Fatalf("expected custom http headers to be set, got %v", client.customHTTPHeaders)
+	}
+	if !client.negotiateVersion {
+		t.Fatalf("expected version negotiation to be enabled")
+	}
+	if client.dialer == nil || client.dialer.Timeout != time.Second {
+		t.Fatalf("expected dialer to be set, got %v", client.dialer)
+	}
+}
+
+func TestNewClientWithOptsInvalidHost(t *testing.T) {
+	if _, err := NewClientWithOpts(WithHost("foobar")); err == nil {
+		t.Fatalf("expected an error parsing the host, got nil")
+	}
+}
+
+func TestWithHTTPClientTimeout(t *testing.T) {
+	httpClient := &http.Client{
+		Transport: &http.Transport{},
+	}
+	_, err := NewClientWithOpts(WithHTTPClient(httpClient), WithTimeout(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if httpClient.Timeout != 0 {
+		t.Fatalf("expected the custom http client not to be modified, got timeout %v", httpClient.Timeout)
+	}
+}
+
+func TestWithTLSClientConfigAndHTTPClient(t *testing.T) {
+	client := &Client{}
+	if err := WithTLSClientConfig("", "", "")(client); err != nil {
+		t.Fatal(err)
+	}
+	if client.tlsConfig == nil {
+		t.Fatalf("expected a TLS configuration")
+	}
+
+	tr := &http.Transport{}
+	custom, err := NewClientWithOpts(
+		WithHost("tcp://localhost:2476"),
+		WithTLSClientConfig("", "", ""),
+		WithHTTPClient(&http.Client{Transport: tr}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !custom.transport.Secure() || custom.transport.Scheme() != "https" {
+		t.Fatalf("expected a secure transport")
+	}
+	if tr.TLSClientConfig != nil {
+		t.Fatalf("expected the custom transport not to be modified")
+	}
+
+	secure, err := NewClientWithOpts(WithHost("tcp://localhost:2476"), WithTLSClientConfig("", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !secure.transport.Secure() {
+		t.Fatalf("expected a secure transport")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{
+		"DOCKER_HOST":        "tcp://localhost:2376",
+		"DOCKER_API_VERSION": "1.21",
+		"DOCKER_CERT_PATH":   "",
+		"DOCKER_TLS_VERIFY":  "",
+	}
+	for k, v := range env {
+		old := os.Getenv(k)
+		os.Setenv(k, v)
+		defer os.Setenv(k, old)
+	}
+
+	client, err := NewEnvClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.host != "tcp://localhost:2376" {
+		t.Fatalf("expected host tcp://localhost:2376, got %s", client.host)
+	}
+	if client.version != "1.21" {
+		t.Fatalf("expected version 1.21, got %s", client.version)
+	}
+
+	os.Setenv("DOCKER_CERT_PATH", "invalid/path")
+	if _, err := NewEnvClient(); err == nil {
+		t.Fatalf("expected an error loading certificates from an invalid path, got nil")
+	}
+}
+
+func TestFromEnvKeepsVersion(t *testing.T) {
+	old := os.Getenv("DOCKER_API_VERSION")
+	os.Setenv("DOCKER_API_VERSION", "")
+	defer os.Setenv("DOCKER_API_VERSION", old)
+
+	client, err := NewClientWithOpts(WithVersion("1.22"), FromEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.version != "1.22" {
+		t.Fatalf("expected version 1.22, got %s", client.version)
+	}
+}
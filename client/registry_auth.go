@@ -0,0 +1,100 @@
+package client
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/engine-api/client/auth"
+	"github.com/docker/engine-api/types"
+)
+
+// AuthProvider provides the credentials to authenticate with registries.
+// The client asks for the credentials of the registry that hosts the images
+// it pulls, pushes, creates and searches, when the options of the operation
+// don't include them. See the auth package for a provider that uses the
+// docker cli configuration.
+type AuthProvider interface {
+	// AuthConfig returns the credentials for the registry with the given hostname.
+	AuthConfig(ctx context.Context, hostname string) (types.AuthConfig, error)
+	// RefreshAuthConfig returns new credentials for the registry with the given hostname,
+	// after the registry rejected the credentials that the client sent.
+	// The client tries one more time with the new credentials, if they are different.
+	RefreshAuthConfig(ctx context.Context, hostname string, rejected types.AuthConfig) (types.AuthConfig, error)
+	// AllAuthConfigs returns the credentials for all the registries it knows, by registry.
+	// They are sent to build images, which may pull images from any registry.
+	AllAuthConfigs(ctx context.Context) (map[string]types.AuthConfig, error)
+}
+
+// WithAuthProvider sets the provider of the credentials to authenticate with registries.
+func WithAuthProvider(provider AuthProvider) Opt {
+	return func(c *Client) error {
+		c.authProvider = provider
+		return nil
+	}
+}
+
+// registryHostname returns the hostname of the registry that hosts the image,
+// to get its credentials from the auth provider. The reference is passed through
+// unchanged to the docker host when the client doesn't have an auth provider.
+func (cli *Client) registryHostname(ref string) (string, error) {
+	if cli.authProvider == nil {
+		return "", nil
+	}
+	return auth.ParseRegistryHostname(ref)
+}
+
+// tryWithRegistryAuth sends a request with the credentials for the registry with the given hostname.
+// It gets the credentials from the auth provider if the registryAuth is empty.
+// When the registry rejects the credentials, it asks for new credentials to the privilege function,
+// or to the auth provider if the function is nil, and it tries one more time.
+func (cli *Client) tryWithRegistryAuth(ctx context.Context, hostname, registryAuth string, privilegeFunc types.RequestPrivilegeFunc, try func(registryAuth string) (*serverResponse, error)) (*serverResponse, error) {
+	fromProvider := registryAuth == "" && cli.authProvider != nil
+
+	var authConfig types.AuthConfig
+	if fromProvider {
+		var err error
+		authConfig, err = cli.authProvider.AuthConfig(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		if registryAuth, err = auth.EncodeAuthConfig(authConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := try(registryAuth)
+	if resp == nil || resp.statusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	if privilegeFunc != nil {
+		newAuthHeader, privilegeErr := privilegeFunc()
+		if privilegeErr != nil {
+			return nil, privilegeErr
+		}
+		return try(newAuthHeader)
+	}
+
+	if cli.authProvider == nil {
+		return resp, err
+	}
+	if !fromProvider {
+		var decodeErr error
+		if authConfig, decodeErr = auth.DecodeAuthConfig(registryAuth); decodeErr != nil {
+			return nil, decodeErr
+		}
+	}
+	refreshed, refreshErr := cli.authProvider.RefreshAuthConfig(ctx, hostname, authConfig)
+	if refreshErr != nil {
+		return nil, refreshErr
+	}
+	if refreshed == authConfig {
+		return resp, err
+	}
+	newAuthHeader, encodeErr := auth.EncodeAuthConfig(refreshed)
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	return try(newAuthHeader)
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/engine-api/client/auth"
+	"github.com/docker/engine-api/types"
+)
+
+// fakeAuthProvider hands out a new token every time the credentials are refreshed.
+type fakeAuthProvider struct {
+	hostnames []string
+	refreshes int
+}
+
+func (p *fakeAuthProvider) AuthConfig(ctx context.Context, hostname string) (types.AuthConfig, error) {
+	p.hostnames = append(p.hostnames, hostname)
+	return types.AuthConfig{RegistryToken: "token0", ServerAddress: hostname}, nil
+}
+
+func (p *fakeAuthProvider) RefreshAuthConfig(ctx context.Context, hostname string, rejected types.AuthConfig) (types.AuthConfig, error) {
+	p.refreshes++
+	return types.AuthConfig{RegistryToken: fmt.Sprintf("token%d", p.refreshes), ServerAddress: hostname}, nil
+}
+
+func (p *fakeAuthProvider) AllAuthConfigs(ctx context.Context) (map[string]types.AuthConfig, error) {
+	return map[string]types.AuthConfig{
+		"registry.example.com": {Username: "user", Password: "secret"},
+	}, nil
+}
+
+// registryAuthMock accepts the requests with the given registry token.
+func registryAuthMock(token string, tokens *[]string) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		authConfig, err := auth.DecodeAuthConfig(req.Header.Get("X-Registry-Auth"))
+		if err != nil {
+			return nil, err
+		}
+		*tokens = append(*tokens, authConfig.RegistryToken)
+		if authConfig.RegistryToken != token {
+			return errorMock(http.StatusUnauthorized, "Unauthorized")(req)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("[]"))),
+		}, nil
+	}
+}
+
+func TestRegistryAuthFromProvider(t *testing.T) {
+	cases := []struct {
+		name     string
+		hostname string
+		call     func(*Client) error
+	}{
+		{"pull", "registry.example.com", func(c *Client) error {
+			_, err := c.ImagePull(context.Background(), "registry.example.com/app:latest", types.ImagePullOptions{})
+			return err
+		}},
+		{"push", "localhost:5000", func(c *Client) error {
+			_, err := c.ImagePush(context.Background(), "localhost:5000/app:latest", types.ImagePushOptions{})
+			return err
+		}},
+		{"create", auth.IndexHostname, func(c *Client) error {
+			_, err := c.ImageCreate(context.Background(), "docker.io/library/ubuntu:latest", types.ImageCreateOptions{})
+			return err
+		}},
+		{"search", "registry.example.com", func(c *Client) error {
+			_, err := c.ImageSearch(context.Background(), "registry.example.com/app", types.ImageSearchOptions{})
+			return err
+		}},
+	}
+
+	for _, cs := range cases {
+		var tokens []string
+		provider := &fakeAuthProvider{}
+		client := &Client{
+			transport:    newMockClient(nil, registryAuthMock("token1", &tokens)),
+			authProvider: provider,
+		}
+		if err := cs.call(client); err != nil {
+			t.Fatalf("%s: %v", cs.name, err)
+		}
+		if len(provider.hostnames) != 1 || provider.hostnames[0] != cs.hostname {
+			t.Fatalf("%s: expected the credentials for %s, got %v", cs.name, cs.hostname, provider.hostnames)
+		}
+		if strings.Join(tokens, ",") != "token0,token1" {
+			t.Fatalf("%s: expected the refreshed token to be sent, got %v", cs.name, tokens)
+		}
+	}
+}
+
+func TestRegistryAuthRefreshesOnce(t *testing.T) {
+	var tokens []string
+	client := &Client{
+		transport:    newMockClient(nil, registryAuthMock("token5", &tokens)),
+		authProvider: &fakeAuthProvider{},
+	}
+	_, err := client.ImagePull(context.Background(), "registry.example.com/app:latest", types.ImagePullOptions{})
+	if !IsErrUnauthorized(err) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 attempts, got %v", tokens)
+	}
+}
+
+func TestRegistryAuthPrivilegeFuncTakesPrecedence(t *testing.T) {
+	var tokens []string
+	provider := &fakeAuthProvider{}
+	client := &Client{
+		transport:    newMockClient(nil, registryAuthMock("privileged", &tokens)),
+		authProvider: provider,
+	}
+
+	registryAuth, err := auth.EncodeAuthConfig(types.AuthConfig{RegistryToken: "explicit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	privilegeFunc := func() (string, error) {
+		return auth.EncodeAuthConfig(types.AuthConfig{RegistryToken: "privileged"})
+	}
+	_, err = client.ImagePull(context.Background(), "registry.example.com/app:latest", types.ImagePullOptions{
+		RegistryAuth:  registryAuth,
+		PrivilegeFunc: privilegeFunc,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tokens, ",") != "explicit,privileged" {
+		t.Fatalf("expected the explicit and privileged tokens to be sent, got %v", tokens)
+	}
+	if len(provider.hostnames) != 0 || provider.refreshes != 0 {
+		t.Fatalf("expected the auth provider not to be used")
+	}
+}
+
+func TestRegistryAuthWithoutRetry(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, errorMock(http.StatusUnauthorized, "Unauthorized")),
+	}
+	_, err := client.ImageSearch(context.Background(), "ubuntu", types.ImageSearchOptions{})
+	if !IsErrUnauthorized(err) {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestRegistryHostnameWithoutProvider(t *testing.T) {
+	client := &Client{}
+	hostname, err := client.registryHostname("registry.example.com/App")
+	if err != nil || hostname != "" {
+		t.Fatalf("expected the reference not to be parsed without an auth provider, got %q and %v", hostname, err)
+	}
+
+	client.authProvider = &fakeAuthProvider{}
+	if _, err := client.registryHostname("registry.example.com/App"); err == nil {
+		t.Fatal("expected an error parsing an invalid reference with an auth provider")
+	}
+	hostname, err = client.registryHostname("registry.example.com/app")
+	if err != nil || hostname != "registry.example.com" {
+		t.Fatalf("expected registry.example.com, got %q and %v", hostname, err)
+	}
+}
+
+func TestImageBuildAuthConfigsFromProvider(t *testing.T) {
+	client := &Client{
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			buf, err := base64.URLEncoding.DecodeString(req.Header.Get("X-Registry-Config"))
+			if err != nil {
+				return nil, err
+			}
+			var authConfigs map[string]types.AuthConfig
+			if err := json.Unmarshal(buf, &authConfigs); err != nil {
+				return nil, err
+			}
+			if authConfigs["registry.example.com"].Username != "user" {
+				return nil, fmt.Errorf("expected the credentials from the provider, got %v", authConfigs)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}, nil
+		}),
+		authProvider: &fakeAuthProvider{},
+	}
+	if _, err := client.ImageBuild(context.Background(), nil, types.ImageBuildOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
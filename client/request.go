@@ -59,11 +59,11 @@ func (cli *Client) sendRequest(ctx context.Context, method, path string, query u
 	var body io.Reader
 
 	if obj != nil {
-		var err error
-		body, err = encodeData(obj)
+		buf, err := encodeData(obj)
 		if err != nil {
 			return nil, err
 		}
+		body = bytes.NewReader(buf.Bytes())
 		if headers == nil {
 			headers = make(map[string][]string)
 		}
@@ -74,32 +74,120 @@ func (cli *Client) sendRequest(ctx context.Context, method, path string, query u
 }
 
 func (cli *Client) sendClientRequest(ctx context.Context, method, path string, query url.Values, body io.Reader, headers map[string][]string) (*serverResponse, error) {
+	return cli.sendAPIRequestWithInterceptors(ctx, cli.newAPIRequest(method, path, query, body, headers))
+}
+
+// getStream sends an http request to the docker API using the method GET.
+// The response body is streamed to the caller, so the request is never retried.
+func (cli *Client) getStream(ctx context.Context, path string, query url.Values, headers map[string][]string) (*serverResponse, error) {
+	apiReq := cli.newAPIRequest("GET", path, query, nil, headers)
+	apiReq.Stream = true
+	return cli.sendAPIRequestWithInterceptors(ctx, apiReq)
+}
+
+// newAPIRequest creates a new request to the docker API.
+func (cli *Client) newAPIRequest(method, path string, query url.Values, body io.Reader, headers map[string][]string) *APIRequest {
+	expectedPayload := (method == "POST" || method == "PUT")
+	if expectedPayload && body == nil {
+		body = bytes.NewReader([]byte{})
+	}
+
+	apiReq := &APIRequest{
+		Method: method,
+		Path:   path,
+		Query:  query,
+		Header: cli.requestHeaders(headers),
+		Body:   body,
+	}
+	if expectedPayload && apiReq.Header.Get("Content-Type") == "" {
+		apiReq.Header.Set("Content-Type", "text/plain")
+	}
+	return apiReq
+}
+
+// sendAPIRequestWithInterceptors sends the request through the chain of interceptors.
+func (cli *Client) sendAPIRequestWithInterceptors(ctx context.Context, apiReq *APIRequest) (*serverResponse, error) {
 	serverResp := &serverResponse{
 		body:       nil,
 		statusCode: -1,
 	}
 
-	expectedPayload := (method == "POST" || method == "PUT")
-	if expectedPayload && body == nil {
-		body = bytes.NewReader([]byte{})
+	if err := cli.checkAPIVersion(ctx); err != nil {
+		return serverResp, err
 	}
 
-	req, err := cli.newRequest(method, path, query, body, headers)
-	if cli.proto == "unix" || cli.proto == "npipe" {
-		// For local communications, it doesn't matter what the host is. We just
+	resp, err := cli.intercept(ctx, apiReq, cli.sendAPIRequest)
+	if resp != nil {
+		serverResp.statusCode = resp.StatusCode
+		serverResp.header = resp.Header
+		if err == nil {
+			serverResp.body = resp.Body
+		}
+	}
+	return serverResp, err
+}
+
+// sendAPIRequest sends a request to the docker API,
+// it's the last step in the chain of interceptors.
+func (cli *Client) sendAPIRequest(ctx context.Context, apiReq *APIRequest) (*APIResponse, error) {
+	req, err := cli.newRequest(apiReq.Method, apiReq.Path, apiReq.Query, apiReq.Body, apiReq.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	serverResp, err := cli.doRequest(ctx, req, apiReq.Path, cli.ClientVersion())
+	return &APIResponse{
+		StatusCode: serverResp.statusCode,
+		Header:     serverResp.header,
+		Body:       serverResp.body,
+	}, err
+}
+
+// sendUnversionedRequest sends a request to an endpoint that is available
+// in every API version, like /version, without the version prefix.
+func (cli *Client) sendUnversionedRequest(ctx context.Context, apiReq *APIRequest) (*APIResponse, error) {
+	u := &url.URL{Path: cli.basePath + apiReq.Path}
+	if len(apiReq.Query) > 0 {
+		u.RawQuery = apiReq.Query.Encode()
+	}
+	req, err := http.NewRequest(apiReq.Method, u.String(), apiReq.Body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range apiReq.Header {
+		req.Header[k] = v
+	}
+
+	serverResp, err := cli.doRequest(ctx, req, apiReq.Path, "")
+	return &APIResponse{
+		StatusCode: serverResp.statusCode,
+		Header:     serverResp.header,
+		Body:       serverResp.body,
+	}, err
+}
+
+// doRequest sends an already built request to the docker API.
+// It converts unsuccessful responses into errors that include
+// the given API path and version.
+func (cli *Client) doRequest(ctx context.Context, req *http.Request, path, version string) (*serverResponse, error) {
+	serverResp := &serverResponse{
+		body:       nil,
+		statusCode: -1,
+	}
+
+	if cli.proto == "unix" || cli.proto == "npipe" || cli.proto == "ssh" {
+		// For local communications, and the ones tunneled to a remote unix socket,
+		// it doesn't matter what the host is. We just
 		// need a valid and meaningful host name. (See #189)
 		req.Host = "docker"
 	}
 	req.URL.Host = cli.addr
 	req.URL.Scheme = cli.transport.Scheme()
 
-	if expectedPayload && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "text/plain")
-	}
-
 	resp, err := cancellable.Do(ctx, cli.transport, req)
 	if resp != nil {
 		serverResp.statusCode = resp.StatusCode
+		serverResp.header = resp.Header
 	}
 
 	if err != nil {
@@ -114,45 +202,56 @@ func (cli *Client) sendClientRequest(ctx context.Context, method, path string, q
 			return serverResp, fmt.Errorf("The server probably has client authentication (--tlsverify) enabled. Please check your TLS client certification settings: %v", err)
 		}
 
-		return serverResp, fmt.Errorf("An error occurred trying to connect: %v", err)
+		return serverResp, connectionError{err}
 	}
 
-	if serverResp.statusCode < 200 || serverResp.statusCode >= 400 {
+	if isErrorStatus(serverResp.statusCode) {
 		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
 			return serverResp, err
 		}
-		if len(body) == 0 {
-			return serverResp, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(serverResp.statusCode), req.URL)
-		}
-		return serverResp, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(body))
+		return serverResp, newServerError(serverResp.statusCode, req.Method, path, version, req.URL.String(), body)
 	}
 
 	serverResp.body = resp.Body
-	serverResp.header = resp.Header
 	return serverResp, nil
 }
 
-func (cli *Client) newRequest(method, path string, query url.Values, body io.Reader, headers map[string][]string) (*http.Request, error) {
+// isErrorStatus returns true if the status code
+// is not a successful response from the docker API.
+// Not modified responses are successful, like
+// starting a container that is already running.
+func isErrorStatus(statusCode int) bool {
+	return statusCode < 200 || statusCode >= 400
+}
+
+func (cli *Client) newRequest(method, path string, query url.Values, body io.Reader, headers http.Header) (*http.Request, error) {
 	apiPath := cli.getAPIPath(path, query)
 	req, err := http.NewRequest(method, apiPath, body)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	return req, nil
+}
+
+// requestHeaders returns the http headers to send in a request.
+func (cli *Client) requestHeaders(headers map[string][]string) http.Header {
+	h := http.Header{}
 
 	// Add CLI Config's HTTP Headers BEFORE we set the Docker headers
 	// then the user can't change OUR headers
 	for k, v := range cli.customHTTPHeaders {
-		req.Header.Set(k, v)
+		h.Set(k, v)
 	}
 
-	if headers != nil {
-		for k, v := range headers {
-			req.Header[k] = v
-		}
+	for k, v := range headers {
+		h[k] = v
 	}
-
-	return req, nil
+	return h
 }
 
 func encodeData(data interface{}) (*bytes.Buffer, error) {
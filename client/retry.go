@@ -0,0 +1,140 @@
+package client
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RetryPolicy configures how the client retries requests
+// that fail with transient errors, like when the daemon is restarting.
+// Streaming and hijacked requests are never retried.
+// It implements the Interceptor interface.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the time to wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum time to wait between two attempts.
+	MaxBackoff time.Duration
+	// Methods holds the http methods of the requests that can be retried.
+	Methods []string
+	// StatusCodes holds the status codes of the responses that can be retried.
+	StatusCodes []int
+	// RetryableError returns true if a request that failed with the error can be retried.
+	// Errors are not retried if it's nil, only status codes are.
+	RetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy returns a retry policy that retries read only requests,
+// like inspect and list calls, when the client cannot connect to the server
+// or the server is temporarily unavailable.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Methods:        []string{"GET", "HEAD"},
+		StatusCodes: []int{
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableError: IsErrConnectionFailed,
+	}
+}
+
+// WithRetryPolicy sets the policy to retry requests that fail with transient errors.
+// The policy is added to the client's interceptors.
+func WithRetryPolicy(policy RetryPolicy) Opt {
+	return WithInterceptors(policy)
+}
+
+// Intercept sends the request and retries it following the policy.
+// It stops retrying when the context is done, or when it would be done
+// before the next attempt.
+func (p RetryPolicy) Intercept(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+	if !p.canRetry(req) {
+		return next(ctx, req)
+	}
+
+	var (
+		resp *APIResponse
+		err  error
+	)
+	for attempt := 1; ; attempt++ {
+		resp, err = next(ctx, req)
+		if err == nil || attempt >= p.MaxAttempts || !p.retryable(resp, err) {
+			return resp, err
+		}
+
+		backoff := p.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(backoff).After(deadline) {
+			return resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return resp, err
+		case <-time.After(backoff):
+		}
+
+		if seeker, ok := req.Body.(io.Seeker); ok {
+			if _, seekErr := seeker.Seek(0, 0); seekErr != nil {
+				return resp, err
+			}
+		}
+	}
+}
+
+// canRetry returns true if the policy can retry the request.
+func (p RetryPolicy) canRetry(req *APIRequest) bool {
+	if req.Hijack || req.Stream || p.MaxAttempts < 2 {
+		return false
+	}
+	// The body of the request must be sent again in every attempt.
+	if req.Body != nil {
+		if _, ok := req.Body.(io.Seeker); !ok {
+			return false
+		}
+	}
+	for _, m := range p.Methods {
+		if m == req.Method {
+			return true
+		}
+	}
+	return false
+}
+
+// retryable returns true if the failed request can be retried.
+func (p RetryPolicy) retryable(resp *APIResponse, err error) bool {
+	if resp != nil {
+		for _, code := range p.StatusCodes {
+			if code == resp.StatusCode {
+				return true
+			}
+		}
+	}
+	return p.RetryableError != nil && p.RetryableError(err)
+}
+
+// backoff returns the time to wait after the given attempt.
+// It grows exponentially, with a random jitter to avoid
+// retrying many requests at the same time.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff == 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	// Wait at least half of the backoff, and a random time up to the full backoff.
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
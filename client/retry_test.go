@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+func flakyMock(failures int, statusCode int, err error, attempts *int) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		*attempts++
+		if *attempts <= failures {
+			if err != nil {
+				return nil, err
+			}
+			return errorMock(statusCode, "")(req)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		}, nil
+	}
+}
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 2 * time.Millisecond
+	return policy
+}
+
+func TestRetryPolicyRetriesStatusCodes(t *testing.T) {
+	var attempts int
+	client := &Client{
+		transport:    newMockClient(nil, flakyMock(2, http.StatusServiceUnavailable, nil, &attempts)),
+		interceptors: []Interceptor{testRetryPolicy()},
+	}
+	if _, err := client.ContainerInspect(context.Background(), "container_id"); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryPolicyRetriesConnectionErrors(t *testing.T) {
+	var attempts int
+	client := &Client{
+		transport:    newMockClient(nil, flakyMock(1, 0, errors.New("connection reset by peer"), &attempts)),
+		interceptors: []Interceptor{testRetryPolicy()},
+	}
+	if _, err := client.Info(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	var attempts int
+	client := &Client{
+		transport:    newMockClient(nil, flakyMock(5, http.StatusServiceUnavailable, nil, &attempts)),
+		interceptors: []Interceptor{testRetryPolicy()},
+	}
+	_, err := client.ContainerInspect(context.Background(), "container_id")
+	if !IsErrUnavailable(err) {
+		t.Fatalf("expected an unavailable error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryPolicyDoesNotRetry(t *testing.T) {
+	cases := []struct {
+		name string
+		call func(*Client) error
+	}{
+		{"non retryable status", func(c *Client) error {
+			c.transport = newMockClient(nil, errorMock(http.StatusInternalServerError, "Server error"))
+			_, err := c.ContainerInspect(context.Background(), "container_id")
+			return err
+		}},
+		{"non idempotent method", func(c *Client) error {
+			return c.ContainerStart(context.Background(), "container_id")
+		}},
+		{"streaming request", func(c *Client) error {
+			_, err := c.ContainerLogs(context.Background(), "container_id", types.ContainerLogsOptions{})
+			return err
+		}},
+		{"hijacked request", func(c *Client) error {
+			_, err := c.ContainerAttach(context.Background(), "container_id", types.ContainerAttachOptions{})
+			return err
+		}},
+	}
+
+	for _, cs := range cases {
+		var attempts int
+		client := &Client{
+			proto:        "tcp",
+			addr:         "127.0.0.1:0",
+			transport:    newMockClient(nil, flakyMock(5, http.StatusServiceUnavailable, nil, &attempts)),
+			interceptors: []Interceptor{testRetryPolicy()},
+		}
+		client.interceptors = append(client.interceptors, InterceptorFunc(func(ctx context.Context, req *APIRequest, next SendFunc) (*APIResponse, error) {
+			attempts++
+			if req.Hijack {
+				return &APIResponse{StatusCode: http.StatusServiceUnavailable}, nil
+			}
+			return next(ctx, req)
+		}))
+		if err := cs.call(client); err == nil {
+			t.Fatalf("%s: expected an error, got nil", cs.name)
+		}
+		if attempts > 2 {
+			t.Fatalf("%s: expected the request not to be retried, got %d attempts", cs.name, attempts)
+		}
+	}
+}
+
+func TestRetryPolicyRespectsContextDeadline(t *testing.T) {
+	var attempts int
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+	client := &Client{
+		transport:    newMockClient(nil, flakyMock(5, http.StatusServiceUnavailable, nil, &attempts)),
+		interceptors: []Interceptor{policy},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := client.ContainerInspect(ctx, "container_id")
+	if !IsErrUnavailable(err) {
+		t.Fatalf("expected an unavailable error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", attempts)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected the client not to wait for a backoff beyond the deadline")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	cases := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, cs := range cases {
+		for i := 0; i < 10; i++ {
+			b := policy.backoff(cs.attempt)
+			if b < cs.max/2 || b > cs.max {
+				t.Fatalf("expected backoff for attempt %d between %v and %v, got %v", cs.attempt, cs.max/2, cs.max, b)
+			}
+		}
+	}
+}
+
+func TestRetryPolicyRetriesVersionNegotiation(t *testing.T) {
+	var attempts int
+	client := &Client{
+		negotiateVersion: true,
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			attempts++
+			switch {
+			case attempts == 1:
+				// The daemon is restarting.
+				return nil, errors.New("dial tcp: connection refused")
+			case req.URL.Path == "/version":
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"ApiVersion":"1.22"}`))),
+				}, nil
+			case req.URL.Path == "/v1.22/info":
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+				}, nil
+			}
+			return nil, fmt.Errorf("unexpected request %s", req.URL.Path)
+		}),
+		interceptors: []Interceptor{testRetryPolicy()},
+	}
+	if _, err := client.Info(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+	if v := client.ClientVersion(); v != "1.22" {
+		t.Fatalf("expected version 1.22, got %s", v)
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// defaultSSHPort is the port used to connect to ssh hosts that don't specify one.
+	defaultSSHPort = "22"
+	// defaultSSHSocket is the path to the docker socket in ssh hosts that don't specify one.
+	defaultSSHSocket = "/var/run/docker.sock"
+	// defaultSSHHandshakeTimeout is the time to wait for the ssh handshake when
+	// the ssh configuration doesn't set a timeout.
+	defaultSSHHandshakeTimeout = 32 * time.Second
+)
+
+// parseSSHHost parses the address of a docker server reachable over ssh,
+// i.e. ssh://user@host:port/var/run/docker.sock. The port and the path
+// to the unix socket in the remote host are optional.
+func parseSSHHost(host string) (user, addr, socket string, err error) {
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return "", "", "", err
+	}
+	if parsed.Scheme != "ssh" || parsed.Hostname() == "" {
+		return "", "", "", fmt.Errorf("unable to parse ssh host `%s`", host)
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		return "", "", "", fmt.Errorf("ssh host `%s` must not include a password, use key based authentication", host)
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+	socket = parsed.Path
+	if socket == "" || socket == "/" {
+		socket = defaultSSHSocket
+	}
+	return parsed.User.Username(), net.JoinHostPort(parsed.Hostname(), port), socket, nil
+}
+
+// NewSSHClientConfig creates the ssh configuration to connect to a docker server over ssh.
+// It authenticates with the keys loaded in the ssh agent, if SSH_AUTH_SOCK is set,
+// and with the private keys in the identity files. Encrypted private keys must be loaded
+// in the agent. The host keys are checked against the keys in the known hosts file.
+func NewSSHClientConfig(user string, identityFiles []string, knownHostsFile string) (*ssh.ClientConfig, error) {
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load ssh known hosts: %v", err)
+	}
+
+	var signers []ssh.Signer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			if agentSigners, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+	for _, file := range identityFiles {
+		key, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ssh identity file: %v", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse ssh identity file %s: %v", file, err)
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no ssh keys available, load them in the ssh agent or provide identity files")
+	}
+
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+// defaultSSHClientConfig creates the ssh configuration with the user's ssh settings.
+// It uses the keys in the ssh agent and the default identity files in ~/.ssh,
+// and it checks the host keys against ~/.ssh/known_hosts.
+func defaultSSHClientConfig(user string) (*ssh.ClientConfig, error) {
+	home := os.Getenv("HOME")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+
+	var identityFiles []string
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		file := filepath.Join(home, ".ssh", name)
+		key, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		// Skip encrypted keys, they can only be used through the agent.
+		if _, err := ssh.ParsePrivateKey(key); err == nil {
+			identityFiles = append(identityFiles, file)
+		}
+	}
+	return NewSSHClientConfig(user, identityFiles, filepath.Join(home, ".ssh", "known_hosts"))
+}
+
+// sshTunnel opens connections to the docker socket in a remote host.
+// All the connections are multiplexed over the same ssh connection,
+// which is reopened if it's lost.
+type sshTunnel struct {
+	addr   string
+	socket string
+	config *ssh.ClientConfig
+	dialer *net.Dialer
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHTunnel creates a tunnel to the docker server at the ssh host.
+// It uses the default ssh configuration if the config is nil.
+func newSSHTunnel(host string, config *ssh.ClientConfig, dialer *net.Dialer) (*sshTunnel, error) {
+	user, addr, socket, err := parseSSHHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		config, err = defaultSSHClientConfig(user)
+		if err != nil {
+			return nil, err
+		}
+	} else if user != "" {
+		// Make a copy to avoid modifying the configuration provided by the user.
+		c := *config
+		c.User = user
+		config = &c
+	}
+
+	if dialer == nil {
+		dialer = &net.Dialer{Timeout: 32 * time.Second}
+	}
+
+	return &sshTunnel{
+		addr:   addr,
+		socket: socket,
+		config: config,
+		dialer: dialer,
+	}, nil
+}
+
+// Dial opens a connection to the docker socket in the remote host.
+// The network and address are ignored, they are in the signature to be
+// used as the dial function of an http transport.
+func (t *sshTunnel) Dial(_, _ string) (net.Conn, error) {
+	client, err := t.sshClient(nil)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial("unix", t.socket)
+	if err == nil {
+		return conn, nil
+	}
+	if _, ok := err.(*ssh.OpenChannelError); ok {
+		// The server rejected the connection to the socket, the ssh connection is fine.
+		return nil, err
+	}
+
+	// The ssh connection is broken, open it again and retry once.
+	if client, err = t.sshClient(client); err != nil {
+		return nil, err
+	}
+	return client.Dial("unix", t.socket)
+}
+
+// sshClient returns the ssh connection to the remote host.
+// It opens a new connection if there is none, or if the current one is the broken one.
+func (t *sshTunnel) sshClient(broken *ssh.Client) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil && t.client != broken {
+		return t.client, nil
+	}
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+
+	conn, err := t.dialer.Dial("tcp", t.addr)
+	if err != nil {
+		return nil, err
+	}
+	// Don't wait forever for servers that accept the connection but never answer.
+	timeout := t.config.Timeout
+	if timeout == 0 {
+		timeout = defaultSSHHandshakeTimeout
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	c, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	t.client = ssh.NewClient(c, chans, reqs)
+	return t.client, nil
+}
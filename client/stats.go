@@ -0,0 +1,221 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/versions"
+	"github.com/docker/engine-api/types/versions/v1p20"
+)
+
+// legacyNetworkInterface is the name given to the network stats sent by
+// daemons older than API 1.21, which only report one network interface.
+const legacyNetworkInterface = "eth0"
+
+// StatsDecoder decodes the stream of stats returned by ContainerStats.
+type StatsDecoder struct {
+	dec     *json.Decoder
+	version string
+	// body is the stream of stats, when the decoder was created by the client.
+	body io.Closer
+}
+
+// NewStatsDecoder creates a new decoder that reads the stats from r.
+// The shape of the stats is detected from their content,
+// use NewStatsDecoderWithVersion when the API version is known.
+func NewStatsDecoder(r io.Reader) *StatsDecoder {
+	return &StatsDecoder{dec: json.NewDecoder(r)}
+}
+
+// NewStatsDecoderWithVersion creates a new decoder that reads the stats
+// sent by a daemon with the given API version from r, like the version
+// returned by Client.ClientVersion after calling ContainerStats.
+func NewStatsDecoderWithVersion(r io.Reader, version string) *StatsDecoder {
+	return &StatsDecoder{
+		dec:     json.NewDecoder(r),
+		version: strings.TrimPrefix(version, "v"),
+	}
+}
+
+// Decode reads the next stats in the stream. It returns io.EOF when the stream ends.
+// Daemons older than API 1.21 send the stats of their only network interface
+// in a network field, they are returned in Networks as the stats of eth0.
+func (d *StatsDecoder) Decode() (types.StatsJSON, error) {
+	switch {
+	case d.version == "":
+		return d.decodeAny()
+	case versions.LessThan(d.version, "1.21"):
+		var stats v1p20.StatsJSON
+		if err := d.dec.Decode(&stats); err != nil {
+			return types.StatsJSON{}, err
+		}
+		return convertV1p20StatsJSON(stats), nil
+	default:
+		var stats types.StatsJSON
+		err := d.dec.Decode(&stats)
+		return stats, err
+	}
+}
+
+// Close closes the stream of stats, if the decoder was created by
+// Client.ContainerStatsDecoder. It does nothing otherwise.
+func (d *StatsDecoder) Close() error {
+	if d.body == nil {
+		return nil
+	}
+	return d.body.Close()
+}
+
+// decodeAny decodes stats in the current or the legacy shape.
+func (d *StatsDecoder) decodeAny() (types.StatsJSON, error) {
+	var stats struct {
+		types.StatsJSON
+		// Network is the network stats sent by daemons older than API 1.21.
+		Network *types.NetworkStats `json:"network,omitempty"`
+	}
+	if err := d.dec.Decode(&stats); err != nil {
+		return types.StatsJSON{}, err
+	}
+	if stats.Networks == nil && stats.Network != nil {
+		stats.Networks = map[string]types.NetworkStats{
+			legacyNetworkInterface: *stats.Network,
+		}
+	}
+	return stats.StatsJSON, nil
+}
+
+// convertV1p20StatsJSON converts the stats sent by daemons older than API 1.21.
+func convertV1p20StatsJSON(stats v1p20.StatsJSON) types.StatsJSON {
+	return types.StatsJSON{
+		Stats: stats.Stats,
+		Networks: map[string]types.NetworkStats{
+			legacyNetworkInterface: stats.Network,
+		},
+	}
+}
+
+// StatsMetrics holds the metrics derived from the stats of a container.
+type StatsMetrics struct {
+	// CPUPercent is the percentage of the host's CPU used by the container,
+	// it can be up to 100% per CPU.
+	CPUPercent float64
+	// MemoryUsage is the memory used by the container, without the page cache.
+	MemoryUsage uint64
+	// MemoryLimit is the memory limit of the container.
+	MemoryLimit uint64
+	// MemoryPercent is the percentage of the memory limit used by the container.
+	MemoryPercent float64
+	// BlockRead is the number of bytes read from block devices.
+	BlockRead uint64
+	// BlockWrite is the number of bytes written to block devices.
+	BlockWrite uint64
+	// NetworkRx is the number of bytes received in all the networks.
+	NetworkRx uint64
+	// NetworkTx is the number of bytes sent in all the networks.
+	NetworkTx uint64
+	// NetworkRxRate is the number of bytes received per second since the previous stats.
+	NetworkRxRate float64
+	// NetworkTxRate is the number of bytes sent per second since the previous stats.
+	NetworkTxRate float64
+	// PIDs is the number of processes in the container.
+	PIDs uint64
+}
+
+// CalculateStatsMetrics derives the metrics from the stats of a container.
+// The network rates are calculated from the previous stats in the stream,
+// they are zero if prev is nil.
+func CalculateStatsMetrics(prev *types.StatsJSON, stats types.StatsJSON) StatsMetrics {
+	m := StatsMetrics{
+		CPUPercent:    CalculateCPUPercent(stats),
+		MemoryUsage:   CalculateMemoryUsage(stats),
+		MemoryLimit:   stats.MemoryStats.Limit,
+		MemoryPercent: CalculateMemoryPercent(stats),
+		PIDs:          stats.PidsStats.Current,
+	}
+	m.BlockRead, m.BlockWrite = CalculateBlockIO(stats)
+	m.NetworkRx, m.NetworkTx = CalculateNetwork(stats)
+	if prev != nil {
+		m.NetworkRxRate, m.NetworkTxRate = CalculateNetworkRates(*prev, stats)
+	}
+	return m
+}
+
+// CalculateCPUPercent returns the percentage of the host's CPU used by the container
+// between the previous sample sent by the daemon, PreCPUStats, and the current one.
+// It can be up to 100% per CPU in the host.
+func CalculateCPUPercent(stats types.StatsJSON) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+
+	cpus := len(stats.CPUStats.CPUUsage.PercpuUsage)
+	if cpus == 0 {
+		cpus = 1
+	}
+	return cpuDelta / systemDelta * float64(cpus) * 100
+}
+
+// CalculateMemoryUsage returns the memory used by the container, without the page cache,
+// which the kernel can reclaim when it needs the memory.
+func CalculateMemoryUsage(stats types.StatsJSON) uint64 {
+	usage := stats.MemoryStats.Usage
+	if cache, ok := stats.MemoryStats.Stats["cache"]; ok && cache < usage {
+		usage -= cache
+	}
+	return usage
+}
+
+// CalculateMemoryPercent returns the percentage of the memory limit used by the container.
+// It's zero if the limit is unknown.
+func CalculateMemoryPercent(stats types.StatsJSON) float64 {
+	if stats.MemoryStats.Limit == 0 {
+		return 0
+	}
+	return float64(CalculateMemoryUsage(stats)) / float64(stats.MemoryStats.Limit) * 100
+}
+
+// CalculateBlockIO returns the number of bytes read from and written to block devices.
+func CalculateBlockIO(stats types.StatsJSON) (read uint64, write uint64) {
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+	return read, write
+}
+
+// CalculateNetwork returns the number of bytes received and sent in all the networks.
+func CalculateNetwork(stats types.StatsJSON) (rx uint64, tx uint64) {
+	for _, n := range stats.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	return rx, tx
+}
+
+// CalculateNetworkRates returns the number of bytes received and sent per second
+// in all the networks, between two stats of the same container. They are zero if
+// the stats are not in order, or if the counters were reset.
+func CalculateNetworkRates(prev, stats types.StatsJSON) (rx float64, tx float64) {
+	seconds := stats.Read.Sub(prev.Read).Seconds()
+	if seconds <= 0 {
+		return 0, 0
+	}
+
+	prevRx, prevTx := CalculateNetwork(prev)
+	curRx, curTx := CalculateNetwork(stats)
+	if curRx >= prevRx {
+		rx = float64(curRx-prevRx) / seconds
+	}
+	if curTx >= prevTx {
+		tx = float64(curTx-prevTx) / seconds
+	}
+	return rx, tx
+}
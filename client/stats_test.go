@@ -0,0 +1,211 @@
+package client
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types"
+)
+
+func TestStatsDecoderNetworks(t *testing.T) {
+	body := `{"read":"2016-01-01T00:00:00Z","networks":{"eth0":{"rx_bytes":10,"tx_bytes":20},"eth1":{"rx_bytes":1,"tx_bytes":2}}}
+{"read":"2016-01-01T00:00:01Z","networks":{"eth0":{"rx_bytes":30,"tx_bytes":40}}}
+`
+	dec := NewStatsDecoder(strings.NewReader(body))
+	stats, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Networks) != 2 || stats.Networks["eth1"].TxBytes != 2 {
+		t.Fatalf("expected 2 networks, got %v", stats.Networks)
+	}
+	stats, err = dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Networks) != 1 || stats.Networks["eth0"].RxBytes != 30 {
+		t.Fatalf("expected eth0 network, got %v", stats.Networks)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestStatsDecoderLegacyNetwork(t *testing.T) {
+	body := `{"read":"2016-01-01T00:00:00Z","network":{"rx_bytes":10,"tx_bytes":20},"memory_stats":{"usage":100}}`
+	stats, err := NewStatsDecoder(strings.NewReader(body)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	network, ok := stats.Networks["eth0"]
+	if !ok || len(stats.Networks) != 1 {
+		t.Fatalf("expected eth0 network, got %v", stats.Networks)
+	}
+	if network.RxBytes != 10 || network.TxBytes != 20 {
+		t.Fatalf("unexpected network stats %v", network)
+	}
+	if stats.MemoryStats.Usage != 100 {
+		t.Fatalf("expected memory usage 100, got %d", stats.MemoryStats.Usage)
+	}
+}
+
+func TestStatsDecoderWithVersion(t *testing.T) {
+	legacy := `{"read":"2016-01-01T00:00:00Z","network":{"rx_bytes":10,"tx_bytes":20},"memory_stats":{"usage":100}}`
+	stats, err := NewStatsDecoderWithVersion(strings.NewReader(legacy), "v1.20").Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Networks) != 1 || stats.Networks["eth0"].RxBytes != 10 || stats.Networks["eth0"].TxBytes != 20 {
+		t.Fatalf("expected eth0 network, got %v", stats.Networks)
+	}
+	if stats.MemoryStats.Usage != 100 {
+		t.Fatalf("expected memory usage 100, got %d", stats.MemoryStats.Usage)
+	}
+
+	// Current daemons don't send the legacy field.
+	current := `{"read":"2016-01-01T00:00:00Z","networks":{"eth1":{"rx_bytes":1,"tx_bytes":2}}}`
+	stats, err = NewStatsDecoderWithVersion(strings.NewReader(current), "1.21").Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Networks) != 1 || stats.Networks["eth1"].TxBytes != 2 {
+		t.Fatalf("expected eth1 network, got %v", stats.Networks)
+	}
+}
+
+func TestStatsDecoderError(t *testing.T) {
+	if _, err := NewStatsDecoder(strings.NewReader(`{"read":`)).Decode(); err == nil {
+		t.Fatal("expected an error decoding truncated stats")
+	}
+}
+
+func TestCalculateCPUPercent(t *testing.T) {
+	var stats types.StatsJSON
+	stats.PreCPUStats.CPUUsage.TotalUsage = 100
+	stats.PreCPUStats.SystemUsage = 1000
+	stats.CPUStats.CPUUsage.TotalUsage = 300
+	stats.CPUStats.CPUUsage.PercpuUsage = []uint64{150, 150}
+	stats.CPUStats.SystemUsage = 2000
+
+	if p := CalculateCPUPercent(stats); !floatEquals(p, 40) {
+		t.Fatalf("expected 40%%, got %v", p)
+	}
+
+	// The first stats in a stream have no previous sample.
+	stats.PreCPUStats = types.CPUStats{}
+	stats.CPUStats.SystemUsage = 0
+	if p := CalculateCPUPercent(stats); p != 0 {
+		t.Fatalf("expected 0%% without system usage, got %v", p)
+	}
+}
+
+func TestCalculateMemory(t *testing.T) {
+	var stats types.StatsJSON
+	stats.MemoryStats.Usage = 300
+	stats.MemoryStats.Stats = map[string]uint64{"cache": 100}
+	stats.MemoryStats.Limit = 400
+
+	if usage := CalculateMemoryUsage(stats); usage != 200 {
+		t.Fatalf("expected usage 200, got %d", usage)
+	}
+	if p := CalculateMemoryPercent(stats); !floatEquals(p, 50) {
+		t.Fatalf("expected 50%%, got %v", p)
+	}
+
+	stats.MemoryStats.Limit = 0
+	if p := CalculateMemoryPercent(stats); p != 0 {
+		t.Fatalf("expected 0%% without limit, got %v", p)
+	}
+}
+
+func TestCalculateBlockIO(t *testing.T) {
+	var stats types.StatsJSON
+	stats.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{
+		{Major: 8, Minor: 0, Op: "Read", Value: 100},
+		{Major: 8, Minor: 0, Op: "Write", Value: 200},
+		{Major: 8, Minor: 0, Op: "Total", Value: 300},
+		{Major: 8, Minor: 16, Op: "read", Value: 10},
+		{Major: 8, Minor: 16, Op: "write", Value: 20},
+	}
+	read, write := CalculateBlockIO(stats)
+	if read != 110 || write != 220 {
+		t.Fatalf("expected 110 read and 220 written, got %d and %d", read, write)
+	}
+}
+
+func TestCalculateNetworkRates(t *testing.T) {
+	now := time.Now()
+	prev := types.StatsJSON{
+		Stats: types.Stats{Read: now},
+		Networks: map[string]types.NetworkStats{
+			"eth0": {RxBytes: 100, TxBytes: 100},
+			"eth1": {RxBytes: 100, TxBytes: 100},
+		},
+	}
+	stats := types.StatsJSON{
+		Stats: types.Stats{Read: now.Add(2 * time.Second)},
+		Networks: map[string]types.NetworkStats{
+			"eth0": {RxBytes: 300, TxBytes: 150},
+			"eth1": {RxBytes: 100, TxBytes: 150},
+		},
+	}
+
+	rx, tx := CalculateNetworkRates(prev, stats)
+	if !floatEquals(rx, 100) || !floatEquals(tx, 50) {
+		t.Fatalf("expected 100 B/s received and 50 B/s sent, got %v and %v", rx, tx)
+	}
+
+	rx, tx = CalculateNetworkRates(stats, prev)
+	if rx != 0 || tx != 0 {
+		t.Fatalf("expected no rates for stats out of order, got %v and %v", rx, tx)
+	}
+}
+
+func TestCalculateStatsMetrics(t *testing.T) {
+	body := `{"read":"2016-01-01T00:00:00Z","network":{"rx_bytes":1000,"tx_bytes":500}}
+{"read":"2016-01-01T00:00:01Z","network":{"rx_bytes":3000,"tx_bytes":600},
+"precpu_stats":{"cpu_usage":{"total_usage":100},"system_cpu_usage":1000},
+"cpu_stats":{"cpu_usage":{"total_usage":200,"percpu_usage":[100]},"system_cpu_usage":2000},
+"memory_stats":{"usage":1024,"limit":2048},
+"blkio_stats":{"io_service_bytes_recursive":[{"op":"Read","value":5},{"op":"Write","value":7}]},
+"pids_stats":{"current":3}}
+`
+	dec := NewStatsDecoder(strings.NewReader(body))
+	prev, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := CalculateStatsMetrics(nil, prev)
+	if m.NetworkRx != 1000 || m.NetworkRxRate != 0 {
+		t.Fatalf("unexpected metrics for first stats %+v", m)
+	}
+
+	stats, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = CalculateStatsMetrics(&prev, stats)
+	expected := StatsMetrics{
+		CPUPercent:    10,
+		MemoryUsage:   1024,
+		MemoryLimit:   2048,
+		MemoryPercent: 50,
+		BlockRead:     5,
+		BlockWrite:    7,
+		NetworkRx:     3000,
+		NetworkTx:     600,
+		NetworkRxRate: 2000,
+		NetworkTxRate: 100,
+		PIDs:          3,
+	}
+	if m != expected {
+		t.Fatalf("expected %+v, got %+v", expected, m)
+	}
+}
+
+func floatEquals(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
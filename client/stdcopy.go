@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// StdType is the type of the standard stream
+// that a frame in a multiplexed stream belongs to.
+type StdType byte
+
+const (
+	// Stdin represents the standard input stream type.
+	Stdin StdType = iota
+	// Stdout represents the standard output stream type.
+	Stdout
+	// Stderr represents the standard error stream type.
+	Stderr
+)
+
+const (
+	// stdHeaderLen is the length of the header of each frame.
+	// The header holds the stream type in its first byte, and
+	// the size of the frame, big endian, in its last four bytes.
+	stdHeaderLen = 8
+	// stdHeaderFdIndex is the index of the stream type in the header.
+	stdHeaderFdIndex = 0
+	// stdHeaderSizeIndex is the index of the size of the frame in the header.
+	stdHeaderSizeIndex = 4
+	// stdCopyBufferSize is the size of the buffers used to copy the streams.
+	stdCopyBufferSize = 32 * 1024
+)
+
+// stdWriter is a writer that prefixes the data written to it
+// with the header of a frame in a multiplexed stream.
+type stdWriter struct {
+	io.Writer
+	stdType StdType
+}
+
+// NewStdWriter creates a writer that writes the data written to it as frames
+// of the given stream type in a multiplexed stream, like the docker daemon does
+// for the output of containers without a tty. Use StdCopy to demultiplex it.
+func NewStdWriter(w io.Writer, t StdType) io.Writer {
+	return &stdWriter{
+		Writer:  w,
+		stdType: t,
+	}
+}
+
+// Write writes the data as a frame in the multiplexed stream.
+// The header and the data are written at once, so different stream
+// types can be written concurrently to the same writer if it's safe
+// to write to it concurrently. It returns the number of bytes written
+// from p, without the header.
+func (w *stdWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	frame := make([]byte, stdHeaderLen+len(p))
+	frame[stdHeaderFdIndex] = byte(w.stdType)
+	binary.BigEndian.PutUint32(frame[stdHeaderSizeIndex:], uint32(len(p)))
+	copy(frame[stdHeaderLen:], p)
+
+	n, err := w.Writer.Write(frame)
+	n -= stdHeaderLen
+	if n < 0 {
+		n = 0
+	}
+	return n, err
+}
+
+// StdCopy demultiplexes a stream of stdout and stderr frames, like the output
+// of ContainerLogs, ContainerAttach and ContainerExecAttach for containers
+// without a tty. It writes the stdout frames to dstout and the stderr frames
+// to dsterr, either can be nil to discard the frames. Stdin frames are written
+// to dstout. It reads until src returns io.EOF, and it returns the number
+// of bytes written to the writers. Frames split across reads are supported,
+// and the frames are copied without buffering them completely.
+func StdCopy(dstout, dsterr io.Writer, src io.Reader) (written int64, err error) {
+	if dstout == nil {
+		dstout = ioutil.Discard
+	}
+	if dsterr == nil {
+		dsterr = ioutil.Discard
+	}
+
+	br, ok := src.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReaderSize(src, stdCopyBufferSize)
+	}
+	buf := make([]byte, stdCopyBufferSize)
+	var header [stdHeaderLen]byte
+
+	for {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			if err == io.EOF {
+				return written, nil
+			}
+			if err == io.ErrUnexpectedEOF {
+				return written, fmt.Errorf("Unexpected EOF reading the header of a frame")
+			}
+			return written, err
+		}
+
+		var dst io.Writer
+		switch StdType(header[stdHeaderFdIndex]) {
+		case Stdin, Stdout:
+			dst = dstout
+		case Stderr:
+			dst = dsterr
+		default:
+			return written, fmt.Errorf("Unrecognized input header: %d", header[stdHeaderFdIndex])
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[stdHeaderSizeIndex:]))
+		n, err := io.CopyBuffer(dst, io.LimitReader(br, size), buf)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n < size {
+			return written, fmt.Errorf("Unexpected EOF reading a frame, expected %d bytes, got %d", size, n)
+		}
+	}
+}
+
+// DemuxStream copies the output of a container to dstout and dsterr.
+// The output is multiplexed, and it's demultiplexed with StdCopy, unless
+// the container has a tty, which is told by its Config.Tty field in
+// the result of ContainerInspect. The raw output of containers with a tty
+// is copied to dstout.
+func DemuxStream(dstout, dsterr io.Writer, src io.Reader, tty bool) (int64, error) {
+	if !tty {
+		return StdCopy(dstout, dsterr, src)
+	}
+	if dstout == nil {
+		dstout = ioutil.Discard
+	}
+	return io.Copy(dstout, src)
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func testStream() ([]byte, string, string) {
+	var buf bytes.Buffer
+	stdout := NewStdWriter(&buf, Stdout)
+	stderr := NewStdWriter(&buf, Stderr)
+	stdout.Write([]byte("hello "))
+	stderr.Write([]byte("something went wrong\n"))
+	stdout.Write([]byte("world\n"))
+	NewStdWriter(&buf, Stdin).Write([]byte("input\n"))
+	return buf.Bytes(), "hello world\ninput\n", "something went wrong\n"
+}
+
+func TestStdWriterFrames(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := NewStdWriter(&buf, Stderr).Write([]byte("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 bytes written, got %d", n)
+	}
+	expected := []byte{2, 0, 0, 0, 0, 0, 0, 5, 'e', 'r', 'r', 'o', 'r'}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatalf("expected %v, got %v", expected, buf.Bytes())
+	}
+
+	if n, err := NewStdWriter(&buf, Stdout).Write(nil); n != 0 || err != nil || buf.Len() != len(expected) {
+		t.Fatalf("expected empty writes to write nothing, got %d, %v", n, err)
+	}
+}
+
+func TestStdCopy(t *testing.T) {
+	stream, expectedOut, expectedErr := testStream()
+
+	readers := map[string]io.Reader{
+		"full":     bytes.NewReader(stream),
+		"one byte": iotest.OneByteReader(bytes.NewReader(stream)),
+		"half":     iotest.HalfReader(bytes.NewReader(stream)),
+		"data err": iotest.DataErrReader(bytes.NewReader(stream)),
+		"split":    io.MultiReader(bytes.NewReader(stream[:3]), bytes.NewReader(stream[3:])),
+	}
+	for name, r := range readers {
+		var stdout, stderr bytes.Buffer
+		written, err := StdCopy(&stdout, &stderr, r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if stdout.String() != expectedOut || stderr.String() != expectedErr {
+			t.Fatalf("%s: expected %q and %q, got %q and %q", name, expectedOut, expectedErr, stdout.String(), stderr.String())
+		}
+		if written != int64(len(expectedOut)+len(expectedErr)) {
+			t.Fatalf("%s: expected %d bytes written, got %d", name, len(expectedOut)+len(expectedErr), written)
+		}
+	}
+}
+
+func TestStdCopyLargeFrame(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1024*1024)
+	var buf bytes.Buffer
+	if _, err := NewStdWriter(&buf, Stdout).Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	written, err := StdCopy(&stdout, nil, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(len(data)) || !bytes.Equal(stdout.Bytes(), data) {
+		t.Fatalf("expected the large frame to be copied, got %d bytes", written)
+	}
+}
+
+func TestStdCopyDiscard(t *testing.T) {
+	stream, expectedOut, _ := testStream()
+	var stdout bytes.Buffer
+	if _, err := StdCopy(&stdout, nil, bytes.NewReader(stream)); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != expectedOut {
+		t.Fatalf("expected %q, got %q", expectedOut, stdout.String())
+	}
+}
+
+func TestStdCopyErrors(t *testing.T) {
+	stream, _, _ := testStream()
+
+	cases := map[string]struct {
+		stream []byte
+		err    string
+	}{
+		"bad stream type":  {[]byte{5, 0, 0, 0, 0, 0, 0, 1, 'a'}, "Unrecognized input header: 5"},
+		"truncated header": {stream[:4], "Unexpected EOF reading the header of a frame"},
+		"truncated frame":  {stream[:10], "Unexpected EOF reading a frame, expected 6 bytes, got 2"},
+	}
+	for name, cs := range cases {
+		_, err := StdCopy(ioutil.Discard, ioutil.Discard, bytes.NewReader(cs.stream))
+		if err == nil || err.Error() != cs.err {
+			t.Fatalf("%s: expected error %q, got %v", name, cs.err, err)
+		}
+	}
+
+	_, err := StdCopy(ioutil.Discard, ioutil.Discard, iotest.TimeoutReader(bytes.NewReader(stream)))
+	if err != iotest.ErrTimeout {
+		t.Fatalf("expected the read error, got %v", err)
+	}
+}
+
+func TestDemuxStream(t *testing.T) {
+	stream, expectedOut, expectedErr := testStream()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := DemuxStream(&stdout, &stderr, bytes.NewReader(stream), false); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != expectedOut || stderr.String() != expectedErr {
+		t.Fatalf("expected the stream to be demultiplexed, got %q and %q", stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	raw := "raw output from a tty\r\n"
+	if _, err := DemuxStream(&stdout, &stderr, strings.NewReader(raw), true); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != raw || stderr.Len() != 0 {
+		t.Fatalf("expected the raw stream to be copied to stdout, got %q and %q", stdout.String(), stderr.String())
+	}
+}
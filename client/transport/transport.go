@@ -2,7 +2,7 @@
 package transport
 
 import (
-	"fmt"
+	"crypto/tls"
 	"net/http"
 
 	"github.com/docker/go-connections/sockets"
@@ -12,38 +12,65 @@ import (
 type apiTransport struct {
 	*http.Client
 	*tlsInfo
-	transport *http.Transport
 }
 
 // NewTransportWithHTTP creates a new transport based on the provided proto, address and http client.
 // It uses Docker's default http transport configuration if the client is nil.
 // It does not modify the client's transport if it's not nil.
+// The TLS configuration is taken from the client's transport when it's an *http.Transport,
+// other round trippers are considered insecure. Use NewTransportWithTLSConfig
+// to provide the TLS configuration of any other round tripper.
 func NewTransportWithHTTP(proto, addr string, client *http.Client) (Client, error) {
-	var transport *http.Transport
+	var tlsConfig *tls.Config
 
 	if client != nil {
-		tr, ok := client.Transport.(*http.Transport)
-		if !ok {
-			return nil, fmt.Errorf("unable to verify TLS configuration, invalid transport %v", client.Transport)
+		if tr, ok := client.Transport.(*http.Transport); ok {
+			tlsConfig = tr.TLSClientConfig
 		}
-		transport = tr
 	} else {
-		transport = defaultTransport(proto, addr)
 		client = &http.Client{
-			Transport: transport,
+			Transport: defaultTransport(proto, addr),
+		}
+	}
+
+	return NewTransportWithTLSConfig(proto, addr, client, tlsConfig)
+}
+
+// NewTransportWithTLSConfig creates a new transport based on the provided proto, address and http client,
+// with the given TLS configuration. The client's transport can be any http.RoundTripper,
+// the TLS configuration tells the scheme to use to send requests, and it's used
+// to secure the connections that are hijacked from the server.
+// It uses Docker's default http transport configuration, secured with the TLS configuration,
+// if the client is nil.
+func NewTransportWithTLSConfig(proto, addr string, client *http.Client, tlsConfig *tls.Config) (Client, error) {
+	if client == nil {
+		tr := defaultTransport(proto, addr)
+		tr.TLSClientConfig = tlsConfig
+		client = &http.Client{
+			Transport: tr,
 		}
 	}
 
 	return &apiTransport{
-		Client:    client,
-		tlsInfo:   &tlsInfo{transport.TLSClientConfig},
-		transport: transport,
+		Client:  client,
+		tlsInfo: &tlsInfo{tlsConfig},
 	}, nil
 }
 
 // CancelRequest stops a request execution.
+// It does nothing if the client's transport cannot cancel requests.
 func (a *apiTransport) CancelRequest(req *http.Request) {
-	a.transport.CancelRequest(req)
+	type requestCanceler interface {
+		CancelRequest(*http.Request)
+	}
+
+	rt := a.Client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if rc, ok := rt.(requestCanceler); ok {
+		rc.CancelRequest(req)
+	}
 }
 
 // defaultTransport creates a new http.Transport with Docker's
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/docker/engine-api/types/versions"
+	"golang.org/x/net/context"
+)
+
+// The tables below map request options to the first API version that
+// supports them. Options that are not listed are supported by every
+// API version the client can talk to. Older daemons either ignore
+// unknown options or fail with generic errors, so the client checks
+// them before sending the request.
+
+// configVersions holds the versioned options in container.Config.
+var configVersions = map[string]string{
+	"StopSignal": "1.21",
+}
+
+// hostConfigVersions holds the versioned options in container.HostConfig.
+var hostConfigVersions = map[string]string{
+	"GroupAdd":    "1.20",
+	"DNSOptions":  "1.21",
+	"OomScoreAdj": "1.22",
+	"ShmSize":     "1.22",
+	"Tmpfs":       "1.22",
+	"Isolation":   "1.22",
+	"UsernsMode":  "1.23",
+	"StorageOpt":  "1.24",
+	"Sysctls":     "1.24",
+	"AutoRemove":  "1.25",
+}
+
+// resourcesVersions holds the versioned options in container.Resources,
+// shared by container.HostConfig and container.UpdateConfig.
+var resourcesVersions = map[string]string{
+	"KernelMemory":         "1.21",
+	"MemoryReservation":    "1.21",
+	"BlkioWeightDevice":    "1.22",
+	"BlkioDeviceReadBps":   "1.22",
+	"BlkioDeviceWriteBps":  "1.22",
+	"BlkioDeviceReadIOps":  "1.22",
+	"BlkioDeviceWriteIOps": "1.22",
+	"PidsLimit":            "1.23",
+	"CPUCount":             "1.24",
+	"CPUPercent":           "1.24",
+	"IOMaximumIOps":        "1.24",
+	"IOMaximumBandwidth":   "1.24",
+}
+
+// updateConfigVersions holds the versioned options in container.UpdateConfig.
+var updateConfigVersions = map[string]string{
+	"RestartPolicy": "1.23",
+}
+
+// imageBuildVersions holds the versioned options in types.ImageBuildOptions.
+var imageBuildVersions = map[string]string{
+	"BuildArgs": "1.21",
+	"Isolation": "1.22",
+	"ShmSize":   "1.22",
+	"Ulimits":   "1.22",
+	"Labels":    "1.23",
+}
+
+// networkCreateVersions holds the versioned options in types.NetworkCreate.
+var networkCreateVersions = map[string]string{
+	"Internal":   "1.22",
+	"EnableIPv6": "1.23",
+	"Labels":     "1.23",
+}
+
+// volumeCreateVersions holds the versioned options in types.VolumeCreateRequest.
+var volumeCreateVersions = map[string]string{
+	"Labels": "1.23",
+}
+
+// versionedOptions groups an options struct with the tables
+// that describe its versioned fields.
+type versionedOptions struct {
+	// name is the prefix used to report the fields, like `HostConfig`.
+	name   string
+	value  interface{}
+	tables []map[string]string
+}
+
+// checkOptionsVersion returns an ErrUnsupportedByVersion for the first option
+// set in the given structs that the API version of the client doesn't support.
+// The version is negotiated first if the client was configured to do it.
+func (cli *Client) checkOptionsVersion(ctx context.Context, options ...versionedOptions) error {
+	if err := cli.checkAPIVersion(ctx); err != nil {
+		return err
+	}
+	version := strings.TrimPrefix(cli.ClientVersion(), "v")
+	if version == "" {
+		// Unversioned requests go to the latest API the daemon supports.
+		return nil
+	}
+	for _, o := range options {
+		if err := unsupportedOption(version, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsupportedOption checks the fields of one options struct against its
+// version tables. Fields are checked in alphabetical order so the error
+// is deterministic when several fields are unsupported.
+func unsupportedOption(version string, o versionedOptions) error {
+	v := reflect.ValueOf(o.value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	minVersions := make(map[string]string)
+	var fields []string
+	for _, table := range o.tables {
+		for field, minVersion := range table {
+			minVersions[field] = minVersion
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		minVersion := minVersions[field]
+		if !versions.LessThan(version, minVersion) {
+			continue
+		}
+		if f := v.FieldByName(field); f.IsValid() && !isZeroValue(f) {
+			return ErrUnsupportedByVersion{
+				Field:      o.name + "." + field,
+				MinVersion: minVersion,
+				Version:    version,
+			}
+		}
+	}
+	return nil
+}
+
+// isZeroValue returns true if the value is the zero value of its type.
+// Empty maps and slices are considered zero values too, they are
+// omitted or ignored by the daemon.
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"github.com/docker/engine-api/types/container"
+	"golang.org/x/net/context"
+)
+
+func newVersionedMockClient(version string, requests *int) *Client {
+	return &Client{
+		version: version,
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			*requests++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			}, nil
+		}),
+	}
+}
+
+func TestUnsupportedOptionsByVersion(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		version string
+		call    func(*Client) error
+		field   string
+		min     string
+	}{
+		{
+			version: "1.24",
+			call: func(cli *Client) error {
+				_, err := cli.ContainerCreate(ctx, &container.Config{}, &container.HostConfig{AutoRemove: true}, nil, "")
+				return err
+			},
+			field: "HostConfig.AutoRemove",
+			min:   "1.25",
+		},
+		{
+			version: "1.23",
+			call: func(cli *Client) error {
+				_, err := cli.ContainerCreate(ctx, nil, &container.HostConfig{Sysctls: map[string]string{"net.ipv4.ip_forward": "1"}}, nil, "")
+				return err
+			},
+			field: "HostConfig.Sysctls",
+			min:   "1.24",
+		},
+		{
+			version: "1.23",
+			call: func(cli *Client) error {
+				_, err := cli.ContainerCreate(ctx, nil, &container.HostConfig{StorageOpt: map[string]string{"size": "10G"}}, nil, "")
+				return err
+			},
+			field: "HostConfig.StorageOpt",
+			min:   "1.24",
+		},
+		{
+			version: "1.22",
+			call: func(cli *Client) error {
+				hostConfig := &container.HostConfig{}
+				hostConfig.PidsLimit = 10
+				_, err := cli.ContainerCreate(ctx, nil, hostConfig, nil, "")
+				return err
+			},
+			field: "HostConfig.PidsLimit",
+			min:   "1.23",
+		},
+		{
+			version: "1.20",
+			call: func(cli *Client) error {
+				_, err := cli.ContainerCreate(ctx, &container.Config{StopSignal: "SIGKILL"}, nil, nil, "")
+				return err
+			},
+			field: "Config.StopSignal",
+			min:   "1.21",
+		},
+		{
+			version: "1.22",
+			call: func(cli *Client) error {
+				updateConfig := container.UpdateConfig{
+					RestartPolicy: container.RestartPolicy{Name: "always"},
+				}
+				return cli.ContainerUpdate(ctx, "container_id", updateConfig)
+			},
+			field: "UpdateConfig.RestartPolicy",
+			min:   "1.23",
+		},
+		{
+			version: "1.22",
+			call: func(cli *Client) error {
+				updateConfig := container.UpdateConfig{}
+				updateConfig.PidsLimit = 10
+				return cli.ContainerUpdate(ctx, "container_id", updateConfig)
+			},
+			field: "UpdateConfig.PidsLimit",
+			min:   "1.23",
+		},
+		{
+			version: "1.22",
+			call: func(cli *Client) error {
+				_, err := cli.ImageBuild(ctx, nil, types.ImageBuildOptions{Labels: map[string]string{"a": "b"}})
+				return err
+			},
+			field: "ImageBuildOptions.Labels",
+			min:   "1.23",
+		},
+		{
+			version: "1.21",
+			call: func(cli *Client) error {
+				_, err := cli.NetworkCreate(ctx, "network", types.NetworkCreate{Internal: true})
+				return err
+			},
+			field: "NetworkCreate.Internal",
+			min:   "1.22",
+		},
+		{
+			version: "v1.22",
+			call: func(cli *Client) error {
+				_, err := cli.VolumeCreate(ctx, types.VolumeCreateRequest{Labels: map[string]string{"a": "b"}})
+				return err
+			},
+			field: "VolumeCreateRequest.Labels",
+			min:   "1.23",
+		},
+	}
+
+	for _, c := range cases {
+		var requests int
+		client := newVersionedMockClient(c.version, &requests)
+		err := c.call(client)
+		if !IsErrUnsupportedByVersion(err) {
+			t.Fatalf("expected an ErrUnsupportedByVersion for %s, got %v", c.field, err)
+		}
+		e := err.(ErrUnsupportedByVersion)
+		if e.Field != c.field || e.MinVersion != c.min || e.Version != strings.TrimPrefix(c.version, "v") {
+			t.Fatalf("unexpected error for %s: %+v", c.field, e)
+		}
+		if !strings.Contains(err.Error(), c.field) {
+			t.Fatalf("expected the error to name %s, got %v", c.field, err)
+		}
+		if requests != 0 {
+			t.Fatalf("expected no request to be sent for %s, got %d", c.field, requests)
+		}
+	}
+}
+
+func TestSupportedOptionsByVersion(t *testing.T) {
+	ctx := context.Background()
+	hostConfig := &container.HostConfig{
+		AutoRemove: true,
+		Sysctls:    map[string]string{"net.ipv4.ip_forward": "1"},
+	}
+	hostConfig.PidsLimit = 10
+
+	for _, version := range []string{"", "1.25", "1.26"} {
+		var requests int
+		client := newVersionedMockClient(version, &requests)
+		if _, err := client.ContainerCreate(ctx, &container.Config{}, hostConfig, nil, ""); err != nil {
+			t.Fatalf("unexpected error with version %q: %v", version, err)
+		}
+		if _, err := client.NetworkCreate(ctx, "network", types.NetworkCreate{Internal: true, EnableIPv6: true}); err != nil {
+			t.Fatalf("unexpected error with version %q: %v", version, err)
+		}
+		if requests != 2 {
+			t.Fatalf("expected 2 requests with version %q, got %d", version, requests)
+		}
+	}
+
+	// Empty options are valid in every version.
+	var requests int
+	client := newVersionedMockClient("1.19", &requests)
+	if _, err := client.ContainerCreate(ctx, &container.Config{}, &container.HostConfig{StorageOpt: map[string]string{}}, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.VolumeCreate(ctx, types.VolumeCreateRequest{Name: "volume"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnsupportedOptionsAfterNegotiation(t *testing.T) {
+	var requests int
+	client := &Client{
+		negotiateVersion: true,
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			requests++
+			body := "{}"
+			if req.URL.Path == "/version" {
+				body = `{"ApiVersion":"1.22"}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		}),
+	}
+
+	_, err := client.VolumeCreate(context.Background(), types.VolumeCreateRequest{Labels: map[string]string{"a": "b"}})
+	if !IsErrUnsupportedByVersion(err) {
+		t.Fatalf("expected an ErrUnsupportedByVersion, got %v", err)
+	}
+	if expected := "VolumeCreateRequest.Labels requires API version 1.23, but the Docker daemon API version is 1.22"; err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+	if requests != 1 {
+		t.Fatalf("expected only the version request, got %d requests", requests)
+	}
+}
+
+func TestNegotiatedAutoRemove(t *testing.T) {
+	client := &Client{
+		negotiateVersion: true,
+		transport: newMockClient(nil, func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/version" {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"ApiVersion":"1.25"}`))),
+				}, nil
+			}
+			if req.URL.Path != "/v1.25/containers/create" {
+				return nil, fmt.Errorf("expected URL '/v1.25/containers/create', got '%s'", req.URL.Path)
+			}
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			if !strings.Contains(string(b), `"AutoRemove":true`) {
+				return nil, fmt.Errorf("expected AutoRemove in the request body, got %s", b)
+			}
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"Id":"container_id"}`))),
+			}, nil
+		}),
+	}
+
+	c, err := client.ContainerCreate(context.Background(), &container.Config{}, &container.HostConfig{AutoRemove: true}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != "container_id" {
+		t.Fatalf("expected container_id, got %s", c.ID)
+	}
+}
@@ -8,8 +8,16 @@ import (
 )
 
 // VolumeCreate creates a volume in the docker host.
+// It returns an ErrUnsupportedByVersion if the options include fields
+// that the API version of the client doesn't support.
 func (cli *Client) VolumeCreate(ctx context.Context, options types.VolumeCreateRequest) (types.Volume, error) {
 	var volume types.Volume
+	if err := cli.checkOptionsVersion(ctx,
+		versionedOptions{"VolumeCreateRequest", options, []map[string]string{volumeCreateVersions}},
+	); err != nil {
+		return volume, err
+	}
+
 	resp, err := cli.post(ctx, "/volumes/create", nil, options, nil)
 	if err != nil {
 		return volume, err
@@ -12,6 +12,10 @@ Other programs, like Docker Machine, can set the default Docker engine environme
 
 	cli, err := client.NewEnvClient()
 
+The client can also be configured with options, which can be combined to set only the values you need:
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithVersion("v1.22"))
+
 All request arguments are defined as typed structures in the types package. For instance, this is how to get all containers running in the host:
 
 	options := types.ContainerListOptions{All: true}
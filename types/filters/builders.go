@@ -0,0 +1,467 @@
+package filters
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/engine-api/types/versions"
+)
+
+// Resource is a kind of object that the daemon lists with filters.
+type Resource string
+
+const (
+	// ContainerResource is the resource of the container list filters.
+	ContainerResource Resource = "container"
+	// ImageResource is the resource of the image list filters.
+	ImageResource Resource = "image"
+	// VolumeResource is the resource of the volume list filters.
+	VolumeResource Resource = "volume"
+	// NetworkResource is the resource of the network list filters.
+	NetworkResource Resource = "network"
+	// EventResource is the resource of the event filters.
+	EventResource Resource = "event"
+)
+
+// acceptedKeys holds the filter keys accepted by the daemon for each resource,
+// with the minimum API version that accepts them.
+var acceptedKeys = map[Resource]map[string]string{
+	ContainerResource: {
+		"exited":    "1.15",
+		"status":    "1.15",
+		"label":     "1.18",
+		"id":        "1.22",
+		"name":      "1.22",
+		"ancestor":  "1.22",
+		"before":    "1.22",
+		"since":     "1.22",
+		"isolation": "1.22",
+		"volume":    "1.24",
+		"network":   "1.24",
+		"health":    "1.24",
+	},
+	ImageResource: {
+		"dangling": "1.15",
+		"label":    "1.18",
+		"before":   "1.24",
+		"since":    "1.24",
+	},
+	VolumeResource: {
+		"dangling": "1.21",
+		"name":     "1.24",
+		"driver":   "1.24",
+		"label":    "1.24",
+	},
+	NetworkResource: {
+		"id":     "1.22",
+		"name":   "1.22",
+		"type":   "1.22",
+		"driver": "1.24",
+		"label":  "1.24",
+	},
+	EventResource: {
+		"container": "1.18",
+		"event":     "1.18",
+		"image":     "1.18",
+		"label":     "1.21",
+		"type":      "1.22",
+		"volume":    "1.22",
+		"network":   "1.22",
+		"daemon":    "1.24",
+	},
+}
+
+// acceptedValues holds the minimum API version of the filter values
+// that the daemon accepts after the filter key.
+var acceptedValues = map[Resource]map[string]map[string]string{
+	ContainerResource: {
+		"status": {"removing": "1.25"},
+	},
+}
+
+var (
+	containerStatuses = map[string]bool{"created": true, "restarting": true, "running": true, "removing": true, "paused": true, "exited": true, "dead": true}
+	containerHealths  = map[string]bool{"starting": true, "healthy": true, "unhealthy": true, "none": true}
+	networkTypes      = map[string]bool{"custom": true, "builtin": true}
+	eventTypes        = map[string]bool{"container": true, "image": true, "volume": true, "network": true, "daemon": true}
+)
+
+// AcceptedKeys returns the filter keys that the daemon accepts for the resource
+// with the given API version, to use with Args.Validate.
+// An empty version is the latest version known by this package.
+func AcceptedKeys(resource Resource, version string) map[string]bool {
+	version = strings.TrimPrefix(version, "v")
+	accepted := make(map[string]bool)
+	for key, minVersion := range acceptedKeys[resource] {
+		if version == "" || versions.GreaterThanOrEqualTo(version, minVersion) {
+			accepted[key] = true
+		}
+	}
+	return accepted
+}
+
+// builder holds the filters added by the builder of a resource,
+// and the first invalid value added to them.
+type builder struct {
+	resource Resource
+	args     Args
+	err      error
+}
+
+func newBuilder(resource Resource) builder {
+	return builder{resource: resource, args: NewArgs()}
+}
+
+func (b *builder) add(key, value string) {
+	b.args.Add(key, value)
+}
+
+// addValid adds the value if it's one of the valid ones.
+func (b *builder) addValid(key, value string, valid map[string]bool) {
+	if !valid[value] {
+		if b.err == nil {
+			var values []string
+			for v := range valid {
+				values = append(values, v)
+			}
+			sort.Strings(values)
+			b.err = fmt.Errorf("Invalid %s filter %s '%s', expected one of %v", b.resource, key, value, values)
+		}
+		return
+	}
+	b.add(key, value)
+}
+
+func (b *builder) addLabel(key, value string) {
+	if value != "" {
+		key += "=" + value
+	}
+	b.add("label", key)
+}
+
+// build returns the filters, or an error if one of them has an invalid
+// value or is not accepted by the daemon with the API version.
+func (b *builder) build(version string) (Args, error) {
+	if b.err != nil {
+		return NewArgs(), b.err
+	}
+	version = strings.TrimPrefix(version, "v")
+
+	var keys []string
+	for key := range b.args.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		minVersion := acceptedKeys[b.resource][key]
+		if version != "" && versions.LessThan(version, minVersion) {
+			return NewArgs(), fmt.Errorf("The %s filter '%s' requires API version %s, but the Docker daemon API version is %s", b.resource, key, minVersion, version)
+		}
+		if version == "" {
+			continue
+		}
+		for _, value := range b.args.Get(key) {
+			minVersion, ok := acceptedValues[b.resource][key][value]
+			if ok && versions.LessThan(version, minVersion) {
+				return NewArgs(), fmt.Errorf("The %s filter %s '%s' requires API version %s, but the Docker daemon API version is %s", b.resource, key, value, minVersion, version)
+			}
+		}
+	}
+
+	args := NewArgs()
+	for key, values := range b.args.fields {
+		for value := range values {
+			args.Add(key, value)
+		}
+	}
+	return args, nil
+}
+
+// ContainerFilters builds the filters to list containers.
+type ContainerFilters struct {
+	b builder
+}
+
+// NewContainerFilters creates a new builder of container filters.
+func NewContainerFilters() *ContainerFilters {
+	return &ContainerFilters{b: newBuilder(ContainerResource)}
+}
+
+// Label filters the containers with the label key, and the value if it's not empty.
+func (f *ContainerFilters) Label(key, value string) *ContainerFilters {
+	f.b.addLabel(key, value)
+	return f
+}
+
+// Status filters the containers in one of the statuses: created, restarting,
+// running, removing, paused, exited or dead.
+func (f *ContainerFilters) Status(statuses ...string) *ContainerFilters {
+	for _, status := range statuses {
+		f.b.addValid("status", status, containerStatuses)
+	}
+	return f
+}
+
+// Exited filters the containers that exited with the code.
+func (f *ContainerFilters) Exited(code int) *ContainerFilters {
+	f.b.add("exited", strconv.Itoa(code))
+	return f
+}
+
+// ID filters the containers by id, or prefix of the id.
+func (f *ContainerFilters) ID(id string) *ContainerFilters {
+	f.b.add("id", id)
+	return f
+}
+
+// Name filters the containers by name.
+func (f *ContainerFilters) Name(name string) *ContainerFilters {
+	f.b.add("name", name)
+	return f
+}
+
+// Ancestor filters the containers created from the image, or from an image based on it.
+func (f *ContainerFilters) Ancestor(ref string) *ContainerFilters {
+	f.b.add("ancestor", ref)
+	return f
+}
+
+// Before filters the containers created before the container.
+func (f *ContainerFilters) Before(container string) *ContainerFilters {
+	f.b.add("before", container)
+	return f
+}
+
+// Since filters the containers created after the container.
+func (f *ContainerFilters) Since(container string) *ContainerFilters {
+	f.b.add("since", container)
+	return f
+}
+
+// Isolation filters the containers with the isolation technology, on Windows.
+func (f *ContainerFilters) Isolation(isolation string) *ContainerFilters {
+	f.b.add("isolation", isolation)
+	return f
+}
+
+// Volume filters the containers that mount the volume, by name or by mount point.
+func (f *ContainerFilters) Volume(volume string) *ContainerFilters {
+	f.b.add("volume", volume)
+	return f
+}
+
+// Network filters the containers connected to the network, by name or id.
+func (f *ContainerFilters) Network(network string) *ContainerFilters {
+	f.b.add("network", network)
+	return f
+}
+
+// Health filters the containers with the health status:
+// starting, healthy, unhealthy or none.
+func (f *ContainerFilters) Health(health string) *ContainerFilters {
+	f.b.addValid("health", health, containerHealths)
+	return f
+}
+
+// Args returns the filters for the API version, or an error if one of them
+// is invalid or not accepted by the daemon with that version.
+// An empty version is the latest version known by this package.
+func (f *ContainerFilters) Args(version string) (Args, error) {
+	return f.b.build(version)
+}
+
+// ImageFilters builds the filters to list images.
+type ImageFilters struct {
+	b builder
+}
+
+// NewImageFilters creates a new builder of image filters.
+func NewImageFilters() *ImageFilters {
+	return &ImageFilters{b: newBuilder(ImageResource)}
+}
+
+// Label filters the images with the label key, and the value if it's not empty.
+func (f *ImageFilters) Label(key, value string) *ImageFilters {
+	f.b.addLabel(key, value)
+	return f
+}
+
+// Dangling filters the images that are not tagged, or the tagged
+// images if dangling is false.
+func (f *ImageFilters) Dangling(dangling bool) *ImageFilters {
+	f.b.add("dangling", strconv.FormatBool(dangling))
+	return f
+}
+
+// Before filters the images created before the image.
+func (f *ImageFilters) Before(image string) *ImageFilters {
+	f.b.add("before", image)
+	return f
+}
+
+// Since filters the images created after the image.
+func (f *ImageFilters) Since(image string) *ImageFilters {
+	f.b.add("since", image)
+	return f
+}
+
+// Args returns the filters for the API version, like ContainerFilters.Args.
+func (f *ImageFilters) Args(version string) (Args, error) {
+	return f.b.build(version)
+}
+
+// VolumeFilters builds the filters to list volumes.
+type VolumeFilters struct {
+	b builder
+}
+
+// NewVolumeFilters creates a new builder of volume filters.
+func NewVolumeFilters() *VolumeFilters {
+	return &VolumeFilters{b: newBuilder(VolumeResource)}
+}
+
+// Label filters the volumes with the label key, and the value if it's not empty.
+func (f *VolumeFilters) Label(key, value string) *VolumeFilters {
+	f.b.addLabel(key, value)
+	return f
+}
+
+// Dangling filters the volumes that are not used by any container, or the
+// volumes in use if dangling is false.
+func (f *VolumeFilters) Dangling(dangling bool) *VolumeFilters {
+	f.b.add("dangling", strconv.FormatBool(dangling))
+	return f
+}
+
+// Name filters the volumes by name.
+func (f *VolumeFilters) Name(name string) *VolumeFilters {
+	f.b.add("name", name)
+	return f
+}
+
+// Driver filters the volumes by driver.
+func (f *VolumeFilters) Driver(driver string) *VolumeFilters {
+	f.b.add("driver", driver)
+	return f
+}
+
+// Args returns the filters for the API version, like ContainerFilters.Args.
+func (f *VolumeFilters) Args(version string) (Args, error) {
+	return f.b.build(version)
+}
+
+// NetworkFilters builds the filters to list networks.
+type NetworkFilters struct {
+	b builder
+}
+
+// NewNetworkFilters creates a new builder of network filters.
+func NewNetworkFilters() *NetworkFilters {
+	return &NetworkFilters{b: newBuilder(NetworkResource)}
+}
+
+// Label filters the networks with the label key, and the value if it's not empty.
+func (f *NetworkFilters) Label(key, value string) *NetworkFilters {
+	f.b.addLabel(key, value)
+	return f
+}
+
+// ID filters the networks by id, or part of the id.
+func (f *NetworkFilters) ID(id string) *NetworkFilters {
+	f.b.add("id", id)
+	return f
+}
+
+// Name filters the networks by name, or part of the name.
+func (f *NetworkFilters) Name(name string) *NetworkFilters {
+	f.b.add("name", name)
+	return f
+}
+
+// Type filters the networks by type: custom or builtin.
+func (f *NetworkFilters) Type(networkType string) *NetworkFilters {
+	f.b.addValid("type", networkType, networkTypes)
+	return f
+}
+
+// Driver filters the networks by driver.
+func (f *NetworkFilters) Driver(driver string) *NetworkFilters {
+	f.b.add("driver", driver)
+	return f
+}
+
+// Args returns the filters for the API version, like ContainerFilters.Args.
+func (f *NetworkFilters) Args(version string) (Args, error) {
+	return f.b.build(version)
+}
+
+// EventFilters builds the filters to receive events.
+type EventFilters struct {
+	b builder
+}
+
+// NewEventFilters creates a new builder of event filters.
+func NewEventFilters() *EventFilters {
+	return &EventFilters{b: newBuilder(EventResource)}
+}
+
+// Label filters the events of the objects with the label key,
+// and the value if it's not empty.
+func (f *EventFilters) Label(key, value string) *EventFilters {
+	f.b.addLabel(key, value)
+	return f
+}
+
+// Type filters the events of one of the types of objects: container, image,
+// volume, network or daemon.
+func (f *EventFilters) Type(types ...string) *EventFilters {
+	for _, t := range types {
+		f.b.addValid("type", t, eventTypes)
+	}
+	return f
+}
+
+// Event filters the events by action, like start or die.
+func (f *EventFilters) Event(actions ...string) *EventFilters {
+	for _, action := range actions {
+		f.b.add("event", action)
+	}
+	return f
+}
+
+// Container filters the events of the container, by name or id.
+func (f *EventFilters) Container(container string) *EventFilters {
+	f.b.add("container", container)
+	return f
+}
+
+// Image filters the events of the image, or of the containers created from it.
+func (f *EventFilters) Image(image string) *EventFilters {
+	f.b.add("image", image)
+	return f
+}
+
+// Volume filters the events of the volume.
+func (f *EventFilters) Volume(volume string) *EventFilters {
+	f.b.add("volume", volume)
+	return f
+}
+
+// Network filters the events of the network, by name or id.
+func (f *EventFilters) Network(network string) *EventFilters {
+	f.b.add("network", network)
+	return f
+}
+
+// Daemon filters the events of the daemon, by name or id.
+func (f *EventFilters) Daemon(daemon string) *EventFilters {
+	f.b.add("daemon", daemon)
+	return f
+}
+
+// Args returns the filters for the API version, like ContainerFilters.Args.
+func (f *EventFilters) Args(version string) (Args, error) {
+	return f.b.build(version)
+}
@@ -0,0 +1,158 @@
+package filters
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func assertArgs(t *testing.T, args Args, expected map[string][]string) {
+	actual := map[string][]string{}
+	for key := range args.fields {
+		values := args.Get(key)
+		sort.Strings(values)
+		actual[key] = values
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestContainerFilters(t *testing.T) {
+	args, err := NewContainerFilters().
+		Label("com.example.app", "").
+		Label("env", "prod").
+		Status("running", "paused").
+		Exited(0).
+		Ancestor("ubuntu").
+		Before("abc").
+		Args("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertArgs(t, args, map[string][]string{
+		"label":    {"com.example.app", "env=prod"},
+		"status":   {"paused", "running"},
+		"exited":   {"0"},
+		"ancestor": {"ubuntu"},
+		"before":   {"abc"},
+	})
+	if err := args.Validate(AcceptedKeys(ContainerResource, "1.22")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContainerFiltersInvalidValue(t *testing.T) {
+	_, err := NewContainerFilters().Status("runing").Health("fine").Args("")
+	if err == nil || !strings.Contains(err.Error(), "Invalid container filter status 'runing'") {
+		t.Fatalf("expected an invalid status error, got %v", err)
+	}
+}
+
+func TestFiltersVersion(t *testing.T) {
+	f := NewContainerFilters().Label("a", "b").Ancestor("ubuntu")
+	if _, err := f.Args("1.22"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.Args("1.21")
+	if err == nil || err.Error() != "The container filter 'ancestor' requires API version 1.22, but the Docker daemon API version is 1.21" {
+		t.Fatalf("expected a version error, got %v", err)
+	}
+
+	if _, err := NewVolumeFilters().Dangling(true).Args("1.21"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewVolumeFilters().Name("data").Args("1.23"); err == nil {
+		t.Fatal("expected an error filtering volumes by name with API 1.23")
+	}
+}
+
+func TestFiltersVersionPrefix(t *testing.T) {
+	f := NewContainerFilters().Ancestor("ubuntu")
+	if _, err := f.Args("v1.22"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.Args("v1.21")
+	if err == nil || err.Error() != "The container filter 'ancestor' requires API version 1.22, but the Docker daemon API version is 1.21" {
+		t.Fatalf("expected a version error, got %v", err)
+	}
+	if accepted := AcceptedKeys(NetworkResource, "v1.22"); !reflect.DeepEqual(accepted, AcceptedKeys(NetworkResource, "1.22")) {
+		t.Fatalf("expected the keys accepted with API 1.22, got %v", accepted)
+	}
+}
+
+func TestFiltersValueVersion(t *testing.T) {
+	f := NewContainerFilters().Status("removing")
+	if _, err := f.Args("1.25"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := f.Args("1.24")
+	if err == nil || err.Error() != "The container filter status 'removing' requires API version 1.25, but the Docker daemon API version is 1.24" {
+		t.Fatalf("expected a version error, got %v", err)
+	}
+	if _, err := NewContainerFilters().Status("running").Args("1.24"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImageFilters(t *testing.T) {
+	args, err := NewImageFilters().Dangling(true).Label("a", "").Since("ubuntu").Args("1.24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertArgs(t, args, map[string][]string{
+		"dangling": {"true"},
+		"label":    {"a"},
+		"since":    {"ubuntu"},
+	})
+}
+
+func TestNetworkFilters(t *testing.T) {
+	args, err := NewNetworkFilters().Type("custom").Driver("bridge").Name("net").Args("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertArgs(t, args, map[string][]string{
+		"type":   {"custom"},
+		"driver": {"bridge"},
+		"name":   {"net"},
+	})
+
+	if _, err := NewNetworkFilters().Type("overlay").Args(""); err == nil {
+		t.Fatal("expected an invalid network type error")
+	}
+}
+
+func TestEventFilters(t *testing.T) {
+	args, err := NewEventFilters().Type("container", "network").Event("start", "die").Container("web").Args("1.22")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertArgs(t, args, map[string][]string{
+		"type":      {"container", "network"},
+		"event":     {"die", "start"},
+		"container": {"web"},
+	})
+
+	if _, err := NewEventFilters().Type("plugin").Args(""); err == nil {
+		t.Fatal("expected an invalid event type error")
+	}
+	if _, err := NewEventFilters().Daemon("d").Args("1.23"); err == nil {
+		t.Fatal("expected an error filtering daemon events with API 1.23")
+	}
+}
+
+func TestAcceptedKeys(t *testing.T) {
+	accepted := AcceptedKeys(NetworkResource, "1.22")
+	expected := map[string]bool{"id": true, "name": true, "type": true}
+	if !reflect.DeepEqual(accepted, expected) {
+		t.Fatalf("expected %v, got %v", expected, accepted)
+	}
+
+	args := NewArgs()
+	args.Add("lable", "a=b")
+	if err := args.Validate(AcceptedKeys(ContainerResource, "")); err == nil {
+		t.Fatal("expected an invalid filter error")
+	}
+}
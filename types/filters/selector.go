@@ -0,0 +1,351 @@
+package filters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Operator is the operator of a label selector requirement.
+type Operator string
+
+const (
+	// Exists requires the label to be set: `key`.
+	Exists Operator = "exists"
+	// DoesNotExist requires the label not to be set: `!key`.
+	DoesNotExist Operator = "!"
+	// Equals requires the label to have the value: `key=value` or `key==value`.
+	Equals Operator = "="
+	// NotEquals requires the label not to have the value,
+	// or not to be set: `key!=value`.
+	NotEquals Operator = "!="
+	// In requires the label to have one of the values: `key in (v1,v2)`.
+	In Operator = "in"
+	// NotIn requires the label not to have any of the values,
+	// or not to be set: `key notin (v1,v2)`.
+	NotIn Operator = "notin"
+)
+
+// Requirement is a condition on one label of a label selector.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	// Values holds the values of the Equals, NotEquals, In and NotIn operators.
+	Values []string
+}
+
+// Matches returns true if the labels meet the requirement.
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	case Equals, In:
+		return ok && r.hasValue(value)
+	case NotEquals, NotIn:
+		return !ok || !r.hasValue(value)
+	}
+	return false
+}
+
+func (r Requirement) hasValue(value string) bool {
+	for _, v := range r.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the requirement in the label selector syntax.
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	case Equals, NotEquals:
+		return r.Key + string(r.Operator) + r.Values[0]
+	}
+	return fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+}
+
+// Selector is a label selector, a list of requirements that labels must all meet.
+// The selectors use the syntax of Kubernetes label selectors, with the
+// requirements separated by commas:
+//
+//	env in (prod,staging),tier!=cache,!legacy,app
+//
+// An empty selector matches all the labels.
+type Selector struct {
+	Requirements []Requirement
+}
+
+// ParseSelector parses a label selector.
+func ParseSelector(selector string) (Selector, error) {
+	p := &selectorParser{lexer: selectorLexer{input: selector}}
+	s, err := p.parse()
+	if err != nil {
+		return Selector{}, fmt.Errorf("Invalid label selector %q: %v", selector, err)
+	}
+	return s, nil
+}
+
+// Matches returns true if the labels meet all the requirements of the selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s.Requirements {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns true if the selector has no requirements.
+func (s Selector) Empty() bool {
+	return len(s.Requirements) == 0
+}
+
+// String returns the selector in the label selector syntax.
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s.Requirements))
+	for _, r := range s.Requirements {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// Compile splits the selector between the label filters that the daemon can
+// evaluate, the Exists and Equals requirements and the In requirements with
+// one value, and the rest of the selector, which the client must evaluate on
+// the labels of the objects returned by the daemon.
+func (s Selector) Compile() (Args, Selector) {
+	args := NewArgs()
+	var rest Selector
+	for _, r := range s.Requirements {
+		switch {
+		case r.Operator == Exists:
+			args.Add("label", r.Key)
+		case (r.Operator == Equals || r.Operator == In) && len(r.Values) == 1:
+			args.Add("label", r.Key+"="+r.Values[0])
+		default:
+			rest.Requirements = append(rest.Requirements, r)
+		}
+	}
+	return args, rest
+}
+
+type selectorToken int
+
+const (
+	tokenEOF selectorToken = iota
+	tokenIdentifier
+	tokenNot
+	tokenEquals
+	tokenDoubleEquals
+	tokenNotEquals
+	tokenOpenParen
+	tokenCloseParen
+	tokenComma
+)
+
+var tokenNames = map[selectorToken]string{
+	tokenEOF:          "end of selector",
+	tokenIdentifier:   "identifier",
+	tokenNot:          "'!'",
+	tokenEquals:       "'='",
+	tokenDoubleEquals: "'=='",
+	tokenNotEquals:    "'!='",
+	tokenOpenParen:    "'('",
+	tokenCloseParen:   "')'",
+	tokenComma:        "','",
+}
+
+// selectorLexer splits a label selector in tokens.
+type selectorLexer struct {
+	input string
+	pos   int
+}
+
+// next returns the next token, its value, and its position in the input.
+func (l *selectorLexer) next() (selectorToken, string, int) {
+	for l.pos < len(l.input) && isSelectorSpace(l.input[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return tokenEOF, "", start
+	}
+
+	switch c := l.input[l.pos]; c {
+	case '!':
+		if l.pos+1 < len(l.input) && l.input[l.pos+1] == '=' {
+			l.pos += 2
+			return tokenNotEquals, "!=", start
+		}
+		l.pos++
+		return tokenNot, "!", start
+	case '=':
+		if l.pos+1 < len(l.input) && l.input[l.pos+1] == '=' {
+			l.pos += 2
+			return tokenDoubleEquals, "==", start
+		}
+		l.pos++
+		return tokenEquals, "=", start
+	case '(':
+		l.pos++
+		return tokenOpenParen, "(", start
+	case ')':
+		l.pos++
+		return tokenCloseParen, ")", start
+	case ',':
+		l.pos++
+		return tokenComma, ",", start
+	}
+
+	for l.pos < len(l.input) && !isSelectorSpace(l.input[l.pos]) && !strings.ContainsRune("!=(),", rune(l.input[l.pos])) {
+		l.pos++
+	}
+	return tokenIdentifier, l.input[start:l.pos], start
+}
+
+func isSelectorSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// selectorParser parses a label selector with one token of lookahead.
+type selectorParser struct {
+	lexer   selectorLexer
+	tok     selectorToken
+	value   string
+	pos     int
+	started bool
+}
+
+func (p *selectorParser) peek() (selectorToken, string) {
+	if !p.started {
+		p.tok, p.value, p.pos = p.lexer.next()
+		p.started = true
+	}
+	return p.tok, p.value
+}
+
+func (p *selectorParser) consume() (selectorToken, string, int) {
+	tok, value := p.peek()
+	pos := p.pos
+	p.tok, p.value, p.pos = p.lexer.next()
+	return tok, value, pos
+}
+
+func (p *selectorParser) expect(expected selectorToken) (string, error) {
+	tok, value, pos := p.consume()
+	if tok != expected {
+		return "", unexpectedToken(tok, value, pos, tokenNames[expected])
+	}
+	return value, nil
+}
+
+func unexpectedToken(tok selectorToken, value string, pos int, expected string) error {
+	found := tokenNames[tok]
+	if tok == tokenIdentifier {
+		found = fmt.Sprintf("'%s'", value)
+	}
+	return fmt.Errorf("found %s at position %d, expected %s", found, pos, expected)
+}
+
+func (p *selectorParser) parse() (Selector, error) {
+	var s Selector
+	if tok, _ := p.peek(); tok == tokenEOF {
+		return s, nil
+	}
+	for {
+		r, err := p.parseRequirement()
+		if err != nil {
+			return Selector{}, err
+		}
+		s.Requirements = append(s.Requirements, r)
+
+		tok, value, pos := p.consume()
+		switch tok {
+		case tokenEOF:
+			return s, nil
+		case tokenComma:
+			continue
+		}
+		return Selector{}, unexpectedToken(tok, value, pos, "',' or end of selector")
+	}
+}
+
+func (p *selectorParser) parseRequirement() (Requirement, error) {
+	if tok, _ := p.peek(); tok == tokenNot {
+		p.consume()
+		key, err := p.expect(tokenIdentifier)
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: DoesNotExist}, nil
+	}
+
+	key, err := p.expect(tokenIdentifier)
+	if err != nil {
+		return Requirement{}, err
+	}
+
+	tok, value := p.peek()
+	switch {
+	case tok == tokenEOF || tok == tokenComma:
+		return Requirement{Key: key, Operator: Exists}, nil
+	case tok == tokenEquals || tok == tokenDoubleEquals || tok == tokenNotEquals:
+		p.consume()
+		op := Equals
+		if tok == tokenNotEquals {
+			op = NotEquals
+		}
+		// An empty value is the value of labels set without a value.
+		var v string
+		if next, _ := p.peek(); next == tokenIdentifier {
+			_, v, _ = p.consume()
+		}
+		return Requirement{Key: key, Operator: op, Values: []string{v}}, nil
+	case tok == tokenIdentifier && (value == string(In) || value == string(NotIn)):
+		p.consume()
+		values, err := p.parseValues()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key, Operator: Operator(value), Values: values}, nil
+	}
+	_, _, pos := p.consume()
+	return Requirement{}, unexpectedToken(tok, value, pos, "'=', '==', '!=', 'in', 'notin', ',' or end of selector")
+}
+
+// parseValues parses a set of values: (v1,v2).
+func (p *selectorParser) parseValues() ([]string, error) {
+	if _, err := p.expect(tokenOpenParen); err != nil {
+		return nil, err
+	}
+	var values []string
+	seen := map[string]bool{}
+	for {
+		v, err := p.expect(tokenIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+
+		tok, value, pos := p.consume()
+		switch tok {
+		case tokenCloseParen:
+			sort.Strings(values)
+			return values, nil
+		case tokenComma:
+			continue
+		}
+		return nil, unexpectedToken(tok, value, pos, "',' or ')'")
+	}
+}
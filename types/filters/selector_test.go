@@ -0,0 +1,127 @@
+package filters
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	cases := []struct {
+		selector string
+		expected []Requirement
+	}{
+		{"", nil},
+		{"  ", nil},
+		{"app", []Requirement{{Key: "app", Operator: Exists}}},
+		{"!legacy", []Requirement{{Key: "legacy", Operator: DoesNotExist}}},
+		{"tier=front", []Requirement{{Key: "tier", Operator: Equals, Values: []string{"front"}}}},
+		{"tier==front", []Requirement{{Key: "tier", Operator: Equals, Values: []string{"front"}}}},
+		{"tier!=cache", []Requirement{{Key: "tier", Operator: NotEquals, Values: []string{"cache"}}}},
+		{"tier=", []Requirement{{Key: "tier", Operator: Equals, Values: []string{""}}}},
+		{"env in (staging, prod,prod)", []Requirement{{Key: "env", Operator: In, Values: []string{"prod", "staging"}}}},
+		{"env notin (dev)", []Requirement{{Key: "env", Operator: NotIn, Values: []string{"dev"}}}},
+		{
+			"env in (prod,staging), tier!=cache,!legacy,com.example.app",
+			[]Requirement{
+				{Key: "env", Operator: In, Values: []string{"prod", "staging"}},
+				{Key: "tier", Operator: NotEquals, Values: []string{"cache"}},
+				{Key: "legacy", Operator: DoesNotExist},
+				{Key: "com.example.app", Operator: Exists},
+			},
+		},
+	}
+	for _, c := range cases {
+		s, err := ParseSelector(c.selector)
+		if err != nil {
+			t.Fatalf("%q: %v", c.selector, err)
+		}
+		if !reflect.DeepEqual(s.Requirements, c.expected) {
+			t.Fatalf("%q: expected %+v, got %+v", c.selector, c.expected, s.Requirements)
+		}
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	cases := []struct {
+		selector      string
+		expectedError string
+	}{
+		{",", "found ',' at position 0, expected identifier"},
+		{"app,", "found end of selector at position 4, expected identifier"},
+		{"!", "found end of selector at position 1, expected identifier"},
+		{"env in prod", "found 'prod' at position 7, expected '('"},
+		{"env in (prod", "found end of selector at position 12, expected ',' or ')'"},
+		{"env in ()", "found ')' at position 8, expected identifier"},
+		{"env prod", "found 'prod' at position 4, expected '=', '==', '!=', 'in', 'notin', ',' or end of selector"},
+		{"a=b c", "found 'c' at position 4, expected ',' or end of selector"},
+	}
+	for _, c := range cases {
+		_, err := ParseSelector(c.selector)
+		if err == nil || !strings.Contains(err.Error(), c.expectedError) {
+			t.Fatalf("%q: expected an error containing %q, got %v", c.selector, c.expectedError, err)
+		}
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "tier": "front", "app": ""}
+	cases := []struct {
+		selector string
+		expected bool
+	}{
+		{"", true},
+		{"app", true},
+		{"app=", true},
+		{"!app", false},
+		{"!legacy", true},
+		{"env=prod", true},
+		{"env!=prod", false},
+		{"version!=2", true},
+		{"env in (prod,staging)", true},
+		{"env in (dev,staging)", false},
+		{"env notin (dev,staging)", true},
+		{"version notin (1)", true},
+		{"env in (prod),tier!=cache,!legacy,app", true},
+		{"env in (prod),tier=cache", false},
+	}
+	for _, c := range cases {
+		s, err := ParseSelector(c.selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := s.Matches(labels); m != c.expected {
+			t.Fatalf("%q: expected %v, got %v", c.selector, c.expected, m)
+		}
+	}
+}
+
+func TestSelectorCompile(t *testing.T) {
+	s, err := ParseSelector("env in (prod),tier!=cache,!legacy,app,region in (eu,us),version=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, rest := s.Compile()
+	assertArgs(t, args, map[string][]string{
+		"label": {"app", "env=prod", "version=2"},
+	})
+	if rest.String() != "tier!=cache,!legacy,region in (eu,us)" {
+		t.Fatalf("unexpected client-side selector %q", rest.String())
+	}
+
+	args, rest = Selector{}.Compile()
+	if args.Len() != 0 || !rest.Empty() {
+		t.Fatalf("expected empty filters and selector, got %v and %v", args, rest)
+	}
+}
+
+func TestSelectorString(t *testing.T) {
+	const selector = "env in (prod,staging),tier!=cache,!legacy,app,version=2"
+	s, err := ParseSelector(selector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != selector {
+		t.Fatalf("expected %q, got %q", selector, s.String())
+	}
+}
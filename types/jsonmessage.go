@@ -0,0 +1,67 @@
+package types
+
+import "encoding/json"
+
+// JSONError is an error reported by the server in a stream of JSON messages.
+type JSONError struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Error returns the message of the error.
+func (e *JSONError) Error() string {
+	return e.Message
+}
+
+// JSONProgress holds the progress of an operation reported in a JSON message.
+type JSONProgress struct {
+	// Current is the number of bytes processed so far.
+	Current int64 `json:"current,omitempty"`
+	// Total is the total number of bytes to process, it's zero if it's unknown.
+	Total int64 `json:"total,omitempty"`
+	// Start is the unix time when the operation started.
+	Start int64 `json:"start,omitempty"`
+}
+
+// JSONMessage is a message in the streams returned by the server
+// to pull, push, build, load and import images.
+type JSONMessage struct {
+	// Stream holds the output of a build.
+	Stream string `json:"stream,omitempty"`
+	// Status is the status of the operation, or of the layer with ID.
+	Status string `json:"status,omitempty"`
+	// Progress holds the progress of the operation, or of the layer with ID.
+	Progress *JSONProgress `json:"progressDetail,omitempty"`
+	// ProgressMessage is the progress formatted to be displayed in a terminal.
+	ProgressMessage string `json:"progress,omitempty"`
+	// ID is the ID of the layer or image the message is about, if any.
+	ID string `json:"id,omitempty"`
+	// From is the image the message is about, if any.
+	From string `json:"from,omitempty"`
+	// Time is the unix time of the message.
+	Time int64 `json:"time,omitempty"`
+	// TimeNano is the time of the message in nanoseconds.
+	TimeNano int64 `json:"timeNano,omitempty"`
+	// Error is the error that made the operation fail.
+	Error *JSONError `json:"errorDetail,omitempty"`
+	// ErrorMessage is the message of the error.
+	// This field is deprecated, use Error.Message.
+	ErrorMessage string `json:"error,omitempty"`
+	// Aux holds additional data about the result of the operation,
+	// like a BuildResult or a PushResult.
+	Aux *json.RawMessage `json:"aux,omitempty"`
+}
+
+// BuildResult holds the result of an image build,
+// sent in the Aux field of a JSON message.
+type BuildResult struct {
+	ID string
+}
+
+// PushResult holds the result of an image push,
+// sent in the Aux field of a JSON message.
+type PushResult struct {
+	Tag    string
+	Digest string
+	Size   int
+}